@@ -0,0 +1,82 @@
+package forge
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"forge/internal/cache"
+	"forge/internal/progress"
+
+	"github.com/spf13/cobra"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the download cache",
+	Long: `Manage the cache of downloaded registry archives and manifests.
+
+The cache lives in %USERPROFILE%\.forge\cache. Cached files are revalidated
+on every pull, and 'forge pull --offline' installs from them without
+network access.`,
+}
+
+var cacheListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List cached downloads",
+	Args:  cobra.NoArgs,
+	Run:   runCacheList,
+}
+
+var cacheCleanCmd = &cobra.Command{
+	Use:   "clean",
+	Short: "Remove all cached downloads",
+	Args:  cobra.NoArgs,
+	Run:   runCacheClean,
+}
+
+func init() {
+	cacheCmd.AddCommand(cacheListCmd, cacheCleanCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
+
+func runCacheList(cmd *cobra.Command, args []string) {
+	c, err := cache.Default()
+	if err != nil {
+		exitWithError("failed to locate cache", err)
+	}
+
+	entries, err := c.List()
+	if err != nil {
+		exitWithError("failed to list cache", err)
+	}
+	if len(entries) == 0 {
+		fmt.Println("The download cache is empty.")
+		return
+	}
+
+	var total int64
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "URL\tSIZE\tFETCHED")
+	fmt.Fprintln(w, "---\t----\t-------")
+	for _, e := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", e.URL, progress.FormatBytes(e.Size), e.Fetched.Local().Format("2006-01-02 15:04"))
+		total += e.Size
+	}
+	w.Flush()
+
+	fmt.Printf("\n%d files, %s in %s\n", len(entries), progress.FormatBytes(total), c.Dir())
+}
+
+func runCacheClean(cmd *cobra.Command, args []string) {
+	c, err := cache.Default()
+	if err != nil {
+		exitWithError("failed to locate cache", err)
+	}
+
+	freed, err := c.Clean()
+	if err != nil {
+		exitWithError("failed to clean cache", err)
+	}
+	fmt.Printf("✓ Removed %s from the download cache\n", progress.FormatBytes(freed))
+}
@@ -7,6 +7,8 @@ import (
 
 	"forge/internal/executor"
 	"forge/internal/fileops"
+	"forge/internal/plan"
+	"forge/internal/remote"
 	"forge/internal/template"
 
 	"github.com/spf13/cobra"
@@ -23,17 +25,40 @@ var initCmd = &cobra.Command{
 Commands inherit your terminal's stdin/stdout/stderr, so interactive
 commands (like npm init, cargo init) work naturally.
 
-Target directory defaults to current working directory if not specified.`,
+Target directory defaults to current working directory if not specified.
+
+The template may also be a git+ URL, which is cloned into a temporary
+directory first (see 'forge pull --help' for the syntax).
+
+Use --dry-run to print the ordered plan of commands and file operations
+without executing anything, or --plan-json for machine-readable output.
+
+The first time a downloaded template is used, and whenever its content
+changes, forge lists every command, copy and append it will perform and
+asks before running anything. Decisions are kept in ~/.forge/trust.yaml.
+Templates forge did not download are trusted implicitly.
+
+A command policy, from the policy section of ~/.forge/config.yaml or a
+system-wide policy.yaml, can restrict which executables templates run;
+a template with a denied command is refused before anything runs.`,
 	Args: cobra.RangeArgs(1, 2),
 	Run:  runInit,
 }
 
+var initDryRun bool
+var initPlanJSON bool
+var initTrust bool
+
 func init() {
+	initCmd.Flags().BoolVar(&initDryRun, "dry-run", false, "Print the execution plan without running anything")
+	initCmd.Flags().BoolVar(&initPlanJSON, "plan-json", false, "Print the execution plan as JSON (implies --dry-run)")
+	initCmd.Flags().BoolVar(&initTrust, "trust", false, "Trust a new or changed downloaded template without asking")
 	rootCmd.AddCommand(initCmd)
 }
 
 func runInit(cmd *cobra.Command, args []string) {
-	templatePath := args[0]
+	templatePath, cleanup := fetchTemplateSource(args[0])
+	defer cleanup()
 
 	// Determine target directory
 	targetDir := "."
@@ -64,6 +89,23 @@ func runInit(cmd *cobra.Command, args []string) {
 		exitWithError("failed to load template", err)
 	}
 
+	if err := tmpl.CheckForgeVersion(Version); err != nil {
+		exitWithError("template is not compatible with this forge", err)
+	}
+
+	p, err := plan.Build(tmpl, resolvedTemplatePath, absTargetDir, false)
+	if err != nil {
+		exitWithError("failed to build plan", err)
+	}
+	if initDryRun || initPlanJSON {
+		printPlan(p, initPlanJSON)
+		return
+	}
+
+	pol := loadPolicy()
+	checkPolicy(pol, p)
+	requireTrust(args[0], resolvedTemplatePath, p, initTrust)
+
 	fmt.Printf("Initializing project from template: %s\n", tmpl.Name)
 
 	// Create target directory if it doesn't exist
@@ -77,6 +119,7 @@ func runInit(cmd *cobra.Command, args []string) {
 	if len(tmpl.Commands) > 0 {
 		fmt.Println("\nExecuting commands:")
 		exec := executor.New(absTargetDir, false, false) // false for testMode = forge init mode
+		exec.SetPolicy(pol)
 		for i, cmdDef := range tmpl.Commands {
 			fmt.Printf("  [%d/%d] %s\n", i+1, len(tmpl.Commands), cmdDef.String())
 			if err := exec.Run(cmdDef); err != nil {
@@ -128,3 +171,47 @@ func validateTargetDirectory(dir string) error {
 
 	return nil
 }
+
+// printPlan writes a dry-run plan to stdout as text or JSON
+func printPlan(p *plan.Plan, asJSON bool) {
+	var err error
+	if asJSON {
+		err = p.WriteJSON(os.Stdout)
+	} else {
+		err = p.WriteText(os.Stdout)
+	}
+	if err != nil {
+		exitWithError("failed to write plan", err)
+	}
+}
+
+// fetchTemplateSource returns a local path for a template argument. Git
+// sources are cloned into a temporary directory, removed by cleanup or, if
+// the command fails, by exitWithError.
+func fetchTemplateSource(arg string) (string, func()) {
+	if !remote.IsGitSource(arg) {
+		return arg, func() {}
+	}
+
+	src, err := remote.ParseGitSource(arg)
+	if err != nil {
+		exitWithError("invalid template source", err)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "forge-template-*")
+	if err != nil {
+		exitWithError("failed to create temp directory", err)
+	}
+	cleanup := func() { os.RemoveAll(tmpDir) }
+	exitCleanups = append(exitCleanups, cleanup)
+
+	templateDir := filepath.Join(tmpDir, src.TemplateName())
+	commit, err := remote.FetchGit(src, templateDir)
+	if err != nil {
+		exitWithError("failed to fetch template", err)
+	}
+
+	// Stderr keeps --plan-json output machine-readable
+	fmt.Fprintf(os.Stderr, "Fetched %s at commit %s\n", src, shortCommit(commit))
+	return templateDir, cleanup
+}
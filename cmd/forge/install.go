@@ -7,6 +7,8 @@ import (
 	"path/filepath"
 	"strings"
 
+	"forge/internal/config"
+
 	"github.com/spf13/cobra"
 )
 
@@ -159,10 +161,11 @@ func runInstall(cmd *cobra.Command, args []string) {
 		fmt.Printf("⚠ Warning: Global templates setup failed: %v\n", err)
 	}
 
-	// Write config file
+	// Write config file, keeping any registries already configured
 	if configPath != "" {
-		if err := os.MkdirAll(filepath.Dir(configPath), 0755); err == nil {
-			_ = os.WriteFile(configPath, []byte("templates_initialized: true\n"), 0644)
+		if cfg, err := config.Load(configPath); err == nil {
+			cfg.TemplatesInitialized = true
+			_ = cfg.Save(configPath)
 		}
 	}
 
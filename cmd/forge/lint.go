@@ -0,0 +1,54 @@
+package forge
+
+import (
+	"fmt"
+	"os"
+
+	"forge/internal/lint"
+
+	"github.com/spf13/cobra"
+)
+
+var lintCmd = &cobra.Command{
+	Use:   "lint <template-path>",
+	Short: "Check a template for mistakes without running it",
+	Long: `Check a template for problems that would otherwise only surface when
+forge init fails halfway:
+- Unknown or misspelled keys in template.yaml
+- Template names that forge new would reject
+- Copy and patch sources that do not exist
+- Append targets that no earlier step creates
+- Interactive commands without a test_cmd
+- Executables that cannot be found in PATH
+
+Errors make the command exit with a non-zero status; warnings do not.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runLint,
+}
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+}
+
+func runLint(cmd *cobra.Command, args []string) {
+	result, err := lint.Lint(args[0])
+	if err != nil {
+		exitWithError("failed to lint template", err)
+	}
+
+	for _, issue := range result.Issues {
+		fmt.Println(result.Format(issue))
+	}
+
+	errs := result.Count(lint.SeverityError)
+	warns := result.Count(lint.SeverityWarning)
+	if errs == 0 && warns == 0 {
+		fmt.Println("✓ No problems found")
+		return
+	}
+
+	fmt.Printf("\n%d error(s), %d warning(s)\n", errs, warns)
+	if errs > 0 {
+		os.Exit(1)
+	}
+}
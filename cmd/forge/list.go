@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"text/tabwriter"
 
 	"forge/internal/template"
@@ -107,6 +108,14 @@ func discoverTemplates(baseDir string) ([]templateInfo, error) {
 			return nil // Skip errors
 		}
 
+		// Skip hidden entries such as .forge-source.yaml and staging directories
+		if path != baseDir && strings.HasPrefix(info.Name(), ".") {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
 		// Look for template.yaml or *.yaml files
 		if !info.IsDir() && (info.Name() == "template.yaml" || filepath.Ext(info.Name()) == ".yaml") {
 			// Read file bytes and parse directly to avoid duplicated I/O
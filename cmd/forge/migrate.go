@@ -0,0 +1,72 @@
+package forge
+
+import (
+	"fmt"
+	"os"
+
+	"forge/internal/template"
+
+	"github.com/spf13/cobra"
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate <template-path>",
+	Short: "Upgrade a template to the current template.yaml format",
+	Long: `Rewrite an older template.yaml in place so it uses the current format
+(apiVersion ` + template.CurrentAPIVersion + `).
+
+The original file is kept next to it as template.yaml.bak. Comments and
+formatting are preserved.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runMigrate,
+}
+
+func init() {
+	rootCmd.AddCommand(migrateCmd)
+}
+
+func runMigrate(cmd *cobra.Command, args []string) {
+	resolvedPath, err := template.ResolveTemplatePath(args[0])
+	if err != nil {
+		exitWithError("failed to resolve template", err)
+	}
+
+	yamlPath, err := template.YAMLPath(resolvedPath)
+	if err != nil {
+		exitWithError("failed to resolve template", err)
+	}
+
+	data, err := os.ReadFile(yamlPath)
+	if err != nil {
+		exitWithError("failed to read template file", err)
+	}
+
+	migrated, from, err := template.Migrate(data)
+	if err != nil {
+		exitWithError("failed to migrate template", err)
+	}
+
+	if from == template.CurrentAPIVersion {
+		fmt.Printf("Template is already at %s. Nothing to do.\n", template.CurrentAPIVersion)
+		return
+	}
+
+	// Refuse to write a result that forge itself would reject
+	if _, err := template.Parse(migrated); err != nil {
+		exitWithError("migrated template is invalid; fix it and run migrate again", err)
+	}
+
+	backupPath := yamlPath + ".bak"
+	if err := os.WriteFile(backupPath, data, 0644); err != nil {
+		exitWithError("failed to write backup", err)
+	}
+	if err := os.WriteFile(yamlPath, migrated, 0644); err != nil {
+		exitWithError("failed to write migrated template", err)
+	}
+
+	if from == "" {
+		from = "unversioned"
+	}
+	fmt.Printf("✓ Migrated %s from %s to %s\n", yamlPath, from, template.CurrentAPIVersion)
+	fmt.Printf("  Backup: %s\n", backupPath)
+}
@@ -0,0 +1,234 @@
+package forge
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"forge/internal/lock"
+	"forge/internal/registry"
+	"forge/internal/remote"
+	"forge/internal/template"
+
+	"github.com/spf13/cobra"
+)
+
+var outdatedCmd = &cobra.Command{
+	Use:   "outdated",
+	Short: "List installed templates that are behind their registry",
+	Long: `Compare the global templates pulled from registries with the registry
+indexes. A template is outdated when the registry has a higher version in
+template.yaml, or the same version with different content.
+
+Templates installed from git or local sources are not checked.
+Run 'forge pull --upgrade-all' to update every outdated template.`,
+	Args: cobra.NoArgs,
+	Run:  runOutdated,
+}
+
+func init() {
+	outdatedCmd.Flags().BoolVar(&searchOffline, "offline", false, "Use cached indexes only")
+	rootCmd.AddCommand(outdatedCmd)
+}
+
+func runOutdated(cmd *cobra.Command, args []string) {
+	globalDir, err := getGlobalTemplatesDir()
+	if err != nil {
+		exitWithError("failed to locate templates", err)
+	}
+	cfg, opts := indexOptions()
+
+	lockPath, err := lock.Find()
+	if err != nil {
+		exitWithError("failed to locate forge.lock", err)
+	}
+	lf, err := lock.Load(lockPath)
+	if err != nil {
+		exitWithError("failed to read forge.lock", err)
+	}
+
+	installed, err := registryTemplates(globalDir, lf)
+	if err != nil {
+		exitWithError("failed to read installed templates", err)
+	}
+	if len(installed) == 0 {
+		fmt.Println("No templates pulled from registries are installed.")
+		return
+	}
+
+	statuses, failed := registry.CheckOutdated(cfg, installed, opts)
+	reportSkipped(failed)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	shown := 0
+	for _, s := range statuses {
+		if s.State == registry.StatusUpToDate {
+			continue
+		}
+		if shown == 0 {
+			fmt.Fprintln(w, "TEMPLATE\tINSTALLED\tLATEST\tREGISTRY\tSTATUS")
+		}
+		shown++
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", s.Ref.Name, dash(s.Version), dash(s.Latest.Version), dash(s.Registry), s.State)
+	}
+	w.Flush()
+
+	if shown == 0 {
+		fmt.Printf("All %d registry templates are up to date.\n", len(statuses))
+		return
+	}
+	fmt.Println("\nRun 'forge pull --upgrade-all' to update the outdated templates.")
+}
+
+// registryTemplates returns the installed global templates that were pulled
+// from a registry, i.e. those whose recorded source is not a git or local
+// one. The recorded source, or for older installs the lockfile, tells which
+// registry each came from when it is known.
+func registryTemplates(globalDir string, lf *lock.File) ([]registry.LocalTemplate, error) {
+	dirs, err := os.ReadDir(globalDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var templates []registry.LocalTemplate
+	for _, d := range dirs {
+		if !d.IsDir() || strings.HasPrefix(d.Name(), ".") {
+			continue
+		}
+		dir := filepath.Join(globalDir, d.Name())
+		data, err := os.ReadFile(filepath.Join(dir, "template.yaml"))
+		if err != nil {
+			continue
+		}
+		info, err := remote.ReadSourceInfo(dir)
+		if err != nil {
+			continue
+		}
+		source := ""
+		if info != nil {
+			source = info.Source
+		} else if entry, ok := lf.Get(d.Name()); ok {
+			source = entry.Source
+		}
+		if remote.IsGitSource(source) || remote.IsLocalSource(source) {
+			continue
+		}
+
+		ref := registry.Ref{Name: d.Name()}
+		if recorded, err := registry.ParseRef(source); err == nil && recorded.Name == d.Name() {
+			ref = recorded
+		}
+
+		t := registry.LocalTemplate{Ref: ref}
+		if tmpl, err := template.Parse(data); err == nil {
+			t.Version = tmpl.Version
+		}
+		if t.Hash, err = lock.HashDir(dir, remote.SourceFile); err != nil {
+			return nil, err
+		}
+		templates = append(templates, t)
+	}
+	return templates, nil
+}
+
+// reportSkipped warns about registries that could not be read
+func reportSkipped(failed map[string]error) {
+	names := make([]string, 0, len(failed))
+	for name := range failed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "Warning: skipped registry '%s': %v\n", name, failed[name])
+	}
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// upgradeAll pulls every outdated registry template, showing how each
+// template.yaml changes and asking before anything is replaced
+func (p *puller) upgradeAll() error {
+	installed, err := registryTemplates(p.globalDir, p.lock)
+	if err != nil {
+		return err
+	}
+	statuses, failed := registry.CheckOutdated(p.cfg, installed, p.opts)
+	reportSkipped(failed)
+
+	var entries []lock.Entry
+	for _, s := range statuses {
+		if !s.Outdated() {
+			continue
+		}
+		ref := registry.Ref{Registry: s.Registry, Name: s.Ref.Name}
+		_, pin, err := registry.Pull(p.cfg, ref, p.scratch, p.opts)
+		if err != nil {
+			fmt.Printf("✗ %s: %v\n", ref, err)
+			continue
+		}
+
+		fmt.Printf("\n%s (%s) %s\n", ref.Name, s.Registry, s)
+		for _, change := range templateChanges(filepath.Join(p.globalDir, ref.Name), filepath.Join(p.scratch, ref.Name)) {
+			fmt.Printf("  %s\n", change)
+		}
+		entries = append(entries, registryEntry(ref.Name, ref.Registry, pin))
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("All registry templates are up to date.")
+		return nil
+	}
+
+	fmt.Println()
+	if !pullYes {
+		fmt.Printf("Upgrade %d templates? [y/N]: ", len(entries))
+		var response string
+		fmt.Scanln(&response)
+		if response != "y" && response != "yes" {
+			fmt.Println("Upgrade cancelled.")
+			return nil
+		}
+	}
+
+	failures := 0
+	for _, entry := range entries {
+		if err := p.install(entry); err != nil {
+			fmt.Printf("✗ %s: %v\n", entry.Name, err)
+			failures++
+		}
+	}
+	fmt.Printf("Completed. %d templates upgraded.\n", len(entries)-failures)
+	if failures > 0 {
+		return fmt.Errorf("%d templates were not upgraded", failures)
+	}
+	return nil
+}
+
+// templateChanges summarises the template.yaml differences between two
+// template directories
+func templateChanges(installedDir, newDir string) []string {
+	from, err := template.Load(installedDir)
+	if err != nil {
+		return []string{"(installed template.yaml could not be read)"}
+	}
+	to, err := template.Load(newDir)
+	if err != nil {
+		return []string{fmt.Sprintf("(new template.yaml is invalid: %v)", err)}
+	}
+	changes := template.Diff(from, to)
+	if len(changes) == 0 {
+		return []string{"template.yaml unchanged; other files differ"}
+	}
+	return changes
+}
@@ -0,0 +1,65 @@
+package forge
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"forge/internal/config"
+	"forge/internal/lock"
+	"forge/internal/registry"
+	"forge/internal/remote"
+)
+
+func TestRegistryTemplatesAfterPull(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, _ := zw.Create("templates-main/python/template.yaml")
+	w.Write([]byte("name: python\nversion: 1.0.0\n"))
+	zw.Close()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(buf.Bytes())
+	}))
+	defer srv.Close()
+
+	globalDir := t.TempDir()
+	cfg := &config.Config{Registries: []config.Registry{
+		{Name: "official", URL: srv.URL + "/official.zip", Type: config.RegistryTypeZip},
+	}}
+	if _, _, err := registry.Pull(cfg, registry.Ref{Name: "python"}, globalDir, registry.Options{}); err != nil {
+		t.Fatalf("Pull() error = %v", err)
+	}
+
+	// Templates from git and local sources are not registry templates
+	for name, source := range map[string]string{
+		"gitty": "git+https://example.com/org/repo//gitty@v1",
+		"local": filepath.Join(t.TempDir(), "local.zip"),
+	} {
+		dir := filepath.Join(globalDir, name)
+		os.MkdirAll(dir, 0755)
+		os.WriteFile(filepath.Join(dir, "template.yaml"), []byte("name: "+name+"\n"), 0644)
+		if err := remote.WriteSourceInfo(dir, remote.SourceInfo{Source: source}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lf, err := lock.Load(filepath.Join(t.TempDir(), "forge.lock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	templates, err := registryTemplates(globalDir, lf)
+	if err != nil {
+		t.Fatalf("registryTemplates() error = %v", err)
+	}
+	if len(templates) != 1 {
+		t.Fatalf("registryTemplates() = %+v, want only python", templates)
+	}
+	got := templates[0]
+	if got.Ref != (registry.Ref{Registry: "official", Name: "python"}) || got.Version != "1.0.0" {
+		t.Errorf("registryTemplates() = %+v, want official/python at 1.0.0", got)
+	}
+}
@@ -0,0 +1,120 @@
+package forge
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"forge/internal/config"
+	"forge/internal/lint"
+	"forge/internal/pack"
+	"forge/internal/progress"
+	"forge/internal/registry"
+	"forge/internal/template"
+
+	"github.com/spf13/cobra"
+)
+
+var packCmd = &cobra.Command{
+	Use:   "pack <template>",
+	Short: "Package a template as a versioned archive",
+	Long: `Lint a template and package it as <name>-<version>.tar.gz, with a
+<name>-<version>.manifest.json listing its metadata, the SHA-256 of every
+file and of the archive, and the content checksum forge pull verifies.
+
+Archives are reproducible: files are stored in sorted order with fixed
+timestamps, owners and modes, so packing unchanged files gives an identical
+archive. template.yaml must declare a version. Lint errors stop packing;
+warnings do not.
+
+Examples:
+  forge pack ./templates/python
+  forge pack python -o dist`,
+	Args: cobra.ExactArgs(1),
+	Run:  runPack,
+}
+
+var publishCmd = &cobra.Command{
+	Use:   "publish <archive>",
+	Short: "Upload a packed template to an index registry",
+	Long: `Upload an archive written by 'forge pack', together with its manifest, to
+an index registry that accepts uploads at api/publish next to its
+index.json. The registry verifies the archive against the manifest and
+refuses to overwrite a version it already has.
+
+A registry requiring a token takes it from http.tokens in config.yaml,
+keyed by the registry's host name.
+
+Examples:
+  forge publish dist/python-1.2.0.tar.gz --registry team`,
+	Args: cobra.ExactArgs(1),
+	Run:  runPublish,
+}
+
+var packOutput string
+var publishRegistry string
+
+func init() {
+	packCmd.Flags().StringVarP(&packOutput, "output", "o", "dist", "Directory to write the archive and manifest to")
+	publishCmd.Flags().StringVar(&publishRegistry, "registry", "", "Registry to publish to (required)")
+	publishCmd.MarkFlagRequired("registry")
+	rootCmd.AddCommand(packCmd, publishCmd)
+}
+
+func runPack(cmd *cobra.Command, args []string) {
+	resolved, err := template.ResolveTemplatePath(args[0])
+	if err != nil {
+		exitWithError("template not found", err)
+	}
+	yamlPath, err := template.YAMLPath(resolved)
+	if err != nil {
+		exitWithError("template not found", err)
+	}
+	dir := filepath.Dir(yamlPath)
+
+	result, err := lint.Lint(dir)
+	if err != nil {
+		exitWithError("failed to lint template", err)
+	}
+	for _, issue := range result.Issues {
+		fmt.Println(result.Format(issue))
+	}
+	if result.HasErrors() {
+		exitWithError(fmt.Sprintf("%d lint error(s); fix them before packing", result.Count(lint.SeverityError)), nil)
+	}
+
+	m, err := pack.Pack(dir, packOutput)
+	if err != nil {
+		exitWithError("failed to pack template", err)
+	}
+
+	archive := filepath.Join(packOutput, m.Archive)
+	size := int64(0)
+	if info, err := os.Stat(archive); err == nil {
+		size = info.Size()
+	}
+	fmt.Printf("✓ Packed %s %s (%d files, %s)\n", m.Name, m.Version, len(m.Files), progress.FormatBytes(size))
+	fmt.Printf("  %s\n", archive)
+	fmt.Printf("  %s\n", pack.ManifestPath(archive))
+	fmt.Printf("  checksum %s\n", m.Checksum)
+}
+
+func runPublish(cmd *cobra.Command, args []string) {
+	cfg, err := config.LoadDefault()
+	if err != nil {
+		exitWithError("failed to load config", err)
+	}
+	if err := configureHTTP(cfg); err != nil {
+		exitWithError("failed to configure uploads", err)
+	}
+	reg, ok := cfg.Registry(publishRegistry)
+	if !ok {
+		exitWithError(fmt.Sprintf("unknown registry '%s' (see 'forge registry list')", publishRegistry), nil)
+	}
+
+	entry, err := registry.Publish(reg, args[0])
+	if err != nil {
+		exitWithError("failed to publish", err)
+	}
+	fmt.Printf("✓ Published %s %s to '%s'\n", entry.Name, entry.Version, reg.Name)
+}
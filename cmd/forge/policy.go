@@ -0,0 +1,40 @@
+package forge
+
+import (
+	"forge/internal/config"
+	"forge/internal/plan"
+	"forge/internal/policy"
+)
+
+// loadPolicy returns the command policy in effect, or nil when none is set
+func loadPolicy() *policy.Policy {
+	path, err := config.Path()
+	if err != nil {
+		exitWithError("failed to locate config", err)
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		exitWithError("failed to load config", err)
+	}
+	pol, err := policy.Load(cfg, path)
+	if err != nil {
+		exitWithError("failed to load command policy", err)
+	}
+	return pol
+}
+
+// checkPolicy refuses a plan up front if the policy denies any of its
+// commands, so nothing runs and no half-initialized project is left behind
+func checkPolicy(pol *policy.Policy, p *plan.Plan) {
+	if pol == nil {
+		return
+	}
+	for _, step := range p.Steps {
+		if step.Kind != plan.KindCommand || step.Skipped {
+			continue
+		}
+		if err := pol.Check(step.Args, step.Dir, p.Mode == plan.ModeTest); err != nil {
+			exitWithError("template not allowed", err)
+		}
+	}
+}
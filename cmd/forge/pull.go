@@ -7,32 +7,123 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"forge/internal/cache"
+	"forge/internal/config"
+	"forge/internal/lock"
+	"forge/internal/progress"
+	"forge/internal/registry"
 	"forge/internal/remote"
+	"forge/internal/scaffold"
 )
 
 var pullCmd = &cobra.Command{
 	Use:   "pull [template-name]",
-	Short: "Download templates from the official repository",
-	Long: `Download templates from the official Forge templates repository.
+	Short: "Download templates from configured registries",
+	Long: `Download templates from the configured template registries
+(see 'forge registry list'). By default only the official Forge templates
+repository is configured.
+
+A plain name is looked up in every registry, highest priority first.
+Prefix the name with a registry to pick one explicitly.
+
+A git+ URL installs a template straight from a git repository using the
+local git executable. Append //<dir> to select a subdirectory and @<ref>
+to pick a tag, branch or commit. The resolved commit is recorded in the
+template's .forge-source.yaml.
+
+A local zip, tar or tar.gz archive, or a directory (written as ./dir or an
+absolute path) is installed without network access. A source with
+template.yaml at its root is one template; otherwise every directory in it
+containing template.yaml is installed.
+
+Archives are checked before extraction: entries escaping the template
+directory and symlinks are rejected, and extraction stops after 512 MiB or
+20000 entries. Adjust the limits with extract.max_bytes and
+extract.max_entries in config.yaml.
+
+Templates are extracted into a staging directory and validated before they
+replace the installed version, so a failed pull never breaks a working
+template. The replaced version is kept; 'forge pull --rollback <name>'
+restores it.
+
+Registries configured with a checksum manifest have their archives
+verified against it, and signed registries also need a manifest signature
+from a key added with 'forge registry trust'. A mismatch aborts the pull;
+--insecure-skip-verify turns verification off.
+
+Registry downloads are cached in %USERPROFILE%\.forge\cache and
+revalidated with ETag/Last-Modified, so unchanged archives are not
+downloaded again. --offline installs from the cache only (see 'forge cache').
+
+Every pull records the template's source, resolved commit and content hash
+in forge.lock (./forge.lock when the current directory has one, otherwise
+%USERPROFILE%\.forge\forge.lock; --lockfile names another, creating it, so
+'forge pull --lockfile forge.lock python' starts a per-repository lockfile).
+Registry templates are pinned to the archive they came from: its index
+version and URL, or for GitHub branch archives the commit it was built
+from. Later pulls of a locked template install that archive again and fail
+if the content no longer matches. Local sources inside the lockfile's
+directory are recorded relative to it. Use --update to move the pin to the
+latest version, or --locked to install exactly what the lockfile lists.
+
+--upgrade-all pulls every registry template that 'forge outdated' reports,
+showing how each template.yaml changes and asking before replacing them
+(--yes skips the question). Their pins are moved to the new versions.
 
 Examples:
   forge pull git              # Download a single template
+  forge pull team/python      # Download from the 'team' registry
+  forge pull git+https://github.com/org/repo//templates/python@v1.4.0
+  forge pull ./python-template.zip
+  forge pull /shared/templates       # Install every template in a directory
   forge pull --all            # Download all available templates
+  forge pull --rollback python  # Restore the previously installed version
+  forge pull --locked         # Install every template pinned in forge.lock
+  forge pull --lockfile forge.lock python   # Pin python in ./forge.lock
+  forge pull --update python  # Move the pin to the latest version
+  forge pull --upgrade-all    # Update every outdated registry template
 
 Templates are stored in: %USERPROFILE%\.forge\templates`,
 	Run: runPull,
 }
 
 var pullAll bool
+var pullRegistry string
+var pullRollback bool
+var pullLocked bool
+var pullUpdate bool
+var pullInsecure bool
+var pullOffline bool
+var pullUpgradeAll bool
+var pullYes bool
+var pullLockPath string
 
 func init() {
 	pullCmd.Flags().BoolVar(&pullAll, "all", false, "Pull all templates from repository")
+	pullCmd.Flags().StringVar(&pullRegistry, "registry", "", "With --all, only pull from this registry")
+	pullCmd.Flags().BoolVar(&pullRollback, "rollback", false, "Restore the previously installed version of a template")
+	pullCmd.Flags().BoolVar(&pullLocked, "locked", false, "Install exactly the versions in forge.lock")
+	pullCmd.Flags().BoolVar(&pullUpdate, "update", false, "Ignore the pinned version and update forge.lock")
+	pullCmd.Flags().StringVar(&pullLockPath, "lockfile", "", "Use this lockfile, creating it if needed, instead of finding one")
+	pullCmd.Flags().BoolVar(&pullOffline, "offline", false, "Install from the download cache without network access")
+	pullCmd.Flags().BoolVar(&pullUpgradeAll, "upgrade-all", false, "Update every outdated registry template")
+	pullCmd.Flags().BoolVarP(&pullYes, "yes", "y", false, "With --upgrade-all, do not ask for confirmation")
+	pullCmd.Flags().BoolVar(&pullInsecure, "insecure-skip-verify", false, "Do not verify registry checksums and signatures")
 	rootCmd.AddCommand(pullCmd)
 }
 
 func runPull(cmd *cobra.Command, args []string) {
 	// Validate arguments
-	if !pullAll && len(args) == 0 {
+	if pullUpgradeAll {
+		if len(args) > 0 || pullAll || pullLocked || pullRollback {
+			fmt.Println("Error: --upgrade-all cannot be combined with a template name, --all, --locked or --rollback")
+			os.Exit(1)
+		}
+		// Upgrading moves the pins to the new versions
+		pullUpdate = true
+	}
+
+	if !pullAll && !pullLocked && !pullUpgradeAll && len(args) == 0 {
 		fmt.Println("Error: template name required")
 		fmt.Println("Usage: forge pull <template-name>")
 		fmt.Println("   or: forge pull --all")
@@ -44,6 +135,11 @@ func runPull(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
+	if pullLocked && (pullUpdate || pullAll) {
+		fmt.Println("Error: --locked cannot be combined with --update or --all")
+		os.Exit(1)
+	}
+
 	// Get global templates directory
 	globalDir, err := getGlobalTemplatesDir()
 	if err != nil {
@@ -57,19 +153,48 @@ func runPull(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
-	if pullAll {
-		// Pull all templates
-		if err := pullAllTemplates(globalDir); err != nil {
+	if pullRollback {
+		if pullAll || len(args) != 1 {
+			fmt.Println("Error: --rollback requires exactly one template name")
+			os.Exit(1)
+		}
+		if err := scaffold.ValidateName(args[0]); err != nil {
 			fmt.Printf("Error: %v\n", err)
 			os.Exit(1)
 		}
-	} else {
-		// Pull single template
-		templateName := args[0]
-		if err := pullSingleTemplate(templateName, globalDir); err != nil {
+		if err := remote.Rollback(globalDir, args[0]); err != nil {
 			fmt.Printf("Error: %v\n", err)
 			os.Exit(1)
 		}
+		fmt.Printf("Template '%s' rolled back to the previously installed version.\n", args[0])
+		return
+	}
+
+	p, err := newPuller(globalDir)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer p.close()
+
+	switch {
+	case pullUpgradeAll:
+		err = p.upgradeAll()
+	case pullAll:
+		err = p.pullAll()
+	case len(args) == 0:
+		err = p.pullLockfile()
+	default:
+		err = p.pull(args[0])
+	}
+	// Record whatever was installed, even if a later template failed
+	if saveErr := p.saveLock(); err == nil {
+		err = saveErr
+	}
+	if err != nil {
+		p.close()
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
 	}
 }
 
@@ -81,44 +206,346 @@ func getGlobalTemplatesDir() (string, error) {
 	return filepath.Join(home, ".forge", "templates"), nil
 }
 
-func pullSingleTemplate(templateName, globalDir string) error {
-	fmt.Println("Downloading templates...")
+// puller installs templates and keeps forge.lock in step. Templates are
+// fetched into a scratch directory, checked against their pins, and only
+// then moved into the global templates directory.
+type puller struct {
+	cfg       *config.Config
+	opts      registry.Options
+	lock      *lock.File
+	globalDir string
+	scratch   string
+	changed   bool
+}
 
-	zipURL := "https://github.com/Vishnuj-n/forge-templates/archive/refs/heads/main.zip"
-	zipPath, err := remote.DownloadRepoZip(zipURL)
+func newPuller(globalDir string) (*puller, error) {
+	cfg, err := config.LoadDefault()
 	if err != nil {
+		return nil, err
+	}
+	applyExtractLimits(cfg)
+	if err := configureHTTP(cfg); err != nil {
+		return nil, err
+	}
+
+	opts, err := registry.OptionsFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	opts.InsecureSkipVerify = pullInsecure
+	if pullInsecure {
+		fmt.Println("Warning: checksum and signature verification is disabled (--insecure-skip-verify)")
+	}
+	if opts.Cache, err = cache.Default(); err != nil {
+		return nil, err
+	}
+	opts.Offline = pullOffline
+
+	lockPath := pullLockPath
+	if lockPath == "" {
+		lockPath, err = lock.Find()
+	} else {
+		lockPath, err = filepath.Abs(lockPath)
+	}
+	if err != nil {
+		return nil, err
+	}
+	lf, err := lock.Load(lockPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Hidden, so template discovery ignores it
+	scratch, err := os.MkdirTemp(globalDir, ".pull-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create scratch directory: %w", err)
+	}
+	return &puller{cfg: cfg, opts: opts, lock: lf, globalDir: globalDir, scratch: scratch}, nil
+}
+
+func (p *puller) close() {
+	os.RemoveAll(p.scratch)
+}
+
+func (p *puller) saveLock() error {
+	if !p.changed {
+		return nil
+	}
+	if err := p.lock.Save(); err != nil {
 		return err
 	}
-	defer os.Remove(zipPath)
+	fmt.Printf("Updated %s\n", p.lock.Path())
+	return nil
+}
+
+// pull installs the templates provided by one source argument
+func (p *puller) pull(arg string) error {
+	source := arg
+	// A plain name that is already locked resolves to its locked source
+	if !remote.IsGitSource(arg) && !remote.IsLocalSource(arg) {
+		ref, err := registry.ParseRef(arg)
+		if err != nil {
+			return err
+		}
+		if entry, ok := p.lock.Get(ref.Name); ok && ref.Registry == "" {
+			source = entry.Source
+			if remote.IsLocalSource(source) {
+				source = p.lock.ResolveSource(source)
+			}
+		}
+	}
 
-	fmt.Printf("Installing template '%s'...\n", templateName)
-	if err := remote.InstallSingleTemplate(zipPath, templateName, globalDir); err != nil {
+	fetched, err := p.fetch(source)
+	if err != nil {
 		return err
 	}
+	for _, entry := range fetched {
+		if err := p.install(entry); err != nil {
+			return err
+		}
+	}
+	if len(fetched) > 1 {
+		fmt.Printf("Completed. %d templates installed or updated.\n", len(fetched))
+	}
+	return nil
+}
 
-	fmt.Printf("Template '%s' installed successfully.\n", templateName)
+// pullLockfile installs every template pinned in the lockfile
+func (p *puller) pullLockfile() error {
+	if len(p.lock.Templates) == 0 {
+		return fmt.Errorf("%s has no templates to install", p.lock.Path())
+	}
+	for _, entry := range p.lock.Templates {
+		if err := p.pull(entry.Name); err != nil {
+			return fmt.Errorf("%s: %w", entry.Name, err)
+		}
+	}
 	return nil
 }
 
-func pullAllTemplates(globalDir string) error {
-	fmt.Println("Downloading templates...")
+// fetch downloads a source into the scratch directory, honouring git pins,
+// and returns lock entries for the templates it provided
+func (p *puller) fetch(source string) ([]lock.Entry, error) {
+	var entries []lock.Entry
+	switch {
+	case remote.IsGitSource(source):
+		src, err := remote.ParseGitSource(source)
+		if err != nil {
+			return nil, err
+		}
+		if err := scaffold.ValidateName(src.TemplateName()); err != nil {
+			return nil, fmt.Errorf("cannot install '%s': %w", source, err)
+		}
+
+		if pullOffline {
+			return nil, fmt.Errorf("git sources cannot be pulled with --offline")
+		}
+
+		requested := src.Ref
+		if pin, ok := p.pinFor(src.TemplateName(), source); ok && pin.Commit != "" {
+			src.Ref = pin.Commit
+		}
+
+		fmt.Printf("Cloning %s...\n", src.RepoURL)
+		name, commit, err := remote.InstallGitTemplate(src, p.scratch)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, lock.Entry{Name: name, Source: source, Ref: requested, Commit: commit})
+
+	case remote.IsLocalSource(source):
+		absSource, err := filepath.Abs(source)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Printf("Installing from %s...\n", source)
+		names, err := remote.InstallLocal(absSource, p.scratch)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			entries = append(entries, lock.Entry{Name: name, Source: p.lock.LocalSource(absSource)})
+		}
+
+	default:
+		ref, err := registry.ParseRef(source)
+		if err != nil {
+			return nil, err
+		}
+		// Offline pulls use the cached registry archive and rely on the hash check
+		if pin, ok := p.pinFor(ref.Name, source); ok && pin.URL != "" && pin.Hash != "" && !pullOffline {
+			entry, err := p.fetchPinned(ref, pin)
+			if err != nil {
+				return nil, err
+			}
+			return []lock.Entry{entry}, nil
+		}
 
-	zipURL := "https://github.com/Vishnuj-n/forge-templates/archive/refs/heads/main.zip"
-	zipPath, err := remote.DownloadRepoZip(zipURL)
+		fmt.Println("Downloading templates...")
+		fmt.Printf("Installing template '%s'...\n", ref)
+		reg, pin, err := registry.Pull(p.cfg, ref, p.scratch, p.opts)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, registryEntry(ref.Name, reg.Name, pin))
+	}
+	return entries, nil
+}
+
+// fetchPinned downloads the archive a registry template is pinned to,
+// rather than the registry's current one
+func (p *puller) fetchPinned(ref registry.Ref, pin lock.Entry) (lock.Entry, error) {
+	reg, ok := p.cfg.Registry(ref.Registry)
+	if !ok {
+		return lock.Entry{}, fmt.Errorf("template '%s' is locked to registry '%s', which is not configured", ref.Name, ref.Registry)
+	}
+	fmt.Printf("Installing template '%s' as pinned in %s...\n", ref, p.lock.Path())
+	archive := registry.Pin{Version: pin.Version, URL: pin.URL, Commit: pin.Commit}
+	if err := registry.PullPinned(reg, ref.Name, archive, p.scratch, p.opts); err != nil {
+		return lock.Entry{}, err
+	}
+	return registryEntry(ref.Name, reg.Name, archive), nil
+}
+
+// registryEntry returns the lock entry for a template pulled from a registry
+func registryEntry(name, registryName string, pin registry.Pin) lock.Entry {
+	return lock.Entry{
+		Name:    name,
+		Source:  registryName + "/" + name,
+		Commit:  pin.Commit,
+		Version: pin.Version,
+		URL:     pin.URL,
+	}
+}
+
+// pinFor returns the lock entry that pins name when it applies to source.
+// With --update no pin applies.
+func (p *puller) pinFor(name, source string) (lock.Entry, bool) {
+	if pullUpdate {
+		return lock.Entry{}, false
+	}
+	entry, ok := p.lock.Get(name)
+	if !ok || entry.Source != source {
+		return lock.Entry{}, false
+	}
+	return entry, true
+}
+
+// install checks a fetched template against its pin, moves it into place
+// and records it in the lockfile
+func (p *puller) install(entry lock.Entry) error {
+	dir := filepath.Join(p.scratch, entry.Name)
+	hash, err := lock.HashDir(dir, remote.SourceFile)
 	if err != nil {
 		return err
 	}
-	defer os.Remove(zipPath)
+	entry.Hash = hash
+
+	pin, pinned := p.pinFor(entry.Name, entry.Source)
+	if pullLocked && !pinned {
+		if existing, ok := p.lock.Get(entry.Name); ok {
+			return fmt.Errorf("template '%s' is locked to %s, not %s", entry.Name, existing.Source, entry.Source)
+		}
+		return fmt.Errorf("template '%s' is not in %s", entry.Name, p.lock.Path())
+	}
+	if pinned && pin.Hash != "" && pin.Hash != hash {
+		return fmt.Errorf("template '%s' does not match %s (locked %s, got %s); run 'forge pull --update %s' to accept the new version",
+			entry.Name, p.lock.Path(), pin.Hash, hash, entry.Name)
+	}
+
+	if err := remote.InstallPrepared(dir, p.globalDir, entry.Name); err != nil {
+		return err
+	}
 
+	if entry.Commit != "" {
+		fmt.Printf("✓ %s (%s at commit %s)\n", entry.Name, entry.Source, shortCommit(entry.Commit))
+	} else {
+		fmt.Printf("✓ %s (%s)\n", entry.Name, entry.Source)
+	}
+
+	if !pinned || pin != entry {
+		p.lock.Set(entry)
+		p.changed = true
+	}
+	return nil
+}
+
+// pullAll installs every template from the configured registries. A
+// template whose content no longer matches its pin is skipped.
+func (p *puller) pullAll() error {
+	regs := p.cfg.SortedRegistries()
+	if pullRegistry != "" {
+		reg, ok := p.cfg.Registry(pullRegistry)
+		if !ok {
+			return fmt.Errorf("unknown registry '%s' (see 'forge registry list')", pullRegistry)
+		}
+		regs = []config.Registry{reg}
+	}
+
+	fmt.Println("Downloading templates...")
 	fmt.Println("Installing all templates...")
-	installed, err := remote.InstallAllTemplates(zipPath, globalDir)
+	installed, err := registry.PullAll(regs, p.scratch, p.opts)
 	if err != nil {
 		return err
 	}
 
-	for _, name := range installed {
-		fmt.Printf("✓ %s\n", name)
+	count, failed := 0, 0
+	for _, t := range installed {
+		if err := p.install(registryEntry(t.Name, t.Registry, t.Pin)); err != nil {
+			fmt.Printf("✗ %s: %v\n", t.Name, err)
+			failed++
+			continue
+		}
+		count++
+	}
+	fmt.Printf("Completed. %d templates installed or updated.\n", count)
+	if failed > 0 {
+		return fmt.Errorf("%d templates were not installed", failed)
+	}
+	return nil
+}
+
+// shortCommit abbreviates a commit SHA for display
+func shortCommit(sha string) string {
+	if len(sha) > 12 {
+		return sha[:12]
+	}
+	return sha
+}
+
+// applyExtractLimits applies archive extraction limits from the config
+func applyExtractLimits(cfg *config.Config) {
+	if cfg.Extract.MaxBytes > 0 {
+		remote.ExtractLimits.MaxBytes = cfg.Extract.MaxBytes
+	}
+	if cfg.Extract.MaxEntries > 0 {
+		remote.ExtractLimits.MaxEntries = cfg.Extract.MaxEntries
+	}
+}
+
+// configureHTTP sets up the shared download client from the config and
+// shows download progress on stderr
+func configureHTTP(cfg *config.Config) error {
+	opts := remote.DefaultClientOptions()
+	opts.UserAgent = "forge/" + Version
+	if cfg.HTTP.ConnectTimeout > 0 {
+		opts.ConnectTimeout = cfg.HTTP.ConnectTimeout
+	}
+	if cfg.HTTP.ReadTimeout > 0 {
+		opts.ReadTimeout = cfg.HTTP.ReadTimeout
+	}
+	if cfg.HTTP.Retries != nil {
+		opts.Retries = *cfg.HTTP.Retries
+	}
+	opts.Proxy = cfg.HTTP.Proxy
+	opts.CABundle = cfg.HTTP.CABundle
+	opts.Tokens = cfg.HTTP.Tokens
+
+	client, err := remote.NewClient(opts)
+	if err != nil {
+		return fmt.Errorf("invalid http settings: %w", err)
 	}
-	fmt.Printf("Completed. %d templates installed or updated.\n", len(installed))
+	remote.SetDefaultClient(client)
+	remote.DownloadProgress = progress.New(os.Stderr).Update
 	return nil
 }
@@ -0,0 +1,195 @@
+package forge
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"forge/internal/config"
+	"forge/internal/remote"
+
+	"github.com/spf13/cobra"
+)
+
+var registryCmd = &cobra.Command{
+	Use:   "registry",
+	Short: "Manage template registries",
+	Long: `Manage the registries forge pull installs templates from.
+
+Registries are stored in %USERPROFILE%\.forge\config.yaml. Higher priority
+registries are searched first; 'forge pull <registry>/<name>' picks one
+explicitly. When no registries are configured, the official Forge
+templates repository is used.
+
+A zip registry (the default) is one archive with a directory per template.
+An index registry (--type index) points at an index.json listing each
+template's metadata, content checksum and archive URL; 'forge search' and
+'forge info' read it without downloading the templates.
+
+A registry added with --manifest has its archive checked against that
+sha256sum-style manifest on every pull. With --signed the manifest must
+also carry a valid signature (<manifest>.sig) from a trusted ed25519 key.
+
+Examples:
+  forge registry list
+  forge registry add team https://git.example.com/templates/archive/main.zip --priority 10
+  forge registry add internal https://templates.example.com/index.json --type index
+  forge registry add team https://example.com/t.zip --manifest https://example.com/SHA256SUMS --signed
+  forge registry trust team-key <base64-ed25519-public-key>
+  forge registry remove team`,
+}
+
+var registryAddCmd = &cobra.Command{
+	Use:   "add <name> <url>",
+	Short: "Add a template registry",
+	Args:  cobra.ExactArgs(2),
+	Run:   runRegistryAdd,
+}
+
+var registryRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Remove a template registry",
+	Args:  cobra.ExactArgs(1),
+	Run:   runRegistryRemove,
+}
+
+var registryListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List template registries in search order",
+	Args:  cobra.NoArgs,
+	Run:   runRegistryList,
+}
+
+var registryTrustCmd = &cobra.Command{
+	Use:   "trust <key-name> <public-key>",
+	Short: "Trust an ed25519 public key for manifest signatures",
+	Args:  cobra.ExactArgs(2),
+	Run:   runRegistryTrust,
+}
+
+var registryUntrustCmd = &cobra.Command{
+	Use:   "untrust <key-name>",
+	Short: "Remove a trusted public key",
+	Args:  cobra.ExactArgs(1),
+	Run:   runRegistryUntrust,
+}
+
+var registryType string
+var registryPriority int
+var registryManifest string
+var registrySigned bool
+
+func init() {
+	registryAddCmd.Flags().StringVar(&registryType, "type", config.RegistryTypeZip, "Registry type: zip or index")
+	registryAddCmd.Flags().IntVar(&registryPriority, "priority", 10, "Search priority (higher is searched first)")
+	registryAddCmd.Flags().StringVar(&registryManifest, "manifest", "", "URL of a SHA-256 checksum manifest for the archive")
+	registryAddCmd.Flags().BoolVar(&registrySigned, "signed", false, "Require a trusted signature of the manifest")
+
+	registryCmd.AddCommand(registryAddCmd, registryRemoveCmd, registryListCmd, registryTrustCmd, registryUntrustCmd)
+	rootCmd.AddCommand(registryCmd)
+}
+
+func runRegistryAdd(cmd *cobra.Command, args []string) {
+	cfg, err := config.LoadDefault()
+	if err != nil {
+		exitWithError("failed to load config", err)
+	}
+
+	reg := config.Registry{
+		Name:     args[0],
+		URL:      args[1],
+		Type:     registryType,
+		Priority: registryPriority,
+		Manifest: registryManifest,
+		Signed:   registrySigned,
+	}
+	if err := cfg.AddRegistry(reg); err != nil {
+		exitWithError("failed to add registry", err)
+	}
+	if err := cfg.SaveDefault(); err != nil {
+		exitWithError("failed to save config", err)
+	}
+
+	fmt.Printf("✓ Added registry '%s'\n", reg.Name)
+}
+
+func runRegistryRemove(cmd *cobra.Command, args []string) {
+	cfg, err := config.LoadDefault()
+	if err != nil {
+		exitWithError("failed to load config", err)
+	}
+
+	if err := cfg.RemoveRegistry(args[0]); err != nil {
+		exitWithError("failed to remove registry", err)
+	}
+	if err := cfg.SaveDefault(); err != nil {
+		exitWithError("failed to save config", err)
+	}
+
+	fmt.Printf("✓ Removed registry '%s'\n", args[0])
+	if len(cfg.Registries) == 0 {
+		fmt.Println("No registries left; the official registry will be used.")
+	}
+}
+
+func runRegistryList(cmd *cobra.Command, args []string) {
+	cfg, err := config.LoadDefault()
+	if err != nil {
+		exitWithError("failed to load config", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTYPE\tPRIORITY\tVERIFY\tURL")
+	fmt.Fprintln(w, "----\t----\t--------\t------\t---")
+	for _, reg := range cfg.SortedRegistries() {
+		verify := "none"
+		if reg.Signed {
+			verify = "signed"
+		} else if reg.Manifest != "" {
+			verify = "checksum"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n", reg.Name, reg.Type, reg.Priority, verify, reg.URL)
+	}
+	w.Flush()
+
+	if len(cfg.TrustedKeys) > 0 {
+		fmt.Println("\nTrusted keys:")
+		for _, k := range cfg.TrustedKeys {
+			fmt.Printf("  %s\n", k.Name)
+		}
+	}
+}
+
+func runRegistryTrust(cmd *cobra.Command, args []string) {
+	if _, err := remote.ParsePublicKey(args[1]); err != nil {
+		exitWithError("invalid public key", err)
+	}
+
+	cfg, err := config.LoadDefault()
+	if err != nil {
+		exitWithError("failed to load config", err)
+	}
+	if err := cfg.AddTrustedKey(config.TrustedKey{Name: args[0], Key: args[1]}); err != nil {
+		exitWithError("failed to trust key", err)
+	}
+	if err := cfg.SaveDefault(); err != nil {
+		exitWithError("failed to save config", err)
+	}
+
+	fmt.Printf("✓ Trusted key '%s'\n", args[0])
+}
+
+func runRegistryUntrust(cmd *cobra.Command, args []string) {
+	cfg, err := config.LoadDefault()
+	if err != nil {
+		exitWithError("failed to load config", err)
+	}
+	if err := cfg.RemoveTrustedKey(args[0]); err != nil {
+		exitWithError("failed to remove key", err)
+	}
+	if err := cfg.SaveDefault(); err != nil {
+		exitWithError("failed to save config", err)
+	}
+
+	fmt.Printf("✓ Removed trusted key '%s'\n", args[0])
+}
@@ -1,9 +1,12 @@
 package forge
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
+	"forge/internal/template"
+
 	"github.com/spf13/cobra"
 )
 
@@ -28,11 +31,22 @@ func init() {
 	rootCmd.CompletionOptions.DisableDefaultCmd = true
 }
 
+// exitCleanups run before exitWithError exits, since os.Exit skips
+// deferred calls
+var exitCleanups []func()
+
 func exitWithError(msg string, err error) {
-	if err != nil {
+	var perr *template.ParseError
+	if errors.As(err, &perr) {
+		// Show the offending template.yaml lines instead of the wrapped chain
+		fmt.Fprintf(os.Stderr, "Error: %s\n%s\n", msg, perr.Render())
+	} else if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %s: %v\n", msg, err)
 	} else {
 		fmt.Fprintf(os.Stderr, "Error: %s\n", msg)
 	}
+	for _, cleanup := range exitCleanups {
+		cleanup()
+	}
 	os.Exit(1)
 }
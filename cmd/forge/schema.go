@@ -0,0 +1,51 @@
+package forge
+
+import (
+	"fmt"
+	"os"
+
+	"forge/internal/template"
+
+	"github.com/spf13/cobra"
+)
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema for template.yaml",
+	Long: `Print the JSON Schema describing template.yaml, generated from the
+template types built into this version of forge.
+
+Editors with YAML language server support (e.g. VS Code) pick it up from
+the modeline that forge new writes at the top of template.yaml:
+
+  ` + template.SchemaModeline + `
+
+Example:
+  forge schema --output template.schema.json`,
+	Args: cobra.NoArgs,
+	Run:  runSchema,
+}
+
+var schemaOutput string
+
+func init() {
+	schemaCmd.Flags().StringVarP(&schemaOutput, "output", "o", "", "Write the schema to a file instead of stdout")
+	rootCmd.AddCommand(schemaCmd)
+}
+
+func runSchema(cmd *cobra.Command, args []string) {
+	data, err := template.JSONSchema()
+	if err != nil {
+		exitWithError("failed to generate schema", err)
+	}
+
+	if schemaOutput == "" {
+		fmt.Print(string(data))
+		return
+	}
+
+	if err := os.WriteFile(schemaOutput, data, 0644); err != nil {
+		exitWithError("failed to write schema", err)
+	}
+	fmt.Printf("Schema written to %s\n", schemaOutput)
+}
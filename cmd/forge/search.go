@@ -0,0 +1,174 @@
+package forge
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"text/tabwriter"
+
+	"forge/internal/cache"
+	"forge/internal/config"
+	"forge/internal/registry"
+
+	"github.com/spf13/cobra"
+)
+
+var searchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Search the configured registries for templates",
+	Long: `Search the indexes of all configured registries. The query is matched
+against template names, tags and descriptions; every word must match, and
+small typos in names are tolerated.
+
+Index registries serve their index directly. For zip registries the index
+is built from the archive, which is kept in the download cache, so repeated
+searches and --offline work without downloading it again.
+
+Examples:
+  forge search python
+  forge search web api --registry team`,
+	Args: cobra.MinimumNArgs(1),
+	Run:  runSearch,
+}
+
+var infoCmd = &cobra.Command{
+	Use:   "info [registry/]<name>",
+	Short: "Show a registry template's metadata and the commands it runs",
+	Long: `Show the metadata of a template in the configured registries, including
+the tools it needs and every command it will run, without installing it.
+For index registries the template's archive is downloaded and verified
+like a pull, and the details are read from its template.yaml rather than
+from index.json.
+
+Examples:
+  forge info python
+  forge info team/fastapi`,
+	Args: cobra.ExactArgs(1),
+	Run:  runInfo,
+}
+
+var searchRegistry string
+var searchOffline bool
+
+func init() {
+	searchCmd.Flags().StringVar(&searchRegistry, "registry", "", "Only search this registry")
+	searchCmd.Flags().BoolVar(&searchOffline, "offline", false, "Use cached indexes only")
+	infoCmd.Flags().BoolVar(&searchOffline, "offline", false, "Use cached indexes only")
+	rootCmd.AddCommand(searchCmd, infoCmd)
+}
+
+// indexOptions loads the config and returns the registry options for
+// commands that read registry indexes
+func indexOptions() (*config.Config, registry.Options) {
+	cfg, err := config.LoadDefault()
+	if err != nil {
+		exitWithError("failed to load config", err)
+	}
+	applyExtractLimits(cfg)
+	if err := configureHTTP(cfg); err != nil {
+		exitWithError("failed to configure downloads", err)
+	}
+
+	opts, err := registry.OptionsFromConfig(cfg)
+	if err != nil {
+		exitWithError("invalid config", err)
+	}
+	if opts.Cache, err = cache.Default(); err != nil {
+		exitWithError("failed to locate cache", err)
+	}
+	opts.Offline = searchOffline
+	return cfg, opts
+}
+
+func runSearch(cmd *cobra.Command, args []string) {
+	cfg, opts := indexOptions()
+	query := strings.Join(args, " ")
+
+	regs := cfg.SortedRegistries()
+	if searchRegistry != "" {
+		reg, ok := cfg.Registry(searchRegistry)
+		if !ok {
+			exitWithError(fmt.Sprintf("unknown registry '%s' (see 'forge registry list')", searchRegistry), nil)
+		}
+		regs = []config.Registry{reg}
+	}
+
+	results, failed := registry.Search(regs, query, opts)
+	for name, err := range failed {
+		fmt.Fprintf(os.Stderr, "Warning: skipped registry '%s': %v\n", name, err)
+	}
+	if len(results) == 0 {
+		fmt.Printf("No templates match '%s'.\n", query)
+		if len(failed) == len(regs) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TEMPLATE\tVERSION\tTAGS\tDESCRIPTION")
+	for _, r := range results {
+		version := r.Version
+		if version == "" {
+			version = "-"
+		}
+		fmt.Fprintf(w, "%s/%s\t%s\t%s\t%s\n", r.Registry, r.Name, version, strings.Join(r.Tags, ","), r.Description)
+	}
+	w.Flush()
+	fmt.Println("\nRun 'forge info <template>' for details or 'forge pull <template>' to install.")
+}
+
+func runInfo(cmd *cobra.Command, args []string) {
+	ref, err := registry.ParseRef(args[0])
+	if err != nil {
+		exitWithError("invalid template reference", err)
+	}
+	cfg, opts := indexOptions()
+
+	reg, e, err := registry.Info(cfg, ref, opts)
+	if err != nil {
+		exitWithError("failed to look up template", err)
+	}
+
+	fmt.Printf("Name:        %s\n", e.Name)
+	fmt.Printf("Registry:    %s\n", reg.Name)
+	if e.Version != "" {
+		fmt.Printf("Version:     %s\n", e.Version)
+	}
+	if e.Description != "" {
+		fmt.Printf("Description: %s\n", e.Description)
+	}
+	if len(e.Tags) > 0 {
+		fmt.Printf("Tags:        %s\n", strings.Join(e.Tags, ", "))
+	}
+	if len(e.Requires) > 0 {
+		var missing []string
+		for _, tool := range e.Requires {
+			if _, err := exec.LookPath(tool); err != nil {
+				missing = append(missing, tool)
+			}
+		}
+		line := strings.Join(e.Requires, ", ")
+		if len(missing) > 0 {
+			line += fmt.Sprintf(" (not found: %s)", strings.Join(missing, ", "))
+		}
+		fmt.Printf("Requires:    %s\n", line)
+	}
+	if e.Checksum != "" {
+		fmt.Printf("Checksum:    %s\n", e.Checksum)
+	}
+	if e.URL != "" {
+		fmt.Printf("Archive:     %s\n", e.URL)
+	}
+
+	if len(e.Commands) == 0 {
+		fmt.Println("\nThis template runs no commands.")
+	} else {
+		fmt.Println("\nCommands run by 'forge init':")
+		for i, c := range e.Commands {
+			fmt.Printf("  %d. %s\n", i+1, c)
+		}
+	}
+	fmt.Printf("\nInstall with: forge pull %s/%s\n", reg.Name, e.Name)
+}
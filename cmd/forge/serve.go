@@ -0,0 +1,81 @@
+package forge
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"forge/internal/index"
+	"forge/internal/regserver"
+
+	"github.com/spf13/cobra"
+)
+
+var serveRegistryCmd = &cobra.Command{
+	Use:   "serve-registry <dir>",
+	Short: "Serve a directory of packed templates as an index registry",
+	Long: `Serve the archives and manifests written by 'forge pack' in <dir> as an
+index registry. The directory is created if it does not exist; archives
+can be copied into it or uploaded with 'forge publish'.
+
+The server provides:
+  /index.json     the latest version of every template
+  /SHA256SUMS     checksums of the index and every archive
+  /<archive>      archives and their manifests
+  /api/publish    uploads from 'forge publish'
+
+Responses carry ETags, so forge's download cache revalidates instead of
+downloading again. Set a token with --token or FORGE_REGISTRY_TOKEN to
+require it for uploads; downloads are always open.
+
+Examples:
+  forge serve-registry ./registry --listen :8080
+  forge registry add team http://host:8080/index.json --type index --manifest http://host:8080/SHA256SUMS`,
+	Args: cobra.ExactArgs(1),
+	Run:  runServeRegistry,
+}
+
+var serveListen string
+var serveToken string
+
+func init() {
+	serveRegistryCmd.Flags().StringVar(&serveListen, "listen", "localhost:8080", "Address to listen on")
+	serveRegistryCmd.Flags().StringVar(&serveToken, "token", "", "Bearer token required to publish (default $FORGE_REGISTRY_TOKEN)")
+	rootCmd.AddCommand(serveRegistryCmd)
+}
+
+func runServeRegistry(cmd *cobra.Command, args []string) {
+	dir, err := filepath.Abs(args[0])
+	if err != nil {
+		exitWithError("invalid directory", err)
+	}
+	store, err := regserver.NewStore(dir)
+	if err != nil {
+		exitWithError("failed to open registry", err)
+	}
+	if _, err := store.Manifests(); err != nil {
+		exitWithError("failed to read registry", err)
+	}
+
+	token := serveToken
+	if token == "" {
+		token = os.Getenv("FORGE_REGISTRY_TOKEN")
+	}
+	logger := log.New(os.Stderr, "", log.LstdFlags)
+	srv := regserver.New(store, regserver.Options{Token: token, Logger: logger})
+
+	ln, err := net.Listen("tcp", serveListen)
+	if err != nil {
+		exitWithError("failed to listen", err)
+	}
+	fmt.Printf("Serving %s at http://%s/%s\n", dir, ln.Addr(), index.FileName)
+	if token == "" {
+		fmt.Println("⚠ No token set; anyone who can reach this address can publish")
+	}
+	if err := http.Serve(ln, srv); err != nil {
+		exitWithError("server stopped", err)
+	}
+}
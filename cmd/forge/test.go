@@ -5,6 +5,7 @@ import (
 
 	"forge/internal/executor"
 	"forge/internal/fileops"
+	"forge/internal/plan"
 	"forge/internal/template"
 	"forge/internal/workspace"
 
@@ -23,17 +24,32 @@ var testCmd = &cobra.Command{
 
 Commands marked as interactive will use test_cmd or be skipped.
 The workspace is NOT committed to any target directory.
-The workspace path is displayed so you can inspect the result.`,
+The workspace path is displayed so you can inspect the result.
+
+Use --dry-run to print the plan without creating a workspace, or
+--plan-json for machine-readable output.
+
+Downloaded templates must be trusted before their commands run, and the
+command policy applies, as with 'forge init'. When a policy is set, forge
+test also refuses network tools such as curl or git clone.`,
 	Args: cobra.ExactArgs(1),
 	Run:  runTest,
 }
 
+var testDryRun bool
+var testPlanJSON bool
+var testTrust bool
+
 func init() {
+	testCmd.Flags().BoolVar(&testDryRun, "dry-run", false, "Print the execution plan without running anything")
+	testCmd.Flags().BoolVar(&testPlanJSON, "plan-json", false, "Print the execution plan as JSON (implies --dry-run)")
+	testCmd.Flags().BoolVar(&testTrust, "trust", false, "Trust a new or changed downloaded template without asking")
 	rootCmd.AddCommand(testCmd)
 }
 
 func runTest(cmd *cobra.Command, args []string) {
-	templatePath := args[0]
+	templatePath, cleanup := fetchTemplateSource(args[0])
+	defer cleanup()
 
 	// Resolve template path (handles both full paths and template names)
 	resolvedTemplatePath, err := template.ResolveTemplatePath(templatePath)
@@ -47,6 +63,24 @@ func runTest(cmd *cobra.Command, args []string) {
 		exitWithError("failed to load template", err)
 	}
 
+	if err := tmpl.CheckForgeVersion(Version); err != nil {
+		exitWithError("template is not compatible with this forge", err)
+	}
+
+	// The workspace is only created once the plan has been accepted
+	p, err := plan.Build(tmpl, resolvedTemplatePath, "<temporary workspace>", true)
+	if err != nil {
+		exitWithError("failed to build plan", err)
+	}
+	if testDryRun || testPlanJSON {
+		printPlan(p, testPlanJSON)
+		return
+	}
+
+	pol := loadPolicy()
+	checkPolicy(pol, p)
+	requireTrust(args[0], resolvedTemplatePath, p, testTrust)
+
 	fmt.Printf("Testing template: %s\n", tmpl.Name)
 
 	// Create workspace
@@ -62,6 +96,7 @@ func runTest(cmd *cobra.Command, args []string) {
 	if len(tmpl.Commands) > 0 {
 		fmt.Println("\nExecuting commands:")
 		exec := executor.New(ws.Path(), false, true) // true for testMode
+		exec.SetPolicy(pol)
 		for i, cmdDef := range tmpl.Commands {
 			fmt.Printf("  [%d/%d] %s\n", i+1, len(tmpl.Commands), cmdDef.String())
 			if err := exec.Run(cmdDef); err != nil {
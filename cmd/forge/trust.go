@@ -0,0 +1,134 @@
+package forge
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"forge/internal/lock"
+	"forge/internal/plan"
+	"forge/internal/remote"
+	"forge/internal/template"
+	"forge/internal/trust"
+)
+
+// templateOrigin returns where a template came from: the git source it was
+// cloned from, the source recorded when it was installed, or its lockfile
+// source for registry templates installed before sources were recorded.
+// Templates outside the global directory, such as ./templates or ones made
+// with 'forge new', have no origin and are implicitly trusted; a global
+// template whose source is unknown is identified by its directory and must
+// be trusted like any other download.
+func templateOrigin(arg, templateDir string) (string, error) {
+	if remote.IsGitSource(arg) {
+		return arg, nil
+	}
+
+	info, err := remote.ReadSourceInfo(templateDir)
+	if err != nil {
+		return "", err
+	}
+	if info != nil {
+		return info.Source, nil
+	}
+
+	globalDir, err := getGlobalTemplatesDir()
+	if err != nil {
+		return "", err
+	}
+	if filepath.Dir(templateDir) != globalDir {
+		return "", nil
+	}
+
+	name := filepath.Base(templateDir)
+	var paths []string
+	if p, err := lock.Find(); err == nil {
+		paths = append(paths, p)
+	}
+	if p, err := lock.GlobalPath(); err == nil {
+		paths = append(paths, p)
+	}
+	for _, p := range paths {
+		lf, err := lock.Load(p)
+		if err != nil {
+			return "", err
+		}
+		if e, ok := lf.Get(name); ok {
+			return e.Source, nil
+		}
+	}
+	return templateDir, nil
+}
+
+// templateDirOf returns the directory of a resolved template path, which
+// may point at template.yaml itself
+func templateDirOf(resolvedPath string) (string, error) {
+	yamlPath, err := template.YAMLPath(resolvedPath)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Dir(yamlPath), nil
+}
+
+// requireTrust stops unless the user trusts the template's current content.
+// The first time a downloaded template is used, and whenever its content
+// changes, every step it would take is shown and confirmation is required;
+// assumeTrust (--trust) accepts without asking.
+func requireTrust(arg, resolvedPath string, p *plan.Plan, assumeTrust bool) {
+	dir, err := templateDirOf(resolvedPath)
+	if err != nil {
+		exitWithError("failed to resolve template", err)
+	}
+	source, err := templateOrigin(arg, dir)
+	if err != nil {
+		exitWithError("failed to determine template source", err)
+	}
+	if source == "" {
+		return
+	}
+
+	hash, err := lock.HashDir(dir, remote.SourceFile)
+	if err != nil {
+		exitWithError("failed to hash template", err)
+	}
+	path, err := trust.DefaultPath()
+	if err != nil {
+		exitWithError("failed to locate trust store", err)
+	}
+	store, err := trust.Load(path)
+	if err != nil {
+		exitWithError("failed to load trust store", err)
+	}
+
+	switch store.Check(source, hash) {
+	case trust.Trusted:
+		return
+	case trust.Changed:
+		prev, _ := store.Get(source)
+		fmt.Printf("Template '%s' from %s has changed since you trusted it (%s -> %s).\n", p.Template, source, prev.Hash, hash)
+	default:
+		fmt.Printf("Template '%s' from %s has not been trusted yet.\n", p.Template, source)
+	}
+	fmt.Println("It will:")
+	if len(p.Steps) == 0 {
+		fmt.Println("  (nothing)")
+	} else if err := p.WriteSteps(os.Stdout); err != nil {
+		exitWithError("failed to write plan", err)
+	}
+	fmt.Println()
+
+	if !assumeTrust {
+		fmt.Print("Trust this template and continue? [y/N]: ")
+		var response string
+		fmt.Scanln(&response)
+		if response != "y" && response != "yes" {
+			exitWithError(fmt.Sprintf("template '%s' is not trusted; nothing was run (pass --trust to accept it)", p.Template), nil)
+		}
+	}
+
+	store.Trust(source, hash)
+	if err := store.Save(); err != nil {
+		exitWithError("failed to save trust decision", err)
+	}
+	fmt.Printf("✓ Trusted %s (%s)\n\n", source, hash)
+}
@@ -1,17 +1,25 @@
 package forge
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"forge/internal/config"
+	"forge/internal/remote"
 	"forge/internal/update"
+	"forge/internal/version"
 
 	"github.com/spf13/cobra"
 )
 
 var checkOnlyUpdate bool
+var updateRollback bool
+var updateInsecure bool
+var updateChannel string
+var updateVersion string
 
 var updateCmd = &cobra.Command{
 	Use:   "update",
@@ -19,63 +27,197 @@ var updateCmd = &cobra.Command{
 	Long: `Check for a newer version of forge on GitHub and install it.
 
 By default this command downloads and replaces the current binary in-place.
-Use --check to only report whether an update is available without installing.`,
+Use --check to only report whether an update is available without installing.
+The release notes of the new version are shown either way.
+
+The stable channel offers full releases only; --channel beta also offers
+pre-releases (set update.channel in config.yaml to make it the default).
+--version installs a specific release, including an older one, for example
+to step back from a regression. Releases are read from the GitHub API, or
+from update.api_url / $FORGE_GITHUB_API when set.
+
+The binary for this OS and architecture is picked from the release assets,
+named forge_<os>_<arch> and optionally packed as .zip or .tar.gz, in which
+case forge is extracted from the archive. Older releases that only publish
+forge-linux, forge-darwin and forge.exe are used on amd64.
+
+The download is verified against the release's checksums.txt before it is
+installed. When keys have been added with 'forge registry trust', the release
+must also publish checksums.txt.sig, signed by one of them; set
+update.require_signature in config.yaml to refuse unsigned releases even
+without trusted keys.
+
+The previous binary is kept next to the new one; 'forge update --rollback'
+restores it (and running it again switches back).`,
 	Run: runUpdate,
 }
 
 func init() {
 	updateCmd.Flags().BoolVar(&checkOnlyUpdate, "check", false, "Only check for updates, do not install")
+	updateCmd.Flags().BoolVar(&updateRollback, "rollback", false, "Restore the binary replaced by the last update")
+	updateCmd.Flags().StringVar(&updateChannel, "channel", "", "Release channel: stable or beta (default from config, else stable)")
+	updateCmd.Flags().StringVar(&updateVersion, "version", "", "Install this release, e.g. v0.4.2, even if it is older")
+	updateCmd.Flags().BoolVar(&updateInsecure, "insecure-skip-verify", false, "Install without verifying checksums and signatures")
 	rootCmd.AddCommand(updateCmd)
 }
 
 func runUpdate(cmd *cobra.Command, args []string) {
+	if updateRollback {
+		runRollback()
+		return
+	}
 	current := Version
 
-	fmt.Printf("Current version: %s\n", current)
-	fmt.Print("Checking for updates...\n")
-
-	// Resolve current executable path
-	exePath, err := os.Executable()
+	cfg, err := config.LoadDefault()
 	if err != nil {
-		exitWithError("could not determine current executable path", err)
+		exitWithError("failed to load config", err)
 	}
-	if realPath, err := filepath.EvalSymlinks(exePath); err == nil {
-		exePath = realPath
+	if err := configureHTTP(cfg); err != nil {
+		exitWithError("failed to configure downloads", err)
 	}
+	configureReleaseAPI(cfg)
 
-	newVersion, available, err := update.CheckUpdate(current)
-	if err != nil {
-		exitWithError("failed to check for updates", err)
+	channel := updateChannel
+	if channel == "" {
+		channel = cfg.Update.Channel
 	}
-
-	if !available {
-		fmt.Println("forge is already up to date.")
-		return
+	if channel == "" {
+		channel = update.ChannelStable
+	}
+	if err := update.ValidateChannel(channel); err != nil {
+		exitWithError("invalid --channel", err)
 	}
+	if updateVersion != "" && cmd.Flags().Changed("channel") {
+		exitWithError("--version and --channel cannot be combined", nil)
+	}
+
+	exePath := executablePath()
+
+	fmt.Printf("Current version: %s\n", current)
 
-	if current == "development" {
-		fmt.Printf("Development build detected — installing latest release: %s\n", newVersion)
+	var release *remote.GitHubRelease
+	if updateVersion != "" {
+		fmt.Printf("Looking up forge %s...\n", updateVersion)
+		release, err = update.PinnedRelease(updateVersion)
+		if err != nil {
+			exitWithError("failed to find release", err)
+		}
+		if c, err := version.Compare(current, release.TagName); err == nil && c == 0 {
+			fmt.Printf("forge %s is already installed.\n", release.TagName)
+			return
+		}
+		fmt.Printf("Selected version: %s\n", release.TagName)
 	} else {
-		fmt.Printf("New version available: %s\n", newVersion)
+		fmt.Printf("Checking for updates (%s channel)...\n", channel)
+		var available bool
+		release, available, err = update.CheckUpdate(current, channel)
+		if err != nil {
+			exitWithError("failed to check for updates", err)
+		}
+		if !available {
+			fmt.Println("forge is already up to date.")
+			return
+		}
+		if current == "development" {
+			fmt.Printf("Development build detected — installing latest release: %s\n", release.TagName)
+		} else {
+			fmt.Printf("New version available: %s\n", release.TagName)
+		}
 	}
+	printReleaseNotes(release)
 
 	if checkOnlyUpdate {
 		fmt.Println("Run 'forge update' without --check to install.")
 		return
 	}
 
-	isWinGet := strings.Contains(strings.ToLower(exePath), "winget")
-	if isWinGet {
-		fmt.Println("\n⚠ This installation is managed by WinGet.")
-		fmt.Println("Self-updating is disabled to prevent package manager registry corruption.")
+	if isWinGetManaged(exePath) {
 		fmt.Println("\nPlease run the following command instead:")
 		fmt.Println("  winget upgrade Vishnuj-n.forge")
 		return
 	}
 
-	if err := update.PerformUpdate(newVersion, exePath); err != nil {
+	opts := update.Options{
+		RequireSignature:   cfg.Update.RequireSignature,
+		InsecureSkipVerify: updateInsecure,
+	}
+	for _, k := range cfg.TrustedKeys {
+		key, err := remote.ParsePublicKey(k.Key)
+		if err != nil {
+			exitWithError(fmt.Sprintf("trusted key '%s'", k.Name), err)
+		}
+		opts.TrustedKeys = append(opts.TrustedKeys, key)
+	}
+	if updateInsecure {
+		fmt.Println("⚠ Skipping checksum and signature verification")
+	}
+
+	if err := update.PerformUpdate(release, exePath, opts); err != nil {
 		exitWithError("update failed", err)
 	}
 
-	fmt.Printf("forge updated to %s successfully.\n", newVersion)
+	fmt.Printf("forge updated to %s successfully.\n", release.TagName)
+	fmt.Println("Run 'forge update --rollback' to return to the previous version.")
+}
+
+// configureReleaseAPI points release lookups at the configured GitHub API,
+// with FORGE_GITHUB_API taking precedence over config.yaml
+func configureReleaseAPI(cfg *config.Config) {
+	switch {
+	case os.Getenv("FORGE_GITHUB_API") != "":
+		remote.GitHubAPIBase = os.Getenv("FORGE_GITHUB_API")
+	case cfg.Update.APIURL != "":
+		remote.GitHubAPIBase = cfg.Update.APIURL
+	}
+}
+
+// printReleaseNotes prints the release body, indented
+func printReleaseNotes(release *remote.GitHubRelease) {
+	notes := strings.TrimSpace(strings.ReplaceAll(release.Body, "\r\n", "\n"))
+	if notes == "" {
+		return
+	}
+	fmt.Printf("\nRelease notes for %s:\n", release.TagName)
+	for _, line := range strings.Split(notes, "\n") {
+		fmt.Printf("  %s\n", line)
+	}
+	fmt.Println()
+}
+
+func runRollback() {
+	exePath := executablePath()
+	if isWinGetManaged(exePath) {
+		fmt.Println("\nPlease use WinGet to install a different version instead.")
+		return
+	}
+	if err := update.Rollback(exePath); err != nil {
+		if errors.Is(err, update.ErrNoBackup) {
+			exitWithError("nothing to roll back: 'forge update' keeps the previous binary once it has installed an update", nil)
+		}
+		exitWithError("rollback failed", err)
+	}
+	fmt.Printf("✓ Restored the previous forge binary (the replaced one is kept at %s)\n", update.BackupPath(exePath))
+}
+
+// executablePath resolves the path of the running forge binary
+func executablePath() string {
+	exePath, err := os.Executable()
+	if err != nil {
+		exitWithError("could not determine current executable path", err)
+	}
+	if realPath, err := filepath.EvalSymlinks(exePath); err == nil {
+		exePath = realPath
+	}
+	return exePath
+}
+
+// isWinGetManaged reports, with a warning, binaries installed by WinGet,
+// which must not replace themselves
+func isWinGetManaged(exePath string) bool {
+	if !strings.Contains(strings.ToLower(exePath), "winget") {
+		return false
+	}
+	fmt.Println("\n⚠ This installation is managed by WinGet.")
+	fmt.Println("Self-updating is disabled to prevent package manager registry corruption.")
+	return true
 }
@@ -0,0 +1,229 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"forge/internal/config"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Cache stores downloaded files keyed by URL, together with the validators
+// (ETag, Last-Modified) needed to revalidate them
+type Cache struct {
+	dir string
+}
+
+// Entry describes one cached download
+type Entry struct {
+	URL          string    `yaml:"url"`
+	ETag         string    `yaml:"etag,omitempty"`
+	LastModified string    `yaml:"last_modified,omitempty"`
+	Size         int64     `yaml:"size"`
+	Fetched      time.Time `yaml:"fetched"`
+}
+
+// New returns a cache rooted at dir
+func New(dir string) *Cache {
+	return &Cache{dir: dir}
+}
+
+// DefaultDir returns the default cache directory (~/.forge/cache)
+func DefaultDir() (string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "cache"), nil
+}
+
+// Default returns the cache in the default directory
+func Default() (*Cache, error) {
+	dir, err := DefaultDir()
+	if err != nil {
+		return nil, err
+	}
+	return New(dir), nil
+}
+
+// Dir returns the cache directory
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+func (c *Cache) key(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(sum[:])
+}
+
+func (c *Cache) dataPath(url string) string {
+	return filepath.Join(c.dir, c.key(url)+".data")
+}
+
+func (c *Cache) metaPath(url string) string {
+	return filepath.Join(c.dir, c.key(url)+".yaml")
+}
+
+// Lookup returns the cached entry for url and the path of its data
+func (c *Cache) Lookup(url string) (*Entry, string, bool) {
+	data, err := os.ReadFile(c.metaPath(url))
+	if err != nil {
+		return nil, "", false
+	}
+	entry := &Entry{}
+	if err := yaml.Unmarshal(data, entry); err != nil || entry.URL != url {
+		return nil, "", false
+	}
+
+	path := c.dataPath(url)
+	if _, err := os.Stat(path); err != nil {
+		return nil, "", false
+	}
+	return entry, path, true
+}
+
+// PartialPath returns where an interrupted download of url is kept so it
+// can be resumed, creating the cache directory if needed
+func (c *Cache) PartialPath(url string) (string, error) {
+	if err := c.mkdir(); err != nil {
+		return "", err
+	}
+	return filepath.Join(c.dir, c.key(url)+".part"), nil
+}
+
+// mkdir creates the cache directory, accessible by its owner only
+func (c *Cache) mkdir() error {
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	return nil
+}
+
+// Store writes body to the cache for url and returns the path of the data.
+// The previous entry is replaced only once body has been read completely.
+func (c *Cache) Store(url string, body io.Reader, etag, lastModified string) (string, error) {
+	if err := c.mkdir(); err != nil {
+		return "", err
+	}
+
+	tmp, err := os.CreateTemp(c.dir, ".download-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create cache file: %w", err)
+	}
+	_, err = io.Copy(tmp, body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("failed to write cache file: %w", err)
+	}
+
+	path, err := c.StoreFile(url, tmp.Name(), etag, lastModified)
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return path, err
+}
+
+// StoreFile moves a completely downloaded file, which must be in the cache
+// directory, into the cache for url and returns the path of the data
+func (c *Cache) StoreFile(url, file, etag, lastModified string) (string, error) {
+	info, err := os.Stat(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to store cache file: %w", err)
+	}
+
+	path := c.dataPath(url)
+	if err := os.Rename(file, path); err != nil {
+		return "", fmt.Errorf("failed to store cache file: %w", err)
+	}
+
+	entry := Entry{URL: url, ETag: etag, LastModified: lastModified, Size: info.Size(), Fetched: time.Now().UTC()}
+	if err := c.writeMeta(entry); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// Touch records that the cached entry for url was revalidated just now
+func (c *Cache) Touch(url string) error {
+	entry, _, ok := c.Lookup(url)
+	if !ok {
+		return fmt.Errorf("%s is not cached", url)
+	}
+	entry.Fetched = time.Now().UTC()
+	return c.writeMeta(*entry)
+}
+
+func (c *Cache) writeMeta(entry Entry) error {
+	data, err := yaml.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+	if err := os.WriteFile(c.metaPath(entry.URL), data, 0644); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	return nil
+}
+
+// List returns all cached entries sorted by URL
+func (c *Cache) List() ([]Entry, error) {
+	files, err := os.ReadDir(c.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	var entries []Entry
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), ".yaml") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(c.dir, f.Name()))
+		if err != nil {
+			continue
+		}
+		var entry Entry
+		if err := yaml.Unmarshal(data, &entry); err != nil || entry.URL == "" {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].URL < entries[j].URL })
+	return entries, nil
+}
+
+// Clean removes every cached file and returns the number of bytes freed
+func (c *Cache) Clean() (int64, error) {
+	files, err := os.ReadDir(c.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	var freed int64
+	for _, f := range files {
+		if info, err := f.Info(); err == nil && !f.IsDir() {
+			freed += info.Size()
+		}
+		if err := os.RemoveAll(filepath.Join(c.dir, f.Name())); err != nil {
+			return freed, fmt.Errorf("failed to remove %s: %w", f.Name(), err)
+		}
+	}
+	return freed, nil
+}
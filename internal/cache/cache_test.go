@@ -0,0 +1,123 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStoreAndLookup(t *testing.T) {
+	c := New(t.TempDir())
+	url := "https://example.com/templates.zip"
+
+	if _, _, ok := c.Lookup(url); ok {
+		t.Fatal("Lookup() found an entry in an empty cache")
+	}
+
+	path, err := c.Store(url, strings.NewReader("archive"), `"v1"`, "Mon, 02 Jan 2006 15:04:05 GMT")
+	if err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+
+	entry, cached, ok := c.Lookup(url)
+	if !ok || cached != path {
+		t.Fatalf("Lookup() = %v, %q, %v; want stored entry at %q", entry, cached, ok, path)
+	}
+	if entry.ETag != `"v1"` || entry.Size != int64(len("archive")) || entry.Fetched.IsZero() {
+		t.Errorf("Lookup() entry = %+v", entry)
+	}
+	data, err := os.ReadFile(cached)
+	if err != nil || string(data) != "archive" {
+		t.Errorf("cached data = %q, %v", data, err)
+	}
+
+	// Replacing an entry keeps a single file per URL
+	if _, err := c.Store(url, strings.NewReader("archive v2"), `"v2"`, ""); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+	entries, err := c.List()
+	if err != nil || len(entries) != 1 || entries[0].ETag != `"v2"` {
+		t.Errorf("List() = %+v, %v; want one entry with the new ETag", entries, err)
+	}
+}
+
+func TestTouch(t *testing.T) {
+	c := New(t.TempDir())
+	url := "https://example.com/a.zip"
+	if err := c.Touch(url); err == nil {
+		t.Error("Touch() expected error for uncached URL")
+	}
+
+	c.Store(url, strings.NewReader("a"), "", "")
+	before, _, _ := c.Lookup(url)
+	if err := c.Touch(url); err != nil {
+		t.Fatalf("Touch() error = %v", err)
+	}
+	after, _, _ := c.Lookup(url)
+	if after.Fetched.Before(before.Fetched) {
+		t.Errorf("Touch() moved Fetched backwards: %v -> %v", before.Fetched, after.Fetched)
+	}
+}
+
+func TestListAndClean(t *testing.T) {
+	c := New(t.TempDir())
+	c.Store("https://b.example.com/b.zip", strings.NewReader("bb"), "", "")
+	c.Store("https://a.example.com/a.zip", strings.NewReader("a"), "", "")
+
+	entries, err := c.List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(entries) != 2 || entries[0].URL != "https://a.example.com/a.zip" {
+		t.Errorf("List() = %+v, want two entries sorted by URL", entries)
+	}
+
+	freed, err := c.Clean()
+	if err != nil {
+		t.Fatalf("Clean() error = %v", err)
+	}
+	if freed < 3 {
+		t.Errorf("Clean() freed %d bytes, want at least 3", freed)
+	}
+	if entries, _ := c.List(); len(entries) != 0 {
+		t.Errorf("List() after Clean() = %+v, want none", entries)
+	}
+}
+
+func TestMissingDirectory(t *testing.T) {
+	c := New(t.TempDir() + "/missing")
+	if entries, err := c.List(); err != nil || len(entries) != 0 {
+		t.Errorf("List() = %v, %v; want empty", entries, err)
+	}
+	if _, err := c.Clean(); err != nil {
+		t.Errorf("Clean() error = %v", err)
+	}
+}
+
+func TestStoreFileFromPartialDownload(t *testing.T) {
+	c := New(filepath.Join(t.TempDir(), "cache"))
+	url := "https://example.com/templates.zip"
+
+	part, err := c.PartialPath(url)
+	if err != nil {
+		t.Fatalf("PartialPath() error = %v", err)
+	}
+	if filepath.Dir(part) != c.Dir() {
+		t.Errorf("PartialPath() = %s, want a file in the cache directory", part)
+	}
+	if err := os.WriteFile(part, []byte("archive"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	path, err := c.StoreFile(url, part, `"v1"`, "")
+	if err != nil {
+		t.Fatalf("StoreFile() error = %v", err)
+	}
+	if entry, cached, ok := c.Lookup(url); !ok || cached != path || entry.Size != int64(len("archive")) {
+		t.Errorf("Lookup() = %+v, %q, %v", entry, cached, ok)
+	}
+	if _, err := os.Stat(part); !os.IsNotExist(err) {
+		t.Error("StoreFile() should move the file into place")
+	}
+}
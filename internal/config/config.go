@@ -0,0 +1,285 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Registry types
+const (
+	// RegistryTypeZip is a single zip archive with one directory per
+	// template, optionally under a top-level prefix (GitHub archive layout)
+	RegistryTypeZip = "zip"
+	// RegistryTypeIndex is an index.json listing templates with their
+	// metadata and per-template archive URLs
+	RegistryTypeIndex = "index"
+)
+
+// The official registry used when none are configured
+const (
+	OfficialRegistryName = "official"
+	OfficialRegistryURL  = "https://github.com/Vishnuj-n/forge-templates/archive/refs/heads/main.zip"
+)
+
+var registryNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// Config is the user configuration stored in ~/.forge/config.yaml
+type Config struct {
+	TemplatesInitialized bool         `yaml:"templates_initialized,omitempty"`
+	Registries           []Registry   `yaml:"registries,omitempty"`
+	Extract              Extract      `yaml:"extract,omitempty"`
+	TrustedKeys          []TrustedKey `yaml:"trusted_keys,omitempty"`
+	HTTP                 HTTP         `yaml:"http,omitempty"`
+	Policy               *Policy      `yaml:"policy,omitempty"`
+	Update               Update       `yaml:"update,omitempty"`
+}
+
+// Update configures where forge update looks for releases and how it
+// verifies new binaries
+type Update struct {
+	// Channel is the default release channel: stable or beta
+	Channel string `yaml:"channel,omitempty"`
+	// APIURL replaces https://api.github.com, e.g. for GitHub Enterprise;
+	// the FORGE_GITHUB_API environment variable overrides it
+	APIURL string `yaml:"api_url,omitempty"`
+	// RequireSignature refuses releases whose checksums are not signed by
+	// one of the trusted keys
+	RequireSignature bool `yaml:"require_signature,omitempty"`
+}
+
+// Policy restricts the commands templates may run. It is read from the
+// policy section of config.yaml, or from a system-wide policy file with the
+// same keys, which takes precedence.
+type Policy struct {
+	// Allow lists the only executables templates may run; empty allows any.
+	// A command run by path is allowed only if its full path is listed.
+	Allow []string `yaml:"allow,omitempty"`
+	// Deny lists executables templates may never run
+	Deny []string `yaml:"deny,omitempty"`
+	// AllowShells lists shells that may run inline scripts (sh -c,
+	// cmd /c, powershell -Command) and wrappers that may run other
+	// commands (env, busybox, xargs, ...); all others are refused
+	AllowShells []string `yaml:"allow_shells,omitempty"`
+	// NetworkTools replaces the built-in list of network-reaching tools
+	// blocked in forge test. Entries are executables ("curl") or an
+	// executable and subcommand ("git clone").
+	NetworkTools []string `yaml:"network_tools,omitempty"`
+	// AllowNetworkInTest lets forge test run network tools
+	AllowNetworkInTest bool `yaml:"allow_network_in_test,omitempty"`
+}
+
+// HTTP configures the client used for registry downloads and update checks.
+// Zero values keep the built-in defaults.
+type HTTP struct {
+	ConnectTimeout time.Duration `yaml:"connect_timeout,omitempty"` // e.g. "10s"
+	ReadTimeout    time.Duration `yaml:"read_timeout,omitempty"`
+	Retries        *int          `yaml:"retries,omitempty"` // 0 disables retries
+	Proxy          string        `yaml:"proxy,omitempty"`
+	CABundle       string        `yaml:"ca_bundle,omitempty"` // PEM file of extra CAs
+	// Tokens maps host names to bearer tokens, e.g. api.github.com: ghp_...
+	Tokens map[string]string `yaml:"tokens,omitempty"`
+}
+
+// TrustedKey is an ed25519 public key accepted for registry manifest signatures
+type TrustedKey struct {
+	Name string `yaml:"name"`
+	Key  string `yaml:"key"` // base64-encoded, optionally prefixed with "ed25519:"
+}
+
+// Extract overrides the limits applied when extracting template archives.
+// Zero values keep the built-in defaults.
+type Extract struct {
+	MaxBytes   int64 `yaml:"max_bytes,omitempty"`
+	MaxEntries int   `yaml:"max_entries,omitempty"`
+}
+
+// Registry is a source of templates that forge pull can install from
+type Registry struct {
+	Name     string `yaml:"name"`
+	URL      string `yaml:"url"`
+	Type     string `yaml:"type"`
+	Priority int    `yaml:"priority"` // higher is searched first
+
+	// Manifest is the URL of a sha256sum-style checksum manifest listing the
+	// registry archive. When set, downloads are verified against it.
+	Manifest string `yaml:"manifest,omitempty"`
+	// Signed requires a valid signature of the manifest, published at
+	// <manifest>.sig, by one of the trusted keys
+	Signed bool `yaml:"signed,omitempty"`
+}
+
+// DefaultRegistries returns the registries used when none are configured
+func DefaultRegistries() []Registry {
+	return []Registry{{
+		Name: OfficialRegistryName,
+		URL:  OfficialRegistryURL,
+		Type: RegistryTypeZip,
+	}}
+}
+
+// Dir returns the forge home directory (~/.forge)
+func Dir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(home, ".forge"), nil
+}
+
+// Path returns the path of the user config file
+func Path() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.yaml"), nil
+}
+
+// Load reads the config file at path. A missing file yields the default
+// configuration. When no registries are configured the official registry
+// is filled in, so saving the config makes it explicit.
+func Load(path string) (*Config, error) {
+	cfg := &Config{}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	if err == nil {
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+		}
+	}
+
+	if len(cfg.Registries) == 0 {
+		cfg.Registries = DefaultRegistries()
+	}
+	return cfg, nil
+}
+
+// LoadDefault loads the config from the default path
+func LoadDefault() (*Config, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+	return Load(path)
+}
+
+// Save writes the config to path, creating the directory if needed
+func (c *Config) Save(path string) error {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	return nil
+}
+
+// SaveDefault writes the config to the default path
+func (c *Config) SaveDefault() error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	return c.Save(path)
+}
+
+// SortedRegistries returns the registries in search order: highest
+// priority first, then in the order they were added
+func (c *Config) SortedRegistries() []Registry {
+	regs := make([]Registry, len(c.Registries))
+	copy(regs, c.Registries)
+	sort.SliceStable(regs, func(i, j int) bool {
+		return regs[i].Priority > regs[j].Priority
+	})
+	return regs
+}
+
+// Registry returns the registry with the given name
+func (c *Config) Registry(name string) (Registry, bool) {
+	for _, r := range c.Registries {
+		if r.Name == name {
+			return r, true
+		}
+	}
+	return Registry{}, false
+}
+
+// AddRegistry validates and appends a registry
+func (c *Config) AddRegistry(r Registry) error {
+	if !registryNamePattern.MatchString(r.Name) {
+		return fmt.Errorf("registry name can only contain letters, numbers, hyphens, and underscores")
+	}
+	if r.URL == "" {
+		return fmt.Errorf("registry URL is required")
+	}
+	if r.Type == "" {
+		r.Type = RegistryTypeZip
+	}
+	if !IsKnownRegistryType(r.Type) {
+		return fmt.Errorf("unknown registry type %q", r.Type)
+	}
+	if _, exists := c.Registry(r.Name); exists {
+		return fmt.Errorf("registry '%s' already exists", r.Name)
+	}
+	if r.Signed && r.Manifest == "" {
+		return fmt.Errorf("a signed registry needs a manifest URL")
+	}
+
+	c.Registries = append(c.Registries, r)
+	return nil
+}
+
+// RemoveRegistry removes the registry with the given name
+func (c *Config) RemoveRegistry(name string) error {
+	for i, r := range c.Registries {
+		if r.Name == name {
+			c.Registries = append(c.Registries[:i], c.Registries[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("registry '%s' not found", name)
+}
+
+// IsKnownRegistryType reports whether forge can pull from registries of type t
+func IsKnownRegistryType(t string) bool {
+	return t == RegistryTypeZip || t == RegistryTypeIndex
+}
+
+// AddTrustedKey appends a trusted signing key
+func (c *Config) AddTrustedKey(k TrustedKey) error {
+	if !registryNamePattern.MatchString(k.Name) {
+		return fmt.Errorf("key name can only contain letters, numbers, hyphens, and underscores")
+	}
+	for _, existing := range c.TrustedKeys {
+		if existing.Name == k.Name {
+			return fmt.Errorf("trusted key '%s' already exists", k.Name)
+		}
+	}
+	c.TrustedKeys = append(c.TrustedKeys, k)
+	return nil
+}
+
+// RemoveTrustedKey removes the trusted key with the given name
+func (c *Config) RemoveTrustedKey(name string) error {
+	for i, k := range c.TrustedKeys {
+		if k.Name == name {
+			c.TrustedKeys = append(c.TrustedKeys[:i], c.TrustedKeys[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("trusted key '%s' not found", name)
+}
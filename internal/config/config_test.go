@@ -0,0 +1,136 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadMissingUsesDefaults(t *testing.T) {
+	cfg, err := Load(filepath.Join(t.TempDir(), "config.yaml"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if len(cfg.Registries) != 1 || cfg.Registries[0].Name != OfficialRegistryName {
+		t.Errorf("Load() registries = %+v, want the official registry", cfg.Registries)
+	}
+}
+
+func TestLoadKeepsExistingKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("templates_initialized: true\n"), 0644); err != nil {
+		t.Fatalf("WriteFile error = %v", err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !cfg.TemplatesInitialized {
+		t.Error("TemplatesInitialized = false, want true")
+	}
+}
+
+func TestRegistryRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "forge", "config.yaml")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if err := cfg.AddRegistry(Registry{Name: "team", URL: "https://example.com/t.zip", Priority: 10}); err != nil {
+		t.Fatalf("AddRegistry() error = %v", err)
+	}
+	if err := cfg.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	sorted := loaded.SortedRegistries()
+	if len(sorted) != 2 || sorted[0].Name != "team" || sorted[1].Name != OfficialRegistryName {
+		t.Fatalf("SortedRegistries() = %+v, want team before official", sorted)
+	}
+	if sorted[0].Type != RegistryTypeZip {
+		t.Errorf("registry type = %q, want default %q", sorted[0].Type, RegistryTypeZip)
+	}
+
+	if err := loaded.RemoveRegistry("team"); err != nil {
+		t.Fatalf("RemoveRegistry() error = %v", err)
+	}
+	if _, ok := loaded.Registry("team"); ok {
+		t.Error("Registry(team) still present after removal")
+	}
+}
+
+func TestAddRegistryValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		reg  Registry
+	}{
+		{"bad name", Registry{Name: "team/x", URL: "https://example.com"}},
+		{"missing url", Registry{Name: "team"}},
+		{"unknown type", Registry{Name: "team", URL: "https://example.com", Type: "ftp"}},
+		{"duplicate", Registry{Name: OfficialRegistryName, URL: "https://example.com"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Registries: DefaultRegistries()}
+			if err := cfg.AddRegistry(tt.reg); err == nil {
+				t.Errorf("AddRegistry(%+v) should fail", tt.reg)
+			}
+		})
+	}
+}
+
+func TestExtractLimitsRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("extract:\n  max_bytes: 1048576\n  max_entries: 50\n"), 0644); err != nil {
+		t.Fatalf("WriteFile error = %v", err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Extract.MaxBytes != 1<<20 || cfg.Extract.MaxEntries != 50 {
+		t.Errorf("Extract = %+v, want max_bytes 1048576 and max_entries 50", cfg.Extract)
+	}
+}
+
+func TestHTTPSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := "http:\n  connect_timeout: 5s\n  read_timeout: 2m\n  retries: 0\n  tokens:\n    example.com: secret\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("WriteFile error = %v", err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.HTTP.ConnectTimeout != 5*time.Second || cfg.HTTP.ReadTimeout != 2*time.Minute {
+		t.Errorf("timeouts = %v/%v, want 5s/2m", cfg.HTTP.ConnectTimeout, cfg.HTTP.ReadTimeout)
+	}
+	if cfg.HTTP.Retries == nil || *cfg.HTTP.Retries != 0 {
+		t.Errorf("Retries = %v, want explicit 0", cfg.HTTP.Retries)
+	}
+	if cfg.HTTP.Tokens["example.com"] != "secret" {
+		t.Errorf("Tokens = %v", cfg.HTTP.Tokens)
+	}
+
+	if err := cfg.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	saved, _ := os.ReadFile(path)
+	if !strings.Contains(string(saved), "connect_timeout: 5s") {
+		t.Errorf("saved config should keep durations readable:\n%s", saved)
+	}
+}
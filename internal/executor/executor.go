@@ -7,6 +7,7 @@ import (
 	"os/exec"
 	"strings"
 
+	"forge/internal/policy"
 	"forge/internal/template"
 )
 
@@ -14,6 +15,7 @@ import (
 type Executor struct {
 	workDir  string
 	testMode bool
+	policy   *policy.Policy
 }
 
 // New creates a new command executor
@@ -27,6 +29,11 @@ func New(workDir string, interactive bool, testMode bool) *Executor {
 	}
 }
 
+// SetPolicy makes Run refuse commands the policy denies; nil allows all
+func (e *Executor) SetPolicy(p *policy.Policy) {
+	e.policy = p
+}
+
 // Run executes a command in the workspace
 func (e *Executor) Run(cmd template.Command) error {
 	if len(cmd.Cmd) == 0 {
@@ -34,14 +41,12 @@ func (e *Executor) Run(cmd template.Command) error {
 	}
 
 	// Determine which command to run
-	cmdToRun := cmd.Cmd
+	cmdToRun, ok := Resolve(cmd, e.testMode)
 
 	// During test mode, handle interactive commands
 	if e.testMode && cmd.Interactive {
-		if len(cmd.TestCmd) > 0 {
-			// Use test command
-			fmt.Printf("[forge test] Using test command for interactive step: %s\n", strings.Join(cmd.TestCmd, " "))
-			cmdToRun = cmd.TestCmd
+		if ok {
+			fmt.Printf("[forge test] Using test command for interactive step: %s\n", strings.Join(cmdToRun, " "))
 		} else {
 			// Skip with warning
 			fmt.Printf("[forge test] Skipping interactive command: %s\n", strings.Join(cmd.Cmd, " "))
@@ -49,8 +54,20 @@ func (e *Executor) Run(cmd template.Command) error {
 		}
 	}
 
+	name := cmdToRun[0]
+	if e.policy != nil {
+		if err := e.policy.Check(cmdToRun, e.workDir, e.testMode); err != nil {
+			return err
+		}
+		// Run the very file the policy judged
+		if path, err := policy.LookPath(name, e.workDir); err == nil {
+			name = path
+		}
+	}
+
 	// Create command
-	execCmd := exec.Command(cmdToRun[0], cmdToRun[1:]...)
+	execCmd := exec.Command(name, cmdToRun[1:]...)
+	execCmd.Args[0] = cmdToRun[0]
 	execCmd.Dir = e.workDir
 
 	// For forge init: always use real TTY (inherit terminal I/O)
@@ -85,3 +102,16 @@ func (e *Executor) Run(cmd template.Command) error {
 
 	return nil
 }
+
+// Resolve returns the argument list that would be executed for cmd.
+// In test mode, interactive commands are replaced by their test_cmd;
+// ok is false when such a command has no test_cmd and would be skipped.
+func Resolve(cmd template.Command, testMode bool) (args []string, ok bool) {
+	if testMode && cmd.Interactive {
+		if len(cmd.TestCmd) > 0 {
+			return cmd.TestCmd, true
+		}
+		return nil, false
+	}
+	return cmd.Cmd, true
+}
@@ -1,9 +1,14 @@
 package executor
 
 import (
+	"errors"
 	"os"
+	"path/filepath"
+	"runtime"
 	"testing"
 
+	"forge/internal/config"
+	"forge/internal/policy"
 	"forge/internal/template"
 )
 
@@ -61,3 +66,51 @@ func TestExecutorRunEmptyCommand(t *testing.T) {
 		t.Fatal("Run() should fail for empty command")
 	}
 }
+
+func TestExecutorRunPolicyDenied(t *testing.T) {
+	wsDir := t.TempDir()
+	exec := New(wsDir, false, true)
+	exec.SetPolicy(policy.New(config.Policy{Allow: []string{"git"}}, "policy.yaml"))
+
+	err := exec.Run(template.Command{Cmd: []string{"sh", "-c", "touch ran"}})
+	var denied *policy.DeniedError
+	if !errors.As(err, &denied) || denied.Rule != policy.RuleAllow {
+		t.Fatalf("Run() error = %v, want a denial by the allow rule", err)
+	}
+	if _, err := os.Stat(filepath.Join(wsDir, "ran")); err == nil {
+		t.Error("denied command should not have run")
+	}
+
+	if err := exec.Run(template.Command{Cmd: []string{"git", "init"}}); err != nil {
+		t.Errorf("allowed command error = %v", err)
+	}
+}
+
+func TestExecutorRunsFileThePolicyChecked(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a shell script")
+	}
+	// Both directories have bin/tool; PATH names bin relatively
+	forgeDir, wsDir := t.TempDir(), t.TempDir()
+	for _, dir := range []string{forgeDir, wsDir} {
+		os.MkdirAll(filepath.Join(dir, "bin"), 0755)
+		script := "#!/bin/sh\n: > \"" + filepath.Join(dir, "ran") + "\"\n"
+		if err := os.WriteFile(filepath.Join(dir, "bin", "tool"), []byte(script), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(forgeDir)
+	t.Setenv("PATH", "bin")
+
+	exec := New(wsDir, false, true)
+	exec.SetPolicy(policy.New(config.Policy{Allow: []string{filepath.Join(wsDir, "bin", "tool")}}, "policy.yaml"))
+	if err := exec.Run(template.Command{Cmd: []string{"tool"}}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(wsDir, "ran")); err != nil {
+		t.Error("the workspace's bin/tool, which the policy allowed, did not run")
+	}
+	if _, err := os.Stat(filepath.Join(forgeDir, "ran")); err == nil {
+		t.Error("forge's own bin/tool ran instead of the one the policy checked")
+	}
+}
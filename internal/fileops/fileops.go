@@ -59,6 +59,51 @@ func (f *FileOps) CopyFiles(copyPaths []string) error {
 	return nil
 }
 
+// FileCopy describes a single file created by a copy operation
+type FileCopy struct {
+	Source string // relative to the template directory
+	Target string // relative to the workspace directory
+}
+
+// CopyTargets lists every file CopyFiles would create, without copying anything
+func (f *FileOps) CopyTargets(copyPaths []string) ([]FileCopy, error) {
+	var copies []FileCopy
+	for _, srcPath := range copyPaths {
+		absSrc := filepath.Join(f.templateDir, srcPath)
+
+		info, err := os.Stat(absSrc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat %s: %w", srcPath, err)
+		}
+
+		if !info.IsDir() {
+			copies = append(copies, FileCopy{Source: srcPath, Target: filepath.Base(srcPath)})
+			continue
+		}
+
+		// Directory contents land relative to the workspace root, as in copyDir
+		err = filepath.Walk(absSrc, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+			relPath, err := filepath.Rel(absSrc, path)
+			if err != nil {
+				return err
+			}
+			copies = append(copies, FileCopy{
+				Source: filepath.Join(srcPath, relPath),
+				Target: relPath,
+			})
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list directory %s: %w", srcPath, err)
+		}
+	}
+
+	return copies, nil
+}
+
 // ApplyAppends applies append-only patches
 func (f *FileOps) ApplyAppends(patches []template.AppendPatch) error {
 	for _, patch := range patches {
@@ -0,0 +1,149 @@
+package index
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"forge/internal/lock"
+	"forge/internal/remote"
+	"forge/internal/scaffold"
+	"forge/internal/template"
+)
+
+// FileName is the conventional name of a registry index
+const FileName = "index.json"
+
+// PublishPath is where registries that accept uploads take them, relative
+// to the index URL (see forge publish)
+const PublishPath = "api/publish"
+
+// Index lists the templates a registry provides
+type Index struct {
+	Templates []Entry `json:"templates"`
+}
+
+// Entry describes one template in an index
+type Entry struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Version     string   `json:"version,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Requires    []string `json:"requires,omitempty"` // executables the commands run
+	Checksum    string   `json:"checksum,omitempty"` // content hash, see lock.HashDir
+	URL         string   `json:"url,omitempty"`      // template archive, relative to the index
+	Commands    []string `json:"commands,omitempty"`
+}
+
+// Parse decodes an index. Template names become directory names when
+// installed, so any that is not a valid template name is rejected.
+func Parse(data []byte) (*Index, error) {
+	idx := &Index{}
+	if err := json.Unmarshal(data, idx); err != nil {
+		return nil, fmt.Errorf("invalid registry index: %w", err)
+	}
+	for _, e := range idx.Templates {
+		if err := scaffold.ValidateName(e.Name); err != nil {
+			return nil, fmt.Errorf("invalid registry index: template %q: %w", e.Name, err)
+		}
+	}
+	return idx, nil
+}
+
+// Bytes encodes the index with templates sorted by name
+func (idx *Index) Bytes() ([]byte, error) {
+	sort.Slice(idx.Templates, func(i, j int) bool { return idx.Templates[i].Name < idx.Templates[j].Name })
+	data, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode registry index: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// Find returns the entry for the named template
+func (idx *Index) Find(name string) (Entry, bool) {
+	for _, e := range idx.Templates {
+		if e.Name == name {
+			return e, true
+		}
+	}
+	return Entry{}, false
+}
+
+// Set adds or replaces the entry for e.Name
+func (idx *Index) Set(e Entry) {
+	for i := range idx.Templates {
+		if idx.Templates[i].Name == e.Name {
+			idx.Templates[i] = e
+			return
+		}
+	}
+	idx.Templates = append(idx.Templates, e)
+}
+
+// FromTemplate builds the metadata part of an entry from a parsed template
+func FromTemplate(name string, t *template.Template) Entry {
+	e := Entry{Name: name, Description: t.Description, Version: t.Version, Tags: t.Tags}
+
+	seen := map[string]bool{}
+	for _, c := range t.Commands {
+		e.Commands = append(e.Commands, c.String())
+		if len(c.Cmd) == 0 {
+			continue
+		}
+		if tool := c.Cmd[0]; !seen[tool] {
+			seen[tool] = true
+			e.Requires = append(e.Requires, tool)
+		}
+	}
+	sort.Strings(e.Requires)
+	return e
+}
+
+// FromDir builds an entry for the template in dir, named after the directory
+func FromDir(dir string) (Entry, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "template.yaml"))
+	if err != nil {
+		return Entry{}, fmt.Errorf("failed to read template.yaml: %w", err)
+	}
+	t, err := template.Parse(data)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	e := FromTemplate(filepath.Base(dir), t)
+	if e.Checksum, err = lock.HashDir(dir, remote.SourceFile); err != nil {
+		return Entry{}, err
+	}
+	return e, nil
+}
+
+// BuildDir builds an index of every template directory directly under parent.
+// Directories without template.yaml and hidden directories are skipped.
+func BuildDir(parent string) (*Index, error) {
+	dirs, err := os.ReadDir(parent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", parent, err)
+	}
+
+	idx := &Index{}
+	for _, d := range dirs {
+		if !d.IsDir() || strings.HasPrefix(d.Name(), ".") {
+			continue
+		}
+		dir := filepath.Join(parent, d.Name())
+		if _, err := os.Stat(filepath.Join(dir, "template.yaml")); err != nil {
+			continue
+		}
+		e, err := FromDir(dir)
+		if err != nil {
+			return nil, fmt.Errorf("template '%s': %w", d.Name(), err)
+		}
+		idx.Templates = append(idx.Templates, e)
+	}
+	sort.Slice(idx.Templates, func(i, j int) bool { return idx.Templates[i].Name < idx.Templates[j].Name })
+	return idx, nil
+}
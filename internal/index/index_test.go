@@ -0,0 +1,137 @@
+package index
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const pythonYAML = `name: python
+description: Python project with a virtual environment
+version: 1.2.0
+tags: [python, venv]
+commands:
+  - cmd: ["python", "-m", "venv", ".venv"]
+  - cmd: ["git", "init"]
+  - cmd: ["python", "-m", "pip", "install", "-U", "pip"]
+files:
+  copy: []
+`
+
+func writeTemplate(t *testing.T, parent, name, yaml string) string {
+	t.Helper()
+	dir := filepath.Join(parent, name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "template.yaml"), []byte(yaml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestBuildDir(t *testing.T) {
+	parent := t.TempDir()
+	writeTemplate(t, parent, "python", pythonYAML)
+	writeTemplate(t, parent, ".python.backup", pythonYAML)
+	os.MkdirAll(filepath.Join(parent, "not-a-template"), 0755)
+
+	idx, err := BuildDir(parent)
+	if err != nil {
+		t.Fatalf("BuildDir() error = %v", err)
+	}
+	if len(idx.Templates) != 1 {
+		t.Fatalf("BuildDir() found %d templates, want 1", len(idx.Templates))
+	}
+
+	e := idx.Templates[0]
+	if e.Name != "python" || e.Version != "1.2.0" || e.Description == "" {
+		t.Errorf("entry = %+v", e)
+	}
+	if !reflect.DeepEqual(e.Requires, []string{"git", "python"}) {
+		t.Errorf("Requires = %v, want [git python]", e.Requires)
+	}
+	if len(e.Commands) != 3 || e.Commands[0] != "python -m venv .venv" {
+		t.Errorf("Commands = %v", e.Commands)
+	}
+	if len(e.Checksum) != len("sha256:")+64 {
+		t.Errorf("Checksum = %q", e.Checksum)
+	}
+}
+
+func TestChecksumIgnoresSourceMetadata(t *testing.T) {
+	dir := writeTemplate(t, t.TempDir(), "python", pythonYAML)
+	before, err := FromDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, ".forge-source.yaml"), []byte("source: somewhere\n"), 0644)
+	after, err := FromDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if before.Checksum != after.Checksum {
+		t.Error("source metadata must not change the checksum")
+	}
+}
+
+func TestParseRoundTrip(t *testing.T) {
+	idx := &Index{}
+	idx.Set(Entry{Name: "rust", Version: "0.1.0"})
+	idx.Set(Entry{Name: "go", URL: "go.tar.gz"})
+	idx.Set(Entry{Name: "rust", Version: "0.2.0"})
+
+	data, err := idx.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(parsed.Templates) != 2 || parsed.Templates[0].Name != "go" {
+		t.Fatalf("templates = %+v, want go and rust sorted", parsed.Templates)
+	}
+	if e, ok := parsed.Find("rust"); !ok || e.Version != "0.2.0" {
+		t.Errorf("Find(rust) = %+v, %v", e, ok)
+	}
+
+	if _, err := Parse([]byte(`{"templates":[{"version":"1"}]}`)); err == nil {
+		t.Error("Parse() should reject entries without a name")
+	}
+	if _, err := Parse([]byte(`{"templates":[{"name":"../../escaped"}]}`)); err == nil {
+		t.Error("Parse() should reject names that are not valid template names")
+	}
+}
+
+func TestScore(t *testing.T) {
+	python := Entry{Name: "python", Description: "Python project with a virtual environment", Tags: []string{"venv"}}
+	fastapi := Entry{Name: "fastapi", Description: "FastAPI service in Python", Tags: []string{"python", "web"}}
+	node := Entry{Name: "node", Description: "Node.js package"}
+
+	tests := []struct {
+		query string
+		entry Entry
+		match bool
+	}{
+		{"python", python, true},
+		{"pyth", python, true},
+		{"pyhton", python, true}, // transposition
+		{"venv", python, true},
+		{"virtual", python, true},
+		{"python web", fastapi, true},
+		{"python web", python, false},
+		{"rust", node, false},
+		{"", node, false},
+	}
+	for _, tt := range tests {
+		if got := tt.entry.Score(tt.query) > 0; got != tt.match {
+			t.Errorf("%s.Score(%q) matched = %v, want %v", tt.entry.Name, tt.query, got, tt.match)
+		}
+	}
+
+	if python.Score("python") <= fastapi.Score("python") {
+		t.Error("an exact name match should rank above a tag match")
+	}
+}
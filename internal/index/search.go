@@ -0,0 +1,106 @@
+package index
+
+import (
+	"strings"
+)
+
+// Score rates how well e matches query, 0 meaning no match. Every word of
+// the query must match the name, a tag or the description; names score
+// highest, and small typos in the name are tolerated.
+func (e Entry) Score(query string) int {
+	words := strings.Fields(strings.ToLower(query))
+	if len(words) == 0 {
+		return 0
+	}
+
+	total := 0
+	for _, w := range words {
+		s := e.scoreWord(w)
+		if s == 0 {
+			return 0
+		}
+		total += s
+	}
+	return total
+}
+
+func (e Entry) scoreWord(w string) int {
+	name := strings.ToLower(e.Name)
+	best := 0
+	consider := func(s int) {
+		if s > best {
+			best = s
+		}
+	}
+
+	switch {
+	case name == w:
+		consider(100)
+	case strings.HasPrefix(name, w):
+		consider(80)
+	case strings.Contains(name, w):
+		consider(60)
+	case len(w) >= 4 && editDistance(name, w) <= maxTypos(w):
+		consider(50)
+	case isSubsequence(w, name):
+		consider(30)
+	}
+
+	for _, tag := range e.Tags {
+		tag = strings.ToLower(tag)
+		if tag == w {
+			consider(70)
+		} else if strings.HasPrefix(tag, w) {
+			consider(40)
+		}
+	}
+
+	if strings.Contains(strings.ToLower(e.Description), w) {
+		consider(20)
+	}
+	return best
+}
+
+func maxTypos(w string) int {
+	if len(w) >= 8 {
+		return 2
+	}
+	return 1
+}
+
+// isSubsequence reports whether the letters of sub appear in s in order
+func isSubsequence(sub, s string) bool {
+	i := 0
+	for j := 0; i < len(sub) && j < len(s); j++ {
+		if sub[i] == s[j] {
+			i++
+		}
+	}
+	return i == len(sub)
+}
+
+// editDistance returns the Levenshtein distance between a and b, counting
+// an adjacent transposition as one edit
+func editDistance(a, b string) int {
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,254 @@
+package lint
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+
+	"forge/internal/fileops"
+	"forge/internal/scaffold"
+	"forge/internal/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Severity classifies a lint issue
+type Severity string
+
+const (
+	// SeverityError marks problems that make forge init fail or misbehave
+	SeverityError Severity = "error"
+	// SeverityWarning marks problems that may work but deserve attention
+	SeverityWarning Severity = "warning"
+)
+
+// Issue is a single lint finding, positioned in template.yaml when possible
+type Issue struct {
+	Severity Severity
+	Line     int
+	Column   int
+	Message  string
+}
+
+// Result holds all issues found in a template
+type Result struct {
+	Path   string // path to template.yaml
+	Issues []Issue
+}
+
+// HasErrors returns true if any issue has error severity
+func (r *Result) HasErrors() bool {
+	for _, issue := range r.Issues {
+		if issue.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Count returns the number of issues with the given severity
+func (r *Result) Count(sev Severity) int {
+	n := 0
+	for _, issue := range r.Issues {
+		if issue.Severity == sev {
+			n++
+		}
+	}
+	return n
+}
+
+// Format renders an issue in the conventional file:line:col form
+func (r *Result) Format(issue Issue) string {
+	if issue.Line == 0 {
+		return fmt.Sprintf("%s: %s: %s", r.Path, issue.Severity, issue.Message)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s: %s", r.Path, issue.Line, issue.Column, issue.Severity, issue.Message)
+}
+
+// lookPath resolves executables; replaced in tests
+var lookPath = exec.LookPath
+
+// Lint checks a template (by path or name) without executing anything
+func Lint(templatePath string) (*Result, error) {
+	resolvedPath, err := template.ResolveTemplatePath(templatePath)
+	if err != nil {
+		return nil, err
+	}
+
+	yamlPath, err := template.YAMLPath(resolvedPath)
+	if err != nil {
+		return nil, err
+	}
+	templateDir := filepath.Dir(yamlPath)
+
+	data, err := os.ReadFile(yamlPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template file: %w", err)
+	}
+
+	l := &linter{
+		result:      &Result{Path: yamlPath},
+		templateDir: templateDir,
+	}
+	l.run(data)
+
+	sort.SliceStable(l.result.Issues, func(i, j int) bool {
+		a, b := l.result.Issues[i], l.result.Issues[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+
+	return l.result, nil
+}
+
+type linter struct {
+	result      *Result
+	templateDir string
+	doc         *template.Document
+}
+
+func (l *linter) add(sev Severity, node *yaml.Node, format string, args ...any) {
+	issue := Issue{Severity: sev, Message: fmt.Sprintf(format, args...)}
+	if node != nil {
+		issue.Line = node.Line
+		issue.Column = node.Column
+	}
+	l.result.Issues = append(l.result.Issues, issue)
+}
+
+// addParseError records a positioned template.ParseError as an issue
+func (l *linter) addParseError(err error) {
+	issue := Issue{Severity: SeverityError, Message: err.Error()}
+	var perr *template.ParseError
+	if errors.As(err, &perr) {
+		issue.Line, issue.Column, issue.Message = perr.Line, perr.Column, perr.Msg
+	}
+	l.result.Issues = append(l.result.Issues, issue)
+}
+
+func (l *linter) run(data []byte) {
+	doc, err := template.Decode(data)
+	if err != nil {
+		l.addParseError(err)
+		return
+	}
+	l.doc = doc
+
+	// Unsupported format versions make every other check unreliable
+	if err := doc.CheckAPIVersion(); err != nil {
+		l.addParseError(err)
+		return
+	}
+
+	for _, perr := range doc.UnknownFields() {
+		l.addParseError(perr)
+	}
+
+	tmpl := doc.Template
+	if tmpl.APIVersion == "" {
+		l.add(SeverityWarning, doc.Root, "template has no apiVersion; run 'forge migrate' to upgrade it to %s", template.CurrentAPIVersion)
+	}
+	l.checkName(tmpl)
+	l.checkCommands(tmpl)
+	produced := l.checkCopies(tmpl)
+	l.checkAppends(tmpl, produced)
+}
+
+func (l *linter) checkName(tmpl *template.Template) {
+	nameNode := l.doc.Node("name")
+	if tmpl.Name == "" {
+		l.add(SeverityError, nameNode, "template name is required")
+		return
+	}
+	if err := scaffold.ValidateName(tmpl.Name); err != nil {
+		l.add(SeverityError, nameNode, "invalid name %q: %v", tmpl.Name, err)
+	}
+}
+
+func (l *linter) checkCommands(tmpl *template.Template) {
+	for i, cmd := range tmpl.Commands {
+		cmdNode := l.doc.Node("commands", i, "cmd")
+		if len(cmd.Cmd) == 0 || cmd.Cmd[0] == "" {
+			l.add(SeverityError, orNode(cmdNode, l.doc.Node("commands", i)), "command %d: cmd must start with an executable", i)
+			continue
+		}
+
+		l.checkExecutable(cmd.Cmd[0], l.doc.Node("commands", i, "cmd", 0))
+
+		testNode := l.doc.Node("commands", i, "test_cmd")
+		switch {
+		case cmd.Interactive && len(cmd.TestCmd) == 0:
+			l.add(SeverityWarning, orNode(l.doc.Node("commands", i, "interactive"), cmdNode),
+				"command %d (%s) is interactive but has no test_cmd; forge test will skip it", i, cmd.String())
+		case !cmd.Interactive && len(cmd.TestCmd) > 0:
+			l.add(SeverityWarning, testNode, "command %d has test_cmd but is not interactive; test_cmd is ignored", i)
+		case len(cmd.TestCmd) > 0:
+			l.checkExecutable(cmd.TestCmd[0], l.doc.Node("commands", i, "test_cmd", 0))
+		}
+	}
+}
+
+func (l *linter) checkExecutable(name string, node *yaml.Node) {
+	if _, err := lookPath(name); err != nil {
+		l.add(SeverityWarning, node, "executable %q not found in PATH", name)
+	}
+}
+
+// checkCopies verifies copy sources and returns the set of files they produce
+func (l *linter) checkCopies(tmpl *template.Template) map[string]bool {
+	produced := map[string]bool{}
+	fops := fileops.New("", l.templateDir)
+
+	for i, src := range tmpl.Files.Copy {
+		copies, err := fops.CopyTargets([]string{src})
+		if err != nil {
+			l.add(SeverityError, l.doc.Node("files", "copy", i), "copy source %q does not exist", src)
+			continue
+		}
+		for _, c := range copies {
+			produced[filepath.ToSlash(filepath.Clean(c.Target))] = true
+		}
+	}
+
+	return produced
+}
+
+func (l *linter) checkAppends(tmpl *template.Template, produced map[string]bool) {
+	for i, patch := range tmpl.Files.Append {
+		if patch.Source == "" {
+			l.add(SeverityError, l.doc.Node("files", "append", i), "append patch %d: source is required", i)
+		} else if _, err := os.Stat(filepath.Join(l.templateDir, patch.Source)); err != nil {
+			l.add(SeverityError, l.doc.Node("files", "append", i, "source"), "append source %q does not exist", patch.Source)
+		}
+
+		if patch.Target == "" {
+			l.add(SeverityError, l.doc.Node("files", "append", i), "append patch %d: target is required", i)
+			continue
+		}
+
+		target := filepath.ToSlash(filepath.Clean(patch.Target))
+		if produced[target] {
+			continue
+		}
+		targetNode := l.doc.Node("files", "append", i, "target")
+		if len(tmpl.Commands) > 0 {
+			l.add(SeverityWarning, targetNode, "append target %q is not created by files.copy; it must be created by a command", patch.Target)
+		} else {
+			l.add(SeverityError, targetNode, "append target %q is not produced by any earlier step", patch.Target)
+		}
+	}
+}
+
+func orNode(nodes ...*yaml.Node) *yaml.Node {
+	for _, n := range nodes {
+		if n != nil {
+			return n
+		}
+	}
+	return nil
+}
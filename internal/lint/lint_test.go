@@ -0,0 +1,163 @@
+package lint
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTemplate creates a template directory with the given template.yaml
+// and extra files (path -> content)
+func writeTemplate(t *testing.T, yamlContent string, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	files["template.yaml"] = yamlContent
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("MkdirAll error = %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile error = %v", err)
+		}
+	}
+	return dir
+}
+
+func stubLookPath(t *testing.T, known ...string) {
+	t.Helper()
+	orig := lookPath
+	lookPath = func(name string) (string, error) {
+		for _, k := range known {
+			if k == name {
+				return "/usr/bin/" + name, nil
+			}
+		}
+		return "", errors.New("not found")
+	}
+	t.Cleanup(func() { lookPath = orig })
+}
+
+func TestLintClean(t *testing.T) {
+	stubLookPath(t, "git", "npm")
+	dir := writeTemplate(t, `apiVersion: forge/v1
+name: clean
+commands:
+  - cmd: ["git", "init"]
+  - cmd: ["npm", "init"]
+    interactive: true
+    test_cmd: ["npm", "init", "-y"]
+files:
+  copy:
+    - files/
+  append:
+    - target: ".gitignore"
+      source: "patches/gitignore.append"
+`, map[string]string{
+		"files/.gitignore":         "*.log\n",
+		"patches/gitignore.append": ".env\n",
+	})
+
+	result, err := Lint(dir)
+	if err != nil {
+		t.Fatalf("Lint() error = %v", err)
+	}
+	if len(result.Issues) != 0 {
+		t.Errorf("Lint() issues = %+v, want none", result.Issues)
+	}
+}
+
+func TestLintIssues(t *testing.T) {
+	stubLookPath(t, "git")
+	dir := writeTemplate(t, `name: bad name
+commands:
+  - cmd: ["git", "init"]
+  - cmd: ["npm", "init"]
+    intractive: true
+  - cmd: ["git", "status"]
+    interactive: true
+files:
+  copy:
+    - files/missing.txt
+  append:
+    - target: "README.md"
+      source: "patches/readme.append"
+`, map[string]string{})
+
+	result, err := Lint(dir)
+	if err != nil {
+		t.Fatalf("Lint() error = %v", err)
+	}
+
+	want := []struct {
+		sev  Severity
+		line int
+		text string
+	}{
+		{SeverityWarning, 1, "no apiVersion"},
+		{SeverityError, 1, "invalid name"},
+		{SeverityWarning, 4, `executable "npm" not found`},
+		{SeverityError, 5, `unknown field "intractive"`},
+		{SeverityWarning, 7, "no test_cmd"},
+		{SeverityError, 10, `copy source "files/missing.txt" does not exist`},
+		{SeverityWarning, 12, "must be created by a command"},
+		{SeverityError, 13, `append source "patches/readme.append" does not exist`},
+	}
+
+	if len(result.Issues) != len(want) {
+		t.Fatalf("got %d issues, want %d:\n%+v", len(result.Issues), len(want), result.Issues)
+	}
+	for i, w := range want {
+		got := result.Issues[i]
+		if got.Severity != w.sev || got.Line != w.line || !strings.Contains(got.Message, w.text) {
+			t.Errorf("issue %d = %+v, want %s at line %d containing %q", i, got, w.sev, w.line, w.text)
+		}
+	}
+	if !result.HasErrors() {
+		t.Error("HasErrors() = false, want true")
+	}
+}
+
+func TestLintAppendWithoutProducer(t *testing.T) {
+	dir := writeTemplate(t, `apiVersion: forge/v1
+name: patch-only
+files:
+  append:
+    - target: ".gitignore"
+      source: "patches/gitignore.append"
+`, map[string]string{"patches/gitignore.append": ".env\n"})
+
+	result, err := Lint(dir)
+	if err != nil {
+		t.Fatalf("Lint() error = %v", err)
+	}
+	if len(result.Issues) != 1 || result.Issues[0].Severity != SeverityError || result.Issues[0].Line != 5 {
+		t.Errorf("Lint() issues = %+v, want one error at line 5", result.Issues)
+	}
+}
+
+func TestLintSyntaxError(t *testing.T) {
+	dir := writeTemplate(t, "name: broken\ncommands:\n  - cmd: [\"git\"\n", map[string]string{})
+
+	result, err := Lint(dir)
+	if err != nil {
+		t.Fatalf("Lint() error = %v", err)
+	}
+	if !result.HasErrors() || result.Issues[0].Line == 0 {
+		t.Errorf("Lint() issues = %+v, want a positioned syntax error", result.Issues)
+	}
+}
+
+func TestLintUnsupportedAPIVersion(t *testing.T) {
+	dir := writeTemplate(t, "apiVersion: forge/v99\nname: future\nnew_feature: true\n", map[string]string{})
+
+	result, err := Lint(dir)
+	if err != nil {
+		t.Fatalf("Lint() error = %v", err)
+	}
+	if len(result.Issues) != 1 || !strings.Contains(result.Issues[0].Message, "forge update") {
+		t.Errorf("Lint() issues = %+v, want a single newer-forge error", result.Issues)
+	}
+}
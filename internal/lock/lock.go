@@ -0,0 +1,200 @@
+package lock
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"forge/internal/config"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the name of the lockfile, both globally and per repository
+const FileName = "forge.lock"
+
+// File records the exact version of each installed template
+type File struct {
+	Templates []Entry `yaml:"templates"`
+
+	path string
+}
+
+// Entry pins one installed template
+type Entry struct {
+	Name    string `yaml:"name"`
+	Source  string `yaml:"source"`            // registry/name, git+ URL or local path
+	Ref     string `yaml:"ref,omitempty"`     // git ref as requested
+	Commit  string `yaml:"commit,omitempty"`  // resolved git commit, also for registry archives
+	Version string `yaml:"version,omitempty"` // registry index version
+	URL     string `yaml:"url,omitempty"`     // registry archive holding the pinned content
+	Hash    string `yaml:"hash"`              // content hash, see HashDir
+}
+
+// GlobalPath returns the per-user lockfile path (~/.forge/forge.lock)
+func GlobalPath() (string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, FileName), nil
+}
+
+// Find returns the lockfile to use: ./forge.lock when the current
+// directory has one, otherwise the global lockfile
+func Find() (string, error) {
+	if _, err := os.Stat(FileName); err == nil {
+		return filepath.Abs(FileName)
+	}
+	return GlobalPath()
+}
+
+// Load reads a lockfile. A missing file yields an empty lockfile that will
+// be created on Save.
+func Load(path string) (*File, error) {
+	f := &File{path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lockfile: %w", err)
+	}
+	if err := yaml.Unmarshal(data, f); err != nil {
+		return nil, fmt.Errorf("failed to parse lockfile %s: %w", path, err)
+	}
+	return f, nil
+}
+
+// Path returns the file the lockfile is read from and saved to
+func (f *File) Path() string {
+	return f.path
+}
+
+// LocalSource returns how a local source at absPath is recorded: relative
+// to the lockfile's directory, as ./dir, when it lies inside it, so a
+// per-repository lockfile can be shared; otherwise the absolute path
+func (f *File) LocalSource(absPath string) string {
+	rel, err := filepath.Rel(filepath.Dir(f.path), absPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return absPath
+	}
+	return "./" + filepath.ToSlash(rel)
+}
+
+// ResolveSource returns the path a recorded local source refers to,
+// resolving relative sources against the lockfile's directory
+func (f *File) ResolveSource(source string) string {
+	if filepath.IsAbs(source) {
+		return source
+	}
+	return filepath.Join(filepath.Dir(f.path), filepath.FromSlash(source))
+}
+
+// Save writes the lockfile, with entries sorted by name
+func (f *File) Save() error {
+	sort.Slice(f.Templates, func(i, j int) bool {
+		return f.Templates[i].Name < f.Templates[j].Name
+	})
+
+	data, err := yaml.Marshal(f)
+	if err != nil {
+		return fmt.Errorf("failed to encode lockfile: %w", err)
+	}
+	header := "# Generated by forge pull. Commit this file to pin template versions.\n"
+	if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+		return fmt.Errorf("failed to create lockfile directory: %w", err)
+	}
+	if err := os.WriteFile(f.path, append([]byte(header), data...), 0644); err != nil {
+		return fmt.Errorf("failed to write lockfile: %w", err)
+	}
+	return nil
+}
+
+// Get returns the entry for the named template
+func (f *File) Get(name string) (Entry, bool) {
+	for _, e := range f.Templates {
+		if e.Name == name {
+			return e, true
+		}
+	}
+	return Entry{}, false
+}
+
+// Set adds or replaces the entry for e.Name
+func (f *File) Set(e Entry) {
+	for i := range f.Templates {
+		if f.Templates[i].Name == e.Name {
+			f.Templates[i] = e
+			return
+		}
+	}
+	f.Templates = append(f.Templates, e)
+}
+
+// HashDir returns a content hash of a template directory, "sha256:<hex>",
+// covering every regular file's relative path and content. File modes are
+// left out so the hash is the same on every platform.
+// Files named in exclude (relative, slash-separated) are ignored.
+func HashDir(dir string, exclude ...string) (string, error) {
+	skip := map[string]bool{}
+	for _, e := range exclude {
+		skip[e] = true
+	}
+
+	var files []string
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		if rel = filepath.ToSlash(rel); !skip[rel] {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to hash %s: %w", dir, err)
+	}
+	sort.Strings(files)
+
+	h := sha256.New()
+	for _, rel := range files {
+		if err := hashFile(h, dir, rel); err != nil {
+			return "", fmt.Errorf("failed to hash %s: %w", rel, err)
+		}
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hashFile(h io.Writer, dir, rel string) error {
+	path := filepath.Join(dir, filepath.FromSlash(rel))
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	// Length-prefix the path and size so different layouts cannot collide
+	fmt.Fprintf(h, "%d:%s %d\n", len(rel), rel, info.Size())
+	_, err = io.Copy(h, f)
+	return err
+}
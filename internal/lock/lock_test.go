@@ -0,0 +1,129 @@
+package lock
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoadMissingIsEmpty(t *testing.T) {
+	f, err := Load(filepath.Join(t.TempDir(), FileName))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(f.Templates) != 0 {
+		t.Errorf("Templates = %+v, want none", f.Templates)
+	}
+}
+
+func TestSaveAndLoadRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", FileName)
+	f, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	python := Entry{Name: "python", Source: "official/python", Hash: "sha256:aa"}
+	goEntry := Entry{Name: "go", Source: "git+https://host/repo//go@v1", Ref: "v1", Commit: "abc123", Hash: "sha256:bb"}
+	f.Set(python)
+	f.Set(goEntry)
+	python.Hash = "sha256:cc"
+	f.Set(python)
+	if err := f.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(loaded.Templates) != 2 || loaded.Templates[0].Name != "go" {
+		t.Fatalf("Templates = %+v, want go and python sorted by name", loaded.Templates)
+	}
+	if got, ok := loaded.Get("go"); !ok || got != goEntry {
+		t.Errorf("Get(go) = %+v, %v; want %+v", got, ok, goEntry)
+	}
+	if got, _ := loaded.Get("python"); got.Hash != "sha256:cc" {
+		t.Errorf("Set did not replace the existing entry: %+v", got)
+	}
+	if _, ok := loaded.Get("rust"); ok {
+		t.Error("Get(rust) found an entry that was never set")
+	}
+}
+
+func TestHashDir(t *testing.T) {
+	a := t.TempDir()
+	writeFiles(t, a, map[string]string{
+		"template.yaml":      "name: python\n",
+		"files/main.py":      "print('hi')\n",
+		".forge-source.yaml": "source: one\n",
+	})
+	b := t.TempDir()
+	writeFiles(t, b, map[string]string{
+		"template.yaml":      "name: python\n",
+		"files/main.py":      "print('hi')\n",
+		".forge-source.yaml": "source: two\n",
+	})
+
+	hashA, err := HashDir(a, ".forge-source.yaml")
+	if err != nil {
+		t.Fatalf("HashDir() error = %v", err)
+	}
+	if !strings.HasPrefix(hashA, "sha256:") {
+		t.Errorf("HashDir() = %q, want sha256: prefix", hashA)
+	}
+	hashB, _ := HashDir(b, ".forge-source.yaml")
+	if hashA != hashB {
+		t.Error("excluded files should not affect the hash")
+	}
+
+	writeFiles(t, b, map[string]string{"files/main.py": "print('bye')\n"})
+	if changed, _ := HashDir(b, ".forge-source.yaml"); changed == hashA {
+		t.Error("changing content should change the hash")
+	}
+
+	// Moving content between files changes the hash
+	c := t.TempDir()
+	writeFiles(t, c, map[string]string{"template.yaml": "name: python\nprint('hi')\n", "files/main.py": ""})
+	if moved, _ := HashDir(c); moved == hashA {
+		t.Error("different layouts should not collide")
+	}
+}
+
+func TestLocalSource(t *testing.T) {
+	repo := t.TempDir()
+	f, err := Load(filepath.Join(repo, FileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inside := filepath.Join(repo, "templates", "python")
+	if got := f.LocalSource(inside); got != "./templates/python" {
+		t.Errorf("LocalSource(inside) = %q, want ./templates/python", got)
+	}
+	if got := f.ResolveSource("./templates/python"); got != inside {
+		t.Errorf("ResolveSource() = %q, want %q", got, inside)
+	}
+
+	outside := filepath.Join(filepath.Dir(repo), "shared")
+	if got := f.LocalSource(outside); got != outside {
+		t.Errorf("LocalSource(outside) = %q, want the absolute path", got)
+	}
+	if got := f.ResolveSource(outside); got != outside {
+		t.Errorf("ResolveSource(absolute) = %q", got)
+	}
+}
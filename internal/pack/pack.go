@@ -0,0 +1,272 @@
+package pack
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"forge/internal/index"
+	"forge/internal/lock"
+	"forge/internal/remote"
+	"forge/internal/scaffold"
+	"forge/internal/template"
+)
+
+// ManifestSuffix ends the file name of the manifest written next to an archive
+const ManifestSuffix = ".manifest.json"
+
+// mtime is stamped on every archive entry so packing is reproducible
+var mtime = time.Unix(0, 0).UTC()
+
+// Manifest describes a packed template: its index metadata plus the
+// SHA-256 of every file and of the archive itself
+type Manifest struct {
+	index.Entry
+	Files         map[string]string `json:"files"`
+	Archive       string            `json:"archive"`
+	ArchiveSHA256 string            `json:"archive_sha256"`
+}
+
+// ArchiveName returns the file name of a packed template, name-version.tar.gz
+func ArchiveName(name, version string) string {
+	return name + "-" + version + ".tar.gz"
+}
+
+// ManifestPath returns the manifest path belonging to an archive
+func ManifestPath(archivePath string) string {
+	return strings.TrimSuffix(archivePath, ".tar.gz") + ManifestSuffix
+}
+
+// Pack writes templateDir as a deterministic tar.gz archive into outDir,
+// together with its manifest, and returns the manifest. The template must
+// declare a valid name and a version. Packing the same files twice gives
+// byte-identical archives.
+func Pack(templateDir, outDir string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(templateDir, "template.yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template.yaml: %w", err)
+	}
+	tmpl, err := template.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := scaffold.ValidateName(tmpl.Name); err != nil {
+		return nil, err
+	}
+	if tmpl.Version == "" {
+		return nil, errors.New("template.yaml needs a version to be packed")
+	}
+
+	files, err := collectFiles(templateDir)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{
+		Entry:   index.FromTemplate(tmpl.Name, tmpl),
+		Files:   map[string]string{},
+		Archive: ArchiveName(tmpl.Name, tmpl.Version),
+	}
+	m.URL = m.Archive
+
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", outDir, err)
+	}
+	archivePath := filepath.Join(outDir, m.Archive)
+	if err := writeArchive(archivePath, templateDir, files, m.Files); err != nil {
+		return nil, err
+	}
+
+	if m.ArchiveSHA256, err = remote.FileSHA256(archivePath); err != nil {
+		return nil, err
+	}
+	// The checksum must match what forge computes after installing the
+	// archive, so derive it from an extracted copy
+	if m.Checksum, err = ContentHash(archivePath, tmpl.Name); err != nil {
+		return nil, err
+	}
+
+	if err := m.Write(ManifestPath(archivePath)); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// collectFiles lists the regular files of a template as sorted slash paths,
+// leaving out VCS metadata and forge's own source metadata
+func collectFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		switch {
+		case info.IsDir():
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		case info.Mode()&os.ModeSymlink != 0:
+			return fmt.Errorf("cannot pack symlink %s", rel)
+		case !info.Mode().IsRegular() || rel == remote.SourceFile:
+			return nil
+		}
+		files = append(files, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template: %w", err)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// writeArchive writes files into a tar.gz with fixed timestamps, owners and
+// modes, recording each file's SHA-256 in sums
+func writeArchive(archivePath, dir string, files []string, sums map[string]string) error {
+	out, err := os.Create(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to create archive: %w", err)
+	}
+	defer out.Close()
+
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+	for _, rel := range files {
+		if err := addFile(tw, dir, rel, sums); err != nil {
+			return fmt.Errorf("failed to pack %s: %w", rel, err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	return out.Close()
+}
+
+func addFile(tw *tar.Writer, dir, rel string, sums map[string]string) error {
+	f, err := os.Open(filepath.Join(dir, filepath.FromSlash(rel)))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	mode := int64(0644)
+	if info.Mode().Perm()&0111 != 0 {
+		mode = 0755
+	}
+	hdr := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     rel,
+		Mode:     mode,
+		Size:     info.Size(),
+		ModTime:  mtime,
+		Format:   tar.FormatUSTAR,
+	}
+	if len(rel) > 100 {
+		// USTAR cannot hold long names; PAX can, still without timestamps
+		hdr.Format = tar.FormatPAX
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(tw, io.TeeReader(f, h)); err != nil {
+		return err
+	}
+	sums[rel] = hex.EncodeToString(h.Sum(nil))
+	return nil
+}
+
+// ContentHash returns the content hash (see lock.HashDir) of the template
+// in a packed archive, as it will be after installation
+func ContentHash(archivePath, name string) (string, error) {
+	tmp, err := os.MkdirTemp("", "forge-pack-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	dir := filepath.Join(tmp, name)
+	if err := remote.ExtractTemplate(archivePath, name, dir); err != nil {
+		return "", err
+	}
+	return lock.HashDir(dir, remote.SourceFile)
+}
+
+// Write saves the manifest as indented JSON
+func (m *Manifest) Write(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
+
+// ParseManifest decodes a manifest
+func ParseManifest(data []byte) (*Manifest, error) {
+	m := &Manifest{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("invalid pack manifest: %w", err)
+	}
+	if m.Name == "" || m.Version == "" || m.Archive == "" {
+		return nil, errors.New("invalid pack manifest: name, version and archive are required")
+	}
+	return m, nil
+}
+
+// ReadManifest reads the manifest at path
+func ReadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	return ParseManifest(data)
+}
+
+// Verify checks an archive against the manifest: its SHA-256, and that the
+// template it contains has the declared name and content hash
+func (m *Manifest) Verify(archivePath string) error {
+	sum, err := remote.FileSHA256(archivePath)
+	if err != nil {
+		return err
+	}
+	if sum != m.ArchiveSHA256 {
+		return fmt.Errorf("%w: archive %s has SHA-256 %s, manifest lists %s", remote.ErrChecksumMismatch, m.Archive, sum, m.ArchiveSHA256)
+	}
+
+	hash, err := ContentHash(archivePath, m.Name)
+	if err != nil {
+		return err
+	}
+	if hash != m.Checksum {
+		return fmt.Errorf("%w: template content hash %s, manifest lists %s", remote.ErrChecksumMismatch, hash, m.Checksum)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package pack
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"forge/internal/lock"
+	"forge/internal/remote"
+)
+
+const nodeYAML = `name: node
+description: Node.js package
+version: 1.0.0
+tags: [js]
+commands:
+  - cmd: ["npm", "init", "-y"]
+files:
+  copy: [".gitignore"]
+`
+
+func newTemplate(t *testing.T) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "node")
+	files := map[string]string{
+		"template.yaml":    nodeYAML,
+		".gitignore":       "node_modules/\n",
+		"scripts/setup.sh": "#!/bin/sh\n",
+		".git/HEAD":        "ref: refs/heads/main\n",
+		remote.SourceFile:  "source: ./node\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.Chmod(filepath.Join(dir, "scripts/setup.sh"), 0755)
+	return dir
+}
+
+func TestPack(t *testing.T) {
+	src := newTemplate(t)
+	out := t.TempDir()
+
+	m, err := Pack(src, out)
+	if err != nil {
+		t.Fatalf("Pack() error = %v", err)
+	}
+	if m.Name != "node" || m.Version != "1.0.0" || m.Archive != "node-1.0.0.tar.gz" {
+		t.Errorf("manifest = %+v", m)
+	}
+	if len(m.Files) != 3 {
+		t.Errorf("Files = %v, want template.yaml, .gitignore and scripts/setup.sh only", m.Files)
+	}
+	if m.Requires[0] != "npm" || m.Commands[0] != "npm init -y" {
+		t.Errorf("metadata = %+v", m.Entry)
+	}
+
+	archive := filepath.Join(out, m.Archive)
+	if err := m.Verify(archive); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+
+	read, err := ReadManifest(ManifestPath(archive))
+	if err != nil {
+		t.Fatalf("ReadManifest() error = %v", err)
+	}
+	if read.ArchiveSHA256 != m.ArchiveSHA256 || read.Checksum != m.Checksum {
+		t.Errorf("ReadManifest() = %+v, want %+v", read, m)
+	}
+
+	// The checksum matches the installed template
+	dest := filepath.Join(t.TempDir(), "node")
+	if err := remote.ExtractTemplate(archive, "node", dest); err != nil {
+		t.Fatal(err)
+	}
+	if hash, _ := lock.HashDir(dest, remote.SourceFile); hash != m.Checksum {
+		t.Errorf("installed hash %s, manifest %s", hash, m.Checksum)
+	}
+	if info, err := os.Stat(filepath.Join(dest, "scripts/setup.sh")); err != nil || info.Mode().Perm()&0100 == 0 {
+		t.Error("executable bit should survive packing")
+	}
+}
+
+func TestPackIsDeterministic(t *testing.T) {
+	src := newTemplate(t)
+	first, err := Pack(src, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Touching files must not change the archive
+	later := time.Now().Add(time.Hour)
+	os.Chtimes(filepath.Join(src, "template.yaml"), later, later)
+	outDir := t.TempDir()
+	second, err := Pack(src, outDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.ArchiveSHA256 != second.ArchiveSHA256 {
+		t.Error("packing the same files twice should give identical archives")
+	}
+
+	data, _ := os.ReadFile(filepath.Join(outDir, second.Archive))
+	if !bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		t.Error("archive should be gzip-compressed")
+	}
+}
+
+func TestPackNeedsVersion(t *testing.T) {
+	src := newTemplate(t)
+	os.WriteFile(filepath.Join(src, "template.yaml"), []byte("name: node\ncommands: []\nfiles:\n  copy: []\n"), 0644)
+	if _, err := Pack(src, t.TempDir()); err == nil {
+		t.Error("Pack() should require a version")
+	}
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	src := newTemplate(t)
+	out := t.TempDir()
+	m, err := Pack(src, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m.ArchiveSHA256 = "0000"
+	if err := m.Verify(filepath.Join(out, m.Archive)); !errors.Is(err, remote.ErrChecksumMismatch) {
+		t.Errorf("Verify() error = %v, want ErrChecksumMismatch", err)
+	}
+}
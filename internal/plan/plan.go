@@ -0,0 +1,173 @@
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"forge/internal/executor"
+	"forge/internal/fileops"
+	"forge/internal/template"
+)
+
+// Step kinds, in the order forge init executes them
+const (
+	KindCommand = "command"
+	KindCopy    = "copy"
+	KindAppend  = "append"
+)
+
+// Mode names for the two execution modes
+const (
+	ModeInit = "init"
+	ModeTest = "test"
+)
+
+// Plan is the ordered list of everything a template would do, computed
+// without spawning processes or touching the target directory
+type Plan struct {
+	Template    string `json:"template"`
+	TemplateDir string `json:"template_dir"`
+	Mode        string `json:"mode"`
+	WorkDir     string `json:"work_dir"`
+	Steps       []Step `json:"steps"`
+}
+
+// Step is a single planned action
+type Step struct {
+	Kind string `json:"kind"`
+
+	// Command steps
+	Args        []string `json:"args,omitempty"`
+	Dir         string   `json:"cwd,omitempty"`
+	Interactive bool     `json:"interactive,omitempty"`
+	Skipped     bool     `json:"skipped,omitempty"`
+	Note        string   `json:"note,omitempty"`
+
+	// File steps (paths relative to template and work directory)
+	Source string `json:"source,omitempty"`
+	Target string `json:"target,omitempty"`
+}
+
+// Build resolves the template into a plan. workDir is the directory the
+// commands would run in; testMode selects forge test semantics, where
+// interactive commands use test_cmd or are skipped.
+func Build(tmpl *template.Template, templatePath, workDir string, testMode bool) (*Plan, error) {
+	p := &Plan{
+		Template:    tmpl.Name,
+		TemplateDir: templatePath,
+		Mode:        ModeInit,
+		WorkDir:     workDir,
+	}
+	if testMode {
+		p.Mode = ModeTest
+	}
+
+	for _, cmd := range tmpl.Commands {
+		args, ok := executor.Resolve(cmd, testMode)
+		step := Step{
+			Kind:        KindCommand,
+			Args:        args,
+			Dir:         workDir,
+			Interactive: cmd.Interactive,
+		}
+		switch {
+		case !ok:
+			step.Args = cmd.Cmd
+			step.Skipped = true
+			step.Note = "interactive command without test_cmd is skipped"
+		case testMode && cmd.Interactive:
+			step.Note = "test_cmd replaces interactive command"
+		}
+		p.Steps = append(p.Steps, step)
+	}
+
+	fops := fileops.New(workDir, templatePath)
+	copies, err := fops.CopyTargets(tmpl.Files.Copy)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range copies {
+		p.Steps = append(p.Steps, Step{Kind: KindCopy, Source: c.Source, Target: c.Target})
+	}
+
+	for _, patch := range tmpl.Files.Append {
+		p.Steps = append(p.Steps, Step{Kind: KindAppend, Source: patch.Source, Target: patch.Target})
+	}
+
+	return p, nil
+}
+
+// WriteJSON writes the plan as indented JSON for tooling
+func (p *Plan) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(p)
+}
+
+// WriteText writes a human-readable rendering of the plan
+func (p *Plan) WriteText(w io.Writer) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Plan for template: %s (forge %s)\n", p.Template, p.Mode)
+	fmt.Fprintf(&b, "Template directory: %s\n", p.TemplateDir)
+	fmt.Fprintf(&b, "Working directory:  %s\n", p.WorkDir)
+
+	if len(p.Steps) == 0 {
+		b.WriteString("\nNothing to do.\n")
+	} else {
+		b.WriteString("\n")
+	}
+	p.writeSteps(&b)
+	b.WriteString("\nDry run: nothing was executed.\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteSteps writes only the numbered steps: every command with its full
+// argument list, and every file the template creates or appends to
+func (p *Plan) WriteSteps(w io.Writer) error {
+	var b strings.Builder
+	p.writeSteps(&b)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (p *Plan) writeSteps(b *strings.Builder) {
+	for i, step := range p.Steps {
+		switch step.Kind {
+		case KindCommand:
+			verb := "run"
+			if step.Skipped {
+				verb = "skip"
+			}
+			fmt.Fprintf(b, "  %d. %-6s %s\n", i+1, verb, formatArgs(step.Args))
+			fmt.Fprintf(b, "     cwd: %s\n", step.Dir)
+			if step.Note != "" {
+				fmt.Fprintf(b, "     note: %s\n", step.Note)
+			}
+		case KindCopy:
+			fmt.Fprintf(b, "  %d. %-6s %s -> %s\n", i+1, "create", filepath.ToSlash(step.Source), filepath.ToSlash(step.Target))
+		case KindAppend:
+			fmt.Fprintf(b, "  %d. %-6s %s -> %s\n", i+1, "append", filepath.ToSlash(step.Source), filepath.ToSlash(step.Target))
+		}
+	}
+}
+
+// formatArgs joins an argument list, quoting arguments that are empty or
+// contain spaces or quotes so each argument's boundaries stay visible
+func formatArgs(args []string) string {
+	parts := make([]string, len(args))
+	for i, a := range args {
+		if a == "" || strings.ContainsAny(a, " \t\n\"'") {
+			a = strconv.Quote(a)
+		}
+		parts[i] = a
+	}
+	return strings.Join(parts, " ")
+}
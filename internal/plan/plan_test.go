@@ -0,0 +1,153 @@
+package plan
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"forge/internal/template"
+)
+
+func newTemplateDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "files", "src"), 0755); err != nil {
+		t.Fatalf("MkdirAll error = %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "files", "README.md"), []byte("readme"), 0644); err != nil {
+		t.Fatalf("WriteFile error = %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "files", "src", "main.py"), []byte("print()"), 0644); err != nil {
+		t.Fatalf("WriteFile error = %v", err)
+	}
+	return dir
+}
+
+func testTemplate() *template.Template {
+	return &template.Template{
+		Name: "python",
+		Commands: []template.Command{
+			{Cmd: []string{"git", "init"}},
+			{Cmd: []string{"uv", "init"}, Interactive: true, TestCmd: []string{"uv", "init", "--bare"}},
+			{Cmd: []string{"npm", "init"}, Interactive: true},
+		},
+		Files: template.FileOps{
+			Copy:   []string{"files"},
+			Append: []template.AppendPatch{{Target: ".gitignore", Source: "patches/gitignore.append"}},
+		},
+	}
+}
+
+func TestBuildInit(t *testing.T) {
+	dir := newTemplateDir(t)
+
+	p, err := Build(testTemplate(), dir, "/work", false)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if p.Mode != ModeInit {
+		t.Errorf("Mode = %q, want %q", p.Mode, ModeInit)
+	}
+
+	wantKinds := []string{KindCommand, KindCommand, KindCommand, KindCopy, KindCopy, KindAppend}
+	if len(p.Steps) != len(wantKinds) {
+		t.Fatalf("got %d steps, want %d", len(p.Steps), len(wantKinds))
+	}
+	for i, kind := range wantKinds {
+		if p.Steps[i].Kind != kind {
+			t.Errorf("step %d kind = %q, want %q", i, p.Steps[i].Kind, kind)
+		}
+	}
+
+	// forge init runs interactive commands as written
+	if got := strings.Join(p.Steps[1].Args, " "); got != "uv init" {
+		t.Errorf("step 1 args = %q, want %q", got, "uv init")
+	}
+	if p.Steps[0].Dir != "/work" {
+		t.Errorf("step 0 cwd = %q, want %q", p.Steps[0].Dir, "/work")
+	}
+
+	targets := map[string]bool{}
+	for _, step := range p.Steps {
+		if step.Kind == KindCopy {
+			targets[filepath.ToSlash(step.Target)] = true
+		}
+	}
+	if !targets["README.md"] || !targets["src/main.py"] {
+		t.Errorf("copy targets = %v, want README.md and src/main.py", targets)
+	}
+}
+
+func TestBuildTestMode(t *testing.T) {
+	dir := newTemplateDir(t)
+
+	p, err := Build(testTemplate(), dir, "/work", true)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if got := strings.Join(p.Steps[1].Args, " "); got != "uv init --bare" {
+		t.Errorf("step 1 args = %q, want test_cmd %q", got, "uv init --bare")
+	}
+	if !p.Steps[2].Skipped {
+		t.Error("interactive command without test_cmd should be skipped")
+	}
+}
+
+func TestBuildMissingCopySource(t *testing.T) {
+	tmpl := &template.Template{Name: "bad", Files: template.FileOps{Copy: []string{"missing/"}}}
+	if _, err := Build(tmpl, t.TempDir(), "/work", false); err == nil {
+		t.Fatal("Build() should fail when a copy source does not exist")
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	p, err := Build(testTemplate(), newTemplateDir(t), "/work", false)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := p.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+
+	var decoded Plan
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("plan JSON does not round-trip: %v", err)
+	}
+	if len(decoded.Steps) != len(p.Steps) {
+		t.Errorf("decoded %d steps, want %d", len(decoded.Steps), len(p.Steps))
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	p, err := Build(testTemplate(), newTemplateDir(t), "/work", true)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := p.WriteText(&buf); err != nil {
+		t.Fatalf("WriteText() error = %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{"run    git init", "skip   npm init", "append patches/gitignore.append -> .gitignore", "nothing was executed"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("WriteText() output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestFormatArgsQuotesBoundaries(t *testing.T) {
+	got := formatArgs([]string{"sh", "-c", "curl x | sh", ""})
+	want := `sh -c "curl x | sh" ""`
+	if got != want {
+		t.Errorf("formatArgs() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,354 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"forge/internal/config"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Rule names reported when a command is denied
+const (
+	RuleDeny    = "deny"
+	RuleAllow   = "allow"
+	RuleShell   = "allow_shells"
+	RuleNetwork = "network_tools"
+)
+
+// DefaultNetworkTools are blocked in forge test unless the policy lists its
+// own network_tools or sets allow_network_in_test
+var DefaultNetworkTools = []string{
+	"curl", "wget", "ssh", "scp", "sftp", "ftp", "rsync", "telnet",
+	"nc", "ncat", "netcat", "bitsadmin",
+	"git clone", "git fetch", "git pull", "git push", "git ls-remote",
+}
+
+// shells are interpreters that can run an inline script
+var shells = map[string]bool{
+	"sh": true, "bash": true, "dash": true, "zsh": true, "ksh": true, "fish": true,
+	"cmd": true, "powershell": true, "pwsh": true,
+}
+
+// wrappers run the command given in their arguments, so they could run a
+// denied command or a shell; they are refused like shells running scripts
+var wrappers = map[string]bool{
+	"env": true, "busybox": true, "toybox": true, "xargs": true, "nohup": true,
+	"nice": true, "ionice": true, "timeout": true, "time": true, "stdbuf": true,
+	"setsid": true, "sudo": true, "doas": true, "su": true, "runas": true,
+	"chroot": true, "unshare": true, "nsenter": true, "flock": true,
+	"strace": true, "watch": true, "script": true, "command": true,
+	"exec": true, "wsl": true, "start": true,
+}
+
+// DeniedError reports a command refused by the policy
+type DeniedError struct {
+	Rule    string   // rule that denied the command, e.g. "allow"
+	Source  string   // file the policy was read from
+	Command []string // command as the template wrote it
+	Reason  string
+}
+
+func (e *DeniedError) Error() string {
+	return fmt.Sprintf("command %q denied by policy rule %q in %s: %s",
+		strings.Join(e.Command, " "), e.Rule, e.Source, e.Reason)
+}
+
+// Policy decides which template commands may run
+type Policy struct {
+	rules  config.Policy
+	source string
+}
+
+// New returns a policy enforcing rules; source names where they came from
+// and is included in denial messages
+func New(rules config.Policy, source string) *Policy {
+	return &Policy{rules: rules, source: source}
+}
+
+// SystemPath returns the system-wide policy file: %ProgramData%\forge\policy.yaml
+// on Windows and /etc/forge/policy.yaml elsewhere
+func SystemPath() string {
+	if runtime.GOOS == "windows" {
+		dir := os.Getenv("ProgramData")
+		if dir == "" {
+			dir = `C:\ProgramData`
+		}
+		return filepath.Join(dir, "forge", "policy.yaml")
+	}
+	return "/etc/forge/policy.yaml"
+}
+
+// LoadFile reads a policy file. It returns nil without error when the file
+// does not exist.
+func LoadFile(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy: %w", err)
+	}
+	var rules config.Policy
+	if err := yaml.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse policy %s: %w", path, err)
+	}
+	return New(rules, path), nil
+}
+
+// Load returns the policy in effect: the system-wide policy file when it
+// exists, so users cannot loosen it, otherwise the policy section of the
+// user config. It returns nil when neither sets a policy.
+func Load(cfg *config.Config, configPath string) (*Policy, error) {
+	p, err := LoadFile(SystemPath())
+	if err != nil || p != nil {
+		return p, err
+	}
+	if cfg.Policy != nil {
+		return New(*cfg.Policy, configPath), nil
+	}
+	return nil, nil
+}
+
+// Check returns a *DeniedError if the policy forbids running args from dir,
+// the directory the command will run in. In test mode network-reaching
+// tools are refused as well.
+func (p *Policy) Check(args []string, dir string, testMode bool) error {
+	if len(args) == 0 {
+		return nil
+	}
+	exe := executableName(args[0])
+	deny := func(rule, reason string) error {
+		return &DeniedError{Rule: rule, Source: p.source, Command: args, Reason: reason}
+	}
+
+	// A name found in an absolute PATH entry runs whatever PATH resolves it
+	// to; a path, or a name found through a relative PATH entry, runs a file
+	// in or near the project that the allow list may know nothing about
+	path, resolved := "", ""
+	if found, byPath, err := lookPath(args[0], dir); byPath {
+		path = found
+	} else if err == nil {
+		resolved = found
+		exe = executableName(found)
+	}
+
+	if contains(p.rules.Deny, exe) {
+		return deny(RuleDeny, fmt.Sprintf("%s is on the deny list", exe))
+	}
+	if len(p.rules.Allow) > 0 {
+		if path != "" && !containsPath(p.rules.Allow, path) {
+			return deny(RuleAllow, fmt.Sprintf("%s is run by path and that path is not on the allow list", path))
+		}
+		if path == "" && !contains(p.rules.Allow, exe) && !containsPath(p.rules.Allow, resolved) {
+			return deny(RuleAllow, fmt.Sprintf("%s is not on the allow list", exe))
+		}
+	}
+	if wrappers[exe] && !contains(p.rules.AllowShells, exe) {
+		return deny(RuleShell, fmt.Sprintf("%s runs other commands", exe))
+	}
+	if shells[exe] && runsInlineScript(exe, args[1:]) && !contains(p.rules.AllowShells, exe) {
+		return deny(RuleShell, fmt.Sprintf("%s may not run inline scripts", exe))
+	}
+	if testMode && !p.rules.AllowNetworkInTest {
+		tools := p.rules.NetworkTools
+		if tools == nil {
+			tools = DefaultNetworkTools
+		}
+		if tool, ok := matchTool(tools, exe, args[1:]); ok {
+			return deny(RuleNetwork, fmt.Sprintf("%s reaches the network, which forge test does not allow", tool))
+		}
+	}
+	return nil
+}
+
+// LookPath returns the file a command runs when started in dir, the same
+// file Check judged. A relative path is taken relative to dir, and a bare
+// name is searched for in PATH, with relative PATH entries also taken
+// relative to dir; the current directory is not searched otherwise.
+func LookPath(name, dir string) (string, error) {
+	path, _, err := lookPath(name, dir)
+	return path, err
+}
+
+// lookPath implements LookPath. byPath reports that name is a path or was
+// found through a relative PATH entry; for a path that does not exist the
+// joined path is still returned.
+func lookPath(name, dir string) (path string, byPath bool, err error) {
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return "", false, err
+	}
+	if hasSeparator(name) {
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(dir, name)
+		}
+		name = filepath.Clean(name)
+		if found, err := exec.LookPath(name); err == nil {
+			return found, true, nil
+		}
+		return name, true, nil
+	}
+
+	for _, entry := range filepath.SplitList(os.Getenv("PATH")) {
+		if entry == "" {
+			entry = "."
+		}
+		relative := !filepath.IsAbs(entry)
+		if relative {
+			entry = filepath.Join(dir, entry)
+		}
+		// A path with a separator is checked as is, with PATHEXT applied
+		// on Windows, rather than searched for
+		if found, err := exec.LookPath(filepath.Join(entry, name)); err == nil {
+			return found, relative, nil
+		}
+	}
+	return "", false, fmt.Errorf("%s: %w", name, exec.ErrNotFound)
+}
+
+func hasSeparator(cmd string) bool {
+	return strings.ContainsAny(cmd, `/\`)
+}
+
+// executableName normalises a command to a comparable name: the base name,
+// lower-cased, without a Windows executable extension
+func executableName(cmd string) string {
+	if i := strings.LastIndexAny(cmd, `/\`); i >= 0 {
+		cmd = cmd[i+1:]
+	}
+	return trimExt(strings.ToLower(cmd))
+}
+
+func trimExt(cmd string) string {
+	for _, ext := range []string{".exe", ".cmd", ".bat", ".com"} {
+		if strings.HasSuffix(cmd, ext) {
+			return strings.TrimSuffix(cmd, ext)
+		}
+	}
+	return cmd
+}
+
+// containsPath reports whether list names the file at path by its full
+// path; bare names in list never match
+func containsPath(list []string, path string) bool {
+	if path == "" {
+		return false
+	}
+	for _, item := range list {
+		if hasSeparator(item) && samePath(item, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// samePath compares command paths as written, ignoring case and executable
+// extensions on Windows
+func samePath(a, b string) bool {
+	a, b = filepath.Clean(a), filepath.Clean(b)
+	if runtime.GOOS == "windows" {
+		return trimExt(strings.ToLower(a)) == trimExt(strings.ToLower(b))
+	}
+	return a == b
+}
+
+func contains(list []string, exe string) bool {
+	for _, item := range list {
+		if executableName(item) == exe {
+			return true
+		}
+	}
+	return false
+}
+
+// runsInlineScript reports whether a shell is given a script on its
+// command line rather than a script file
+func runsInlineScript(exe string, args []string) bool {
+	switch exe {
+	case "cmd":
+		for _, a := range args {
+			if l := strings.ToLower(a); l == "/c" || l == "/k" {
+				return true
+			}
+		}
+		return false
+
+	case "powershell", "pwsh":
+		// Parameter names may be abbreviated to any unique prefix
+		for _, a := range args {
+			if !strings.HasPrefix(a, "-") && !strings.HasPrefix(a, "/") {
+				continue
+			}
+			name := strings.ToLower(strings.TrimLeft(a, "-/"))
+			if name == "" {
+				continue
+			}
+			if name == "file" || name == "f" {
+				return false
+			}
+			if strings.HasPrefix("command", name) || strings.HasPrefix("encodedcommand", name) || name == "ec" {
+				return true
+			}
+		}
+		// Windows PowerShell treats bare arguments as a command
+		return exe == "powershell" && hasPositional(args)
+
+	default:
+		for i := 0; i < len(args); i++ {
+			a := args[i]
+			switch {
+			case a == "-o" || a == "+o":
+				i++ // the option name
+			case a == "--command" || strings.HasPrefix(a, "--command="):
+				return true
+			case strings.HasPrefix(a, "--"):
+			case strings.HasPrefix(a, "-") && a != "-":
+				if strings.Contains(a, "c") {
+					return true
+				}
+			default:
+				// Options end at the script file
+				return false
+			}
+		}
+		return false
+	}
+}
+
+func hasPositional(args []string) bool {
+	for _, a := range args {
+		if !strings.HasPrefix(a, "-") && !strings.HasPrefix(a, "/") {
+			return true
+		}
+	}
+	return false
+}
+
+// matchTool finds the entry of tools matching exe and, for entries with a
+// subcommand, its first non-option argument
+func matchTool(tools []string, exe string, args []string) (string, bool) {
+	sub := ""
+	for _, a := range args {
+		if !strings.HasPrefix(a, "-") {
+			sub = strings.ToLower(a)
+			break
+		}
+	}
+	for _, tool := range tools {
+		fields := strings.Fields(tool)
+		if len(fields) == 0 || executableName(fields[0]) != exe {
+			continue
+		}
+		if len(fields) == 1 || strings.ToLower(fields[1]) == sub {
+			return tool, true
+		}
+	}
+	return "", false
+}
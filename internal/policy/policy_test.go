@@ -0,0 +1,220 @@
+package policy
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"forge/internal/config"
+)
+
+func deniedRule(t *testing.T, p *Policy, args []string, testMode bool) string {
+	t.Helper()
+	err := p.Check(args, "", testMode)
+	if err == nil {
+		return ""
+	}
+	var denied *DeniedError
+	if !errors.As(err, &denied) {
+		t.Fatalf("Check(%q) error = %v, want *DeniedError", args, err)
+	}
+	return denied.Rule
+}
+
+func TestCheckRules(t *testing.T) {
+	p := New(config.Policy{
+		Allow:       []string{"git", "uv", "npm", "bash", "cmd", "powershell", "curl"},
+		Deny:        []string{"npm"},
+		AllowShells: []string{"bash"},
+	}, "policy.yaml")
+
+	tests := []struct {
+		args     []string
+		testMode bool
+		want     string
+	}{
+		{[]string{"git", "init"}, false, ""},
+		{[]string{`C:\Tools\UV.EXE`, "init"}, false, RuleAllow},
+		{[]string{"python", "-m", "venv", ".venv"}, false, RuleAllow},
+		{[]string{"npm", "init", "-y"}, false, RuleDeny},
+		{[]string{"bash", "-c", "echo hi"}, false, ""},
+		{[]string{"cmd", "/C", "del x"}, false, RuleShell},
+		{[]string{"cmd", "/?"}, false, ""},
+		{[]string{"powershell", "-NoProfile", "-Command", "Remove-Item x"}, false, RuleShell},
+		{[]string{"powershell", "-enc", "ZQBjAGgAbwA="}, false, RuleShell},
+		{[]string{"powershell", "Get-Process"}, false, RuleShell},
+		{[]string{"powershell", "-ExecutionPolicy", "Bypass", "-File", "setup.ps1"}, false, ""},
+		{[]string{"curl", "-O", "https://example.com"}, false, ""},
+		{[]string{"curl", "-O", "https://example.com"}, true, RuleNetwork},
+		{[]string{"git", "init"}, true, ""},
+		{[]string{"git", "clone", "https://example.com/r.git"}, true, RuleNetwork},
+	}
+	for _, tt := range tests {
+		if got := deniedRule(t, p, tt.args, tt.testMode); got != tt.want {
+			t.Errorf("Check(%q, test=%v) rule = %q, want %q", tt.args, tt.testMode, got, tt.want)
+		}
+	}
+}
+
+func TestPosixShellInlineScripts(t *testing.T) {
+	p := New(config.Policy{}, "config.yaml")
+	tests := map[string]bool{
+		"sh -c true":               true,
+		"bash -euxc true":          true,
+		"bash --norc -c true":      true,
+		"sh -o pipefail -c true":   true,
+		"fish --command=true":      true,
+		"bash setup.sh -c":         false,
+		"bash -e setup.sh":         false,
+		"/usr/bin/zsh -o err x.sh": false,
+	}
+	for cmd, want := range tests {
+		denied := deniedRule(t, p, strings.Fields(cmd), false) == RuleShell
+		if denied != want {
+			t.Errorf("%q denied = %v, want %v", cmd, denied, want)
+		}
+	}
+}
+
+func TestPathsMustBeAllowedByFullPath(t *testing.T) {
+	p := New(config.Policy{Allow: []string{"git", "/opt/tools/uv"}}, "policy.yaml")
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"git", "clone", "https://example.com/x.git", "x"}, ""},
+		{[]string{"./x/git", "status"}, RuleAllow},
+		{[]string{"/opt/tools/uv", "init"}, ""},
+		{[]string{"/opt/tools/../tools/uv", "init"}, ""},
+		{[]string{"/tmp/uv", "init"}, RuleAllow},
+	}
+	for _, tt := range tests {
+		if got := deniedRule(t, p, tt.args, false); got != tt.want {
+			t.Errorf("Check(%q) rule = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestCommandsResolvedInWorkDir(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs executables without an extension")
+	}
+	// forge runs from one directory, the template's commands in another,
+	// and each has its own bin/git and x/git
+	forgeDir, workDir := t.TempDir(), t.TempDir()
+	for _, dir := range []string{forgeDir, workDir} {
+		for _, rel := range []string{"bin", "x"} {
+			os.MkdirAll(filepath.Join(dir, rel), 0755)
+			if err := os.WriteFile(filepath.Join(dir, rel, "git"), []byte("#!/bin/sh\n"), 0755); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	t.Chdir(forgeDir)
+	t.Setenv("PATH", "bin")
+
+	p := New(config.Policy{Allow: []string{
+		"git",
+		filepath.Join(forgeDir, "bin", "git"),
+		filepath.Join(forgeDir, "x", "git"),
+	}}, "policy.yaml")
+	tests := []struct {
+		args []string
+		dir  string
+		want string
+	}{
+		{[]string{"git", "status"}, forgeDir, ""},
+		{[]string{"git", "status"}, workDir, RuleAllow},
+		{[]string{"./x/git", "status"}, forgeDir, ""},
+		{[]string{"./x/git", "status"}, workDir, RuleAllow},
+	}
+	for _, tt := range tests {
+		err := p.Check(tt.args, tt.dir, false)
+		var denied *DeniedError
+		got := ""
+		if errors.As(err, &denied) {
+			got = denied.Rule
+		}
+		if got != tt.want {
+			t.Errorf("Check(%q) in %s rule = %q, want %q (%v)", tt.args, tt.dir, got, tt.want, err)
+		}
+	}
+
+	if got, err := LookPath("git", workDir); err != nil || got != filepath.Join(workDir, "bin", "git") {
+		t.Errorf("LookPath(git) = %s, %v, want the work directory's bin/git", got, err)
+	}
+}
+
+func TestWrappersAreShells(t *testing.T) {
+	p := New(config.Policy{Deny: []string{"curl"}}, "policy.yaml")
+	for _, cmd := range []string{
+		"env curl https://example.com",
+		"env sh -c true",
+		"busybox sh -c true",
+		"/bin/busybox wget https://example.com",
+		"xargs curl",
+		"nohup git status",
+	} {
+		if got := deniedRule(t, p, strings.Fields(cmd), false); got != RuleShell {
+			t.Errorf("Check(%q) rule = %q, want %q", cmd, got, RuleShell)
+		}
+	}
+
+	allowed := New(config.Policy{AllowShells: []string{"env"}}, "policy.yaml")
+	if got := deniedRule(t, allowed, []string{"env", "FOO=1", "git", "status"}, false); got != "" {
+		t.Errorf("env listed in allow_shells: rule = %q, want none", got)
+	}
+}
+
+func TestNetworkToolsInTest(t *testing.T) {
+	custom := New(config.Policy{NetworkTools: []string{"npm install"}}, "config.yaml")
+	if got := deniedRule(t, custom, []string{"npm", "install"}, true); got != RuleNetwork {
+		t.Errorf("custom network tool rule = %q, want %q", got, RuleNetwork)
+	}
+	if got := deniedRule(t, custom, []string{"curl", "x"}, true); got != "" {
+		t.Errorf("network_tools should replace the defaults, got rule %q", got)
+	}
+
+	open := New(config.Policy{AllowNetworkInTest: true}, "config.yaml")
+	if got := deniedRule(t, open, []string{"curl", "x"}, true); got != "" {
+		t.Errorf("allow_network_in_test rule = %q, want none", got)
+	}
+}
+
+func TestDeniedErrorNamesRule(t *testing.T) {
+	p := New(config.Policy{Allow: []string{"git"}}, "/etc/forge/policy.yaml")
+	err := p.Check([]string{"curl", "x"}, "", false)
+	if err == nil {
+		t.Fatal("Check() should deny curl")
+	}
+	for _, want := range []string{`"allow"`, "/etc/forge/policy.yaml", "curl"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %s", err, want)
+		}
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	p, err := LoadFile(filepath.Join(t.TempDir(), "missing.yaml"))
+	if err != nil || p != nil {
+		t.Fatalf("LoadFile(missing) = %v, %v, want nil, nil", p, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	os.WriteFile(path, []byte("allow: [git]\nallow_network_in_test: true\n"), 0644)
+	p, err = LoadFile(path)
+	if err != nil || p == nil {
+		t.Fatalf("LoadFile() = %v, %v", p, err)
+	}
+	if got := deniedRule(t, p, []string{"uv", "init"}, false); got != RuleAllow {
+		t.Errorf("loaded policy rule = %q, want %q", got, RuleAllow)
+	}
+
+	os.WriteFile(path, []byte("allow: git: x"), 0644)
+	if _, err := LoadFile(path); err == nil {
+		t.Error("LoadFile() of invalid YAML should fail")
+	}
+}
@@ -0,0 +1,111 @@
+package progress
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Reporter renders download progress: a redrawn bar on terminals and a log
+// line every few seconds otherwise, so CI logs stay readable
+type Reporter struct {
+	w        io.Writer
+	tty      bool
+	width    int
+	interval time.Duration
+	now      func() time.Time
+
+	label string
+	last  time.Time
+}
+
+// New returns a reporter writing to f, drawing a bar when f is a terminal
+func New(f *os.File) *Reporter {
+	return NewWriter(f, IsTerminal(f))
+}
+
+// NewWriter returns a reporter writing to w. When tty is false progress is
+// logged as plain lines.
+func NewWriter(w io.Writer, tty bool) *Reporter {
+	return &Reporter{w: w, tty: tty, width: 30, interval: 2 * time.Second, now: time.Now}
+}
+
+// IsTerminal reports whether f is an interactive terminal
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Update records progress for label; total is -1 when unknown. It matches
+// remote.ProgressFunc.
+func (r *Reporter) Update(label string, done, total int64) {
+	finished := total >= 0 && done >= total
+	now := r.now()
+
+	if label != r.label {
+		r.label = label
+		r.last = time.Time{}
+	}
+
+	if r.tty {
+		// Redraw at most ten times a second
+		if !finished && now.Sub(r.last) < 100*time.Millisecond {
+			return
+		}
+		r.last = now
+		fmt.Fprintf(r.w, "\r%s %s", label, r.bar(done, total))
+		if finished {
+			fmt.Fprintln(r.w)
+		}
+		return
+	}
+
+	if !finished && !r.last.IsZero() && now.Sub(r.last) < r.interval {
+		return
+	}
+	if r.last.IsZero() && !finished {
+		// Start the clock without logging zero progress
+		r.last = now
+		return
+	}
+	r.last = now
+	if finished {
+		fmt.Fprintf(r.w, "Downloaded %s (%s)\n", label, FormatBytes(done))
+		return
+	}
+	fmt.Fprintf(r.w, "Downloading %s: %s\n", label, r.amount(done, total))
+}
+
+func (r *Reporter) bar(done, total int64) string {
+	if total <= 0 {
+		return FormatBytes(done)
+	}
+	filled := int(int64(r.width) * done / total)
+	if filled > r.width {
+		filled = r.width
+	}
+	return fmt.Sprintf("[%s%s] %s", strings.Repeat("=", filled), strings.Repeat(" ", r.width-filled), r.amount(done, total))
+}
+
+func (r *Reporter) amount(done, total int64) string {
+	if total <= 0 {
+		return FormatBytes(done)
+	}
+	return fmt.Sprintf("%3d%% %s / %s", done*100/total, FormatBytes(done), FormatBytes(total))
+}
+
+// FormatBytes renders a byte count for display, e.g. 1.5 MiB
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,75 @@
+package progress
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func fakeClock(r *Reporter) *time.Time {
+	t := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	r.now = func() time.Time { return t }
+	return &t
+}
+
+func TestReporterLogLines(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewWriter(&buf, false)
+	clock := fakeClock(r)
+
+	r.Update("templates.zip", 0, 4096)
+	r.Update("templates.zip", 1024, 4096)
+	*clock = clock.Add(3 * time.Second)
+	r.Update("templates.zip", 2048, 4096)
+	r.Update("templates.zip", 3072, 4096)
+	r.Update("templates.zip", 4096, 4096)
+
+	want := "Downloading templates.zip:  50% 2.0 KiB / 4.0 KiB\nDownloaded templates.zip (4.0 KiB)\n"
+	if buf.String() != want {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestReporterBar(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewWriter(&buf, true)
+	r.width = 10
+	clock := fakeClock(r)
+
+	r.Update("forge", 5, 10)
+	*clock = clock.Add(10 * time.Millisecond)
+	r.Update("forge", 6, 10) // throttled
+	r.Update("forge", 10, 10)
+
+	out := buf.String()
+	if !strings.Contains(out, "\rforge [=====     ]  50%") {
+		t.Errorf("missing half bar in %q", out)
+	}
+	if strings.Contains(out, " 60%") {
+		t.Errorf("redraw should be throttled: %q", out)
+	}
+	if !strings.HasSuffix(out, "\rforge [==========] 100% 10 B / 10 B\n") {
+		t.Errorf("missing final bar in %q", out)
+	}
+}
+
+func TestReporterUnknownSize(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewWriter(&buf, true)
+	fakeClock(r)
+
+	r.Update("index.json", 2048, -1)
+	if !strings.Contains(buf.String(), "index.json 2.0 KiB") {
+		t.Errorf("output = %q", buf.String())
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[int64]string{512: "512 B", 1536: "1.5 KiB", 5 << 20: "5.0 MiB"}
+	for n, want := range tests {
+		if got := FormatBytes(n); got != want {
+			t.Errorf("FormatBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
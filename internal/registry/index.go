@@ -0,0 +1,230 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"forge/internal/config"
+	"forge/internal/index"
+	"forge/internal/lock"
+	"forge/internal/remote"
+	"forge/internal/scaffold"
+)
+
+// ErrChecksumMismatch is returned when a template from an index registry
+// does not match the checksum the index lists for it
+var ErrChecksumMismatch = errors.New("template does not match the index checksum")
+
+// FetchIndex returns the index of a registry. Index registries serve it
+// directly; for zip registries it is built from the downloaded archive.
+// Archive URLs in the returned entries are absolute.
+func FetchIndex(reg config.Registry, opts Options) (*index.Index, error) {
+	switch reg.Type {
+	case config.RegistryTypeIndex:
+		path, cleanup, err := download(reg, opts)
+		if err != nil {
+			return nil, err
+		}
+		defer cleanup()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		idx, err := index.Parse(data)
+		if err != nil {
+			return nil, err
+		}
+		for i, e := range idx.Templates {
+			if idx.Templates[i].URL, err = resolveURL(reg.URL, e.URL); err != nil {
+				return nil, fmt.Errorf("template '%s': %w", e.Name, err)
+			}
+		}
+		return idx, nil
+
+	case config.RegistryTypeZip:
+		path, cleanup, err := download(reg, opts)
+		if err != nil {
+			return nil, err
+		}
+		defer cleanup()
+
+		tmp, err := os.MkdirTemp("", "forge-index-*")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create temp directory: %w", err)
+		}
+		defer os.RemoveAll(tmp)
+		if _, err := remote.InstallAllTemplates(path, tmp); err != nil {
+			return nil, err
+		}
+		return index.BuildDir(tmp)
+	}
+	return nil, fmt.Errorf("unsupported registry type %q", reg.Type)
+}
+
+// Result is a search match
+type Result struct {
+	Registry string
+	index.Entry
+	score int
+}
+
+// Search matches query against the indexes of regs, best matches first.
+// Registries whose index cannot be fetched are reported in the returned
+// error map and otherwise skipped.
+func Search(regs []config.Registry, query string, opts Options) ([]Result, map[string]error) {
+	var results []Result
+	failed := map[string]error{}
+	for _, reg := range regs {
+		idx, err := FetchIndex(reg, opts)
+		if err != nil {
+			failed[reg.Name] = err
+			continue
+		}
+		for _, e := range idx.Templates {
+			if score := e.Score(query); score > 0 {
+				results = append(results, Result{Registry: reg.Name, Entry: e, score: score})
+			}
+		}
+	}
+
+	// Registries are in priority order, so a stable sort keeps it on ties
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].score != results[j].score {
+			return results[i].score > results[j].score
+		}
+		return results[i].Name < results[j].Name
+	})
+	return results, failed
+}
+
+// Info returns the index entry for ref from the first candidate registry
+// that provides it. For index registries the entry describes the template
+// in the verified archive, not what index.json claims about it.
+func Info(cfg *config.Config, ref Ref, opts Options) (config.Registry, index.Entry, error) {
+	regs, err := Candidates(cfg, ref)
+	if err != nil {
+		return config.Registry{}, index.Entry{}, err
+	}
+
+	var failures []error
+	for _, reg := range regs {
+		idx, err := FetchIndex(reg, opts)
+		if err != nil {
+			failures = append(failures, fmt.Errorf("%s: %w", reg.Name, err))
+			continue
+		}
+		e, ok := idx.Find(ref.Name)
+		if !ok {
+			continue
+		}
+		if reg.Type == config.RegistryTypeIndex {
+			if e, err = inspectIndexed(reg, e, opts); err != nil {
+				return config.Registry{}, index.Entry{}, fmt.Errorf("%s: %w", reg.Name, err)
+			}
+		}
+		return reg, e, nil
+	}
+
+	err = fmt.Errorf("template '%s' not found", ref)
+	if len(failures) > 0 {
+		err = fmt.Errorf("%w (%w)", err, errors.Join(failures...))
+	}
+	return config.Registry{}, index.Entry{}, err
+}
+
+// inspectIndexed builds e's entry from the template in its archive, once
+// the archive has passed the same checks as a pull
+func inspectIndexed(reg config.Registry, e index.Entry, opts Options) (index.Entry, error) {
+	tmp, err := os.MkdirTemp("", "forge-info-*")
+	if err != nil {
+		return index.Entry{}, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	if err := pullIndexed(reg, e, tmp, opts); err != nil {
+		return index.Entry{}, err
+	}
+	verified, err := index.FromDir(filepath.Join(tmp, e.Name))
+	if err != nil {
+		return index.Entry{}, err
+	}
+	verified.URL = e.URL
+	return verified, nil
+}
+
+// pullIndexed installs the template described by e into destParentDir,
+// checking it against the registry's checksum manifest, if any, and the
+// index checksum before it replaces anything
+func pullIndexed(reg config.Registry, e index.Entry, destParentDir string, opts Options) error {
+	if err := scaffold.ValidateName(e.Name); err != nil {
+		return fmt.Errorf("invalid template name %q in the index: %w", e.Name, err)
+	}
+	if e.URL == "" {
+		return fmt.Errorf("template '%s' has no archive URL in the index", e.Name)
+	}
+
+	archivePath, cleanup, err := fetchArchive(e.URL, opts)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	if err := verifyArchive(reg, archiveName(e.URL), archivePath, opts); err != nil {
+		return err
+	}
+	return installIndexed(archivePath, e, sourceInfo(reg, e.Name, indexPin(e)), destParentDir, opts)
+}
+
+// installIndexed extracts e's template from an archive written by forge
+// pack and installs it into destParentDir, recording source, once it
+// matches e.Checksum
+func installIndexed(archivePath string, e index.Entry, source remote.SourceInfo, destParentDir string, opts Options) error {
+	// e.Name becomes a directory name; never let it leave destParentDir
+	if err := scaffold.ValidateName(e.Name); err != nil {
+		return fmt.Errorf("invalid template name %q: %w", e.Name, err)
+	}
+	// Hidden, so template discovery ignores it
+	tmp, err := os.MkdirTemp(destParentDir, ".index-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	dir := filepath.Join(tmp, e.Name)
+	if err := remote.ExtractTemplate(archivePath, e.Name, dir); err != nil {
+		return err
+	}
+
+	if e.Checksum != "" && !opts.InsecureSkipVerify {
+		got, err := lock.HashDir(dir, remote.SourceFile)
+		if err != nil {
+			return err
+		}
+		if got != e.Checksum {
+			return fmt.Errorf("%w: '%s' lists %s, downloaded %s", ErrChecksumMismatch, e.Name, e.Checksum, got)
+		}
+	}
+	if err := remote.WriteSourceInfo(dir, source); err != nil {
+		return err
+	}
+	return remote.InstallPrepared(dir, destParentDir, e.Name)
+}
+
+// resolveURL resolves ref, which may be relative, against the index URL
+func resolveURL(base, ref string) (string, error) {
+	if ref == "" {
+		return "", nil
+	}
+	b, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("invalid index URL: %w", err)
+	}
+	r, err := url.Parse(ref)
+	if err != nil {
+		return "", fmt.Errorf("invalid archive URL %q: %w", ref, err)
+	}
+	return b.ResolveReference(r).String(), nil
+}
@@ -0,0 +1,221 @@
+package registry
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"forge/internal/config"
+	"forge/internal/index"
+)
+
+const fastapiYAML = `name: fastapi
+description: FastAPI service
+version: 2.0.0
+tags: [python, web]
+commands:
+  - cmd: ["uv", "init"]
+files:
+  copy: []
+`
+
+// indexedTemplate returns an index entry for a template and a zip archive
+// with the template at its root, as forge pack writes them
+func indexedTemplate(t *testing.T, name, yamlContent string) (index.Entry, []byte) {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), name)
+	os.MkdirAll(dir, 0755)
+	if err := os.WriteFile(filepath.Join(dir, "template.yaml"), []byte(yamlContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+	entry, err := index.FromDir(dir)
+	if err != nil {
+		t.Fatalf("FromDir() error = %v", err)
+	}
+	entry.URL = "archives/" + name + ".zip"
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, _ := zw.Create("template.yaml")
+	w.Write([]byte(yamlContent))
+	zw.Close()
+	return entry, buf.Bytes()
+}
+
+func indexJSON(t *testing.T, entries ...index.Entry) []byte {
+	t.Helper()
+	data, err := json.Marshal(index.Index{Templates: entries})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestPullFromIndexRegistry(t *testing.T) {
+	entry, archive := indexedTemplate(t, "fastapi", fastapiYAML)
+	srv := serveRegistries(t, map[string][]byte{
+		"/idx/index.json":           indexJSON(t, entry),
+		"/idx/archives/fastapi.zip": archive,
+	})
+	cfg := &config.Config{Registries: []config.Registry{
+		{Name: "internal", URL: srv.URL + "/idx/index.json", Type: config.RegistryTypeIndex},
+	}}
+
+	dest := t.TempDir()
+	reg, _, err := Pull(cfg, Ref{Name: "fastapi"}, dest, Options{})
+	if err != nil {
+		t.Fatalf("Pull() error = %v", err)
+	}
+	if reg.Name != "internal" {
+		t.Errorf("Pull() registry = %s, want internal", reg.Name)
+	}
+	if got := readInstalled(t, dest, "fastapi"); got != fastapiYAML {
+		t.Errorf("installed template.yaml = %q", got)
+	}
+
+	if _, _, err := Pull(cfg, Ref{Name: "missing"}, dest, Options{}); err == nil {
+		t.Error("Pull() of an unknown template should fail")
+	}
+}
+
+func TestPullFromIndexRejectsChecksumMismatch(t *testing.T) {
+	entry, _ := indexedTemplate(t, "fastapi", fastapiYAML)
+	_, tampered := indexedTemplate(t, "fastapi", strings.Replace(fastapiYAML, "uv", "curl", 1))
+	srv := serveRegistries(t, map[string][]byte{
+		"/index.json":           indexJSON(t, entry),
+		"/archives/fastapi.zip": tampered,
+	})
+	reg := config.Registry{Name: "internal", URL: srv.URL + "/index.json", Type: config.RegistryTypeIndex}
+
+	dest := t.TempDir()
+	_, err := pullFrom(reg, "fastapi", dest, Options{})
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("pullFrom() error = %v, want ErrChecksumMismatch", err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "fastapi")); !os.IsNotExist(err) {
+		t.Error("a template failing its checksum must not be installed")
+	}
+}
+
+func TestPullRejectsEscapingIndexNames(t *testing.T) {
+	_, archive := indexedTemplate(t, "fastapi", fastapiYAML)
+	srv := serveRegistries(t, map[string][]byte{
+		"/index.json":           []byte(`{"templates":[{"name":"../../escaped","url":"archives/fastapi.zip"}]}`),
+		"/archives/fastapi.zip": archive,
+	})
+	reg := config.Registry{Name: "internal", URL: srv.URL + "/index.json", Type: config.RegistryTypeIndex}
+	cfg := &config.Config{Registries: []config.Registry{reg}}
+
+	root := t.TempDir()
+	dest := filepath.Join(root, "a", "b")
+	os.MkdirAll(dest, 0755)
+	escaped := filepath.Join(dest, "..", "..", "escaped")
+
+	if _, _, err := Pull(cfg, Ref{Name: "fastapi"}, dest, Options{}); err == nil {
+		t.Error("Pull() should fail for an index with an escaping name")
+	}
+	if _, err := PullAll(cfg.Registries, dest, Options{}); err == nil {
+		t.Error("PullAll() should fail for an index with an escaping name")
+	}
+	if _, err := os.Stat(escaped); !os.IsNotExist(err) {
+		t.Fatalf("template written outside the destination: %v", err)
+	}
+
+	// Entries that bypass index parsing are checked again before installing
+	e := index.Entry{Name: "../../escaped", URL: srv.URL + "/archives/fastapi.zip"}
+	if err := pullIndexed(reg, e, dest, Options{}); err == nil {
+		t.Error("pullIndexed() should reject an escaping name")
+	}
+	if _, err := os.Stat(escaped); !os.IsNotExist(err) {
+		t.Fatalf("template written outside the destination: %v", err)
+	}
+}
+
+func TestInfoReadsVerifiedTemplate(t *testing.T) {
+	entry, archive := indexedTemplate(t, "fastapi", fastapiYAML)
+	// The index misdescribes what the archive runs
+	listed := entry
+	listed.Commands = []string{"echo harmless"}
+	listed.Requires = []string{"echo"}
+	tampered := entry
+	tampered.Name = "tampered"
+	tampered.Checksum = listed.Checksum + "0"
+	srv := serveRegistries(t, map[string][]byte{
+		"/index.json":           indexJSON(t, listed, tampered),
+		"/archives/fastapi.zip": archive,
+	})
+	cfg := &config.Config{Registries: []config.Registry{
+		{Name: "internal", URL: srv.URL + "/index.json", Type: config.RegistryTypeIndex},
+	}}
+
+	_, e, err := Info(cfg, Ref{Name: "fastapi"}, Options{})
+	if err != nil {
+		t.Fatalf("Info() error = %v", err)
+	}
+	if len(e.Commands) != 1 || e.Commands[0] != "uv init" || e.Requires[0] != "uv" {
+		t.Errorf("Info() commands = %v, requires = %v, want those of template.yaml", e.Commands, e.Requires)
+	}
+
+	// An archive that does not match its checksum is refused
+	if _, _, err := Info(cfg, Ref{Name: "tampered"}, Options{}); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Info() error = %v, want ErrChecksumMismatch", err)
+	}
+}
+
+func TestSearchAcrossRegistryTypes(t *testing.T) {
+	entry, archive := indexedTemplate(t, "fastapi", fastapiYAML)
+	srv := serveRegistries(t, map[string][]byte{
+		"/index.json":           indexJSON(t, entry),
+		"/archives/fastapi.zip": archive,
+		"/official.zip": zipArchive(t, "templates-main/", map[string]string{
+			"python": "name: python\ndescription: Python project\ncommands: []\nfiles:\n  copy: []\n",
+			"node":   "name: node\ndescription: Node.js package\ncommands: []\nfiles:\n  copy: []\n",
+		}),
+	})
+	regs := []config.Registry{
+		{Name: "internal", URL: srv.URL + "/index.json", Type: config.RegistryTypeIndex},
+		{Name: "official", URL: srv.URL + "/official.zip", Type: config.RegistryTypeZip},
+		{Name: "broken", URL: srv.URL + "/missing.zip", Type: config.RegistryTypeZip},
+	}
+
+	results, failed := Search(regs, "python", Options{})
+	if len(results) != 2 {
+		t.Fatalf("Search() = %+v, want python and fastapi", results)
+	}
+	if results[0].Name != "python" || results[0].Registry != "official" {
+		t.Errorf("best match = %s/%s, want official/python", results[0].Registry, results[0].Name)
+	}
+	if results[1].Name != "fastapi" || results[1].Version != "2.0.0" {
+		t.Errorf("second match = %+v", results[1])
+	}
+	if _, ok := failed["broken"]; !ok || len(failed) != 1 {
+		t.Errorf("failed = %v, want only the broken registry", failed)
+	}
+}
+
+func TestInfo(t *testing.T) {
+	entry, archive := indexedTemplate(t, "fastapi", fastapiYAML)
+	srv := serveRegistries(t, map[string][]byte{
+		"/index.json":           indexJSON(t, entry),
+		"/archives/fastapi.zip": archive,
+	})
+	cfg := &config.Config{Registries: []config.Registry{
+		{Name: "internal", URL: srv.URL + "/index.json", Type: config.RegistryTypeIndex},
+	}}
+
+	reg, e, err := Info(cfg, Ref{Registry: "internal", Name: "fastapi"}, Options{})
+	if err != nil {
+		t.Fatalf("Info() error = %v", err)
+	}
+	if reg.Name != "internal" || e.Commands[0] != "uv init" || e.Requires[0] != "uv" {
+		t.Errorf("Info() = %s, %+v", reg.Name, e)
+	}
+	if e.URL != srv.URL+"/archives/fastapi.zip" {
+		t.Errorf("URL = %s, want it resolved against the index", e.URL)
+	}
+}
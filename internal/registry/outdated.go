@@ -0,0 +1,124 @@
+package registry
+
+import (
+	"fmt"
+
+	"forge/internal/config"
+	"forge/internal/index"
+	"forge/internal/version"
+)
+
+// Installed template states reported by CheckOutdated
+const (
+	StatusUpToDate = "up to date"
+	StatusNewer    = "newer version" // the registry has a higher version
+	StatusChanged  = "changed"       // same version, different content
+	StatusAhead    = "ahead"         // installed version is above the registry's
+	StatusMissing  = "not in registry"
+)
+
+// LocalTemplate is an installed template to check for updates
+type LocalTemplate struct {
+	Ref     Ref    // where it was pulled from; Registry may be empty
+	Version string // template.yaml version
+	Hash    string // content hash, see lock.HashDir
+}
+
+// Status compares an installed template with its registry
+type Status struct {
+	LocalTemplate
+	Registry string // registry that provides the template
+	Latest   index.Entry
+	State    string
+}
+
+// Outdated reports whether pulling the template again would change it
+func (s Status) Outdated() bool {
+	return s.State == StatusNewer || s.State == StatusChanged
+}
+
+// CheckOutdated compares installed templates with the indexes of the
+// registries they come from. A template without a registry is looked up
+// in every registry by priority. Registries whose index cannot be fetched
+// are reported in the error map; their templates are left out.
+func CheckOutdated(cfg *config.Config, templates []LocalTemplate, opts Options) ([]Status, map[string]error) {
+	indexes := map[string]*index.Index{}
+	failed := map[string]error{}
+	fetch := func(reg config.Registry) *index.Index {
+		if idx, ok := indexes[reg.Name]; ok {
+			return idx
+		}
+		if _, ok := failed[reg.Name]; ok {
+			return nil
+		}
+		idx, err := FetchIndex(reg, opts)
+		if err != nil {
+			failed[reg.Name] = err
+			return nil
+		}
+		indexes[reg.Name] = idx
+		return idx
+	}
+
+	var statuses []Status
+	for _, t := range templates {
+		regs, err := Candidates(cfg, t.Ref)
+		if err != nil {
+			failed[t.Ref.Registry] = err
+			continue
+		}
+
+		status := Status{LocalTemplate: t, State: StatusMissing}
+		skipped := false
+		for _, reg := range regs {
+			idx := fetch(reg)
+			if idx == nil {
+				skipped = true
+				continue
+			}
+			if e, ok := idx.Find(t.Ref.Name); ok {
+				status.Registry = reg.Name
+				status.Latest = e
+				status.State = compare(t, e)
+				break
+			}
+		}
+		if status.State == StatusMissing && skipped {
+			continue
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, failed
+}
+
+// compare decides the state of an installed template. Versions decide when
+// both sides have a valid one; otherwise, or when they are equal, content
+// hashes do.
+func compare(t LocalTemplate, latest index.Entry) string {
+	if t.Version != "" && latest.Version != "" {
+		if c, err := version.Compare(t.Version, latest.Version); err == nil {
+			switch {
+			case c < 0:
+				return StatusNewer
+			case c > 0:
+				return StatusAhead
+			}
+		}
+	}
+	if latest.Checksum != "" && t.Hash != "" && t.Hash != latest.Checksum {
+		return StatusChanged
+	}
+	return StatusUpToDate
+}
+
+// String renders the installed and latest versions, e.g. "1.0.0 -> 1.2.0"
+func (s Status) String() string {
+	return fmt.Sprintf("%s -> %s", orDash(s.Version), orDash(s.Latest.Version))
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,68 @@
+package registry
+
+import (
+	"testing"
+
+	"forge/internal/config"
+	"forge/internal/index"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name   string
+		local  LocalTemplate
+		latest index.Entry
+		want   string
+	}{
+		{"same", LocalTemplate{Version: "1.0.0", Hash: "h1"}, index.Entry{Version: "1.0.0", Checksum: "h1"}, StatusUpToDate},
+		{"newer", LocalTemplate{Version: "1.0.0", Hash: "h1"}, index.Entry{Version: "1.1.0", Checksum: "h2"}, StatusNewer},
+		{"ahead", LocalTemplate{Version: "2.0.0", Hash: "h1"}, index.Entry{Version: "1.1.0", Checksum: "h2"}, StatusAhead},
+		{"prerelease", LocalTemplate{Version: "1.1.0-beta.1"}, index.Entry{Version: "1.1.0"}, StatusNewer},
+		{"changed content", LocalTemplate{Version: "1.0.0", Hash: "h1"}, index.Entry{Version: "1.0.0", Checksum: "h2"}, StatusChanged},
+		{"unversioned", LocalTemplate{Hash: "h1"}, index.Entry{Checksum: "h2"}, StatusChanged},
+		{"no checksum", LocalTemplate{Hash: "h1"}, index.Entry{}, StatusUpToDate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := compare(tt.local, tt.latest); got != tt.want {
+				t.Errorf("compare() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckOutdated(t *testing.T) {
+	entry, archive := indexedTemplate(t, "fastapi", fastapiYAML)
+	srv := serveRegistries(t, map[string][]byte{
+		"/index.json":           indexJSON(t, entry),
+		"/archives/fastapi.zip": archive,
+	})
+	cfg := &config.Config{Registries: []config.Registry{
+		{Name: "internal", URL: srv.URL + "/index.json", Type: config.RegistryTypeIndex},
+		{Name: "down", URL: srv.URL + "/gone.json", Type: config.RegistryTypeIndex},
+	}}
+
+	installed := []LocalTemplate{
+		{Ref: Ref{Registry: "internal", Name: "fastapi"}, Version: "1.0.0", Hash: "old"},
+		{Ref: Ref{Name: "fastapi"}, Version: "2.0.0", Hash: entry.Checksum},
+		{Ref: Ref{Registry: "internal", Name: "gone"}},
+		{Ref: Ref{Registry: "down", Name: "other"}},
+	}
+	statuses, failed := CheckOutdated(cfg, installed, Options{})
+
+	if len(statuses) != 3 {
+		t.Fatalf("CheckOutdated() = %+v, want 3 statuses", statuses)
+	}
+	if s := statuses[0]; s.State != StatusNewer || !s.Outdated() || s.String() != "1.0.0 -> 2.0.0" {
+		t.Errorf("status[0] = %q %s", s.State, s)
+	}
+	if s := statuses[1]; s.State != StatusUpToDate || s.Registry != "internal" {
+		t.Errorf("status[1] = %q from %q", s.State, s.Registry)
+	}
+	if s := statuses[2]; s.State != StatusMissing || s.Outdated() {
+		t.Errorf("status[2] = %q", s.State)
+	}
+	if _, ok := failed["down"]; !ok {
+		t.Errorf("failed = %v, want the unreachable registry reported", failed)
+	}
+}
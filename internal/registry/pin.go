@@ -0,0 +1,57 @@
+package registry
+
+import (
+	"fmt"
+
+	"forge/internal/config"
+	"forge/internal/index"
+	"forge/internal/remote"
+)
+
+// Pin records where a pulled template came from precisely enough to fetch
+// the same content again after the registry has moved on
+type Pin struct {
+	Version string // version listed in the index, if any
+	URL     string // archive to fetch the pinned content from
+	Commit  string // commit the archive was built from, when known
+}
+
+// indexPin pins a template from an index registry to its archive URL,
+// which forge publish makes unique per version
+func indexPin(e index.Entry) Pin {
+	return Pin{Version: e.Version, URL: e.URL}
+}
+
+// zipPin pins a zip registry to the commit its archive was built from, so
+// a registry tracking a branch can be fetched at that commit later. Without
+// a known commit the registry URL is all there is to record.
+func zipPin(reg config.Registry, archivePath string) Pin {
+	commit := remote.ArchiveCommit(archivePath)
+	return Pin{URL: remote.CommitArchiveURL(reg.URL, commit), Commit: commit}
+}
+
+// PullPinned installs the template called name from the archive a pin
+// records, instead of the registry's current one. The registry's checksum
+// manifest describes its current archives, so it is not consulted; callers
+// must check the result against the content hash stored with the pin.
+func PullPinned(reg config.Registry, name string, pin Pin, destParentDir string, opts Options) error {
+	if pin.URL == "" {
+		return fmt.Errorf("the pin for '%s' records no archive URL", name)
+	}
+	archivePath, cleanup, err := fetchArchive(pin.URL, opts)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	switch reg.Type {
+	case config.RegistryTypeZip:
+		if err := remote.InstallSingleTemplate(archivePath, name, destParentDir); err != nil {
+			return err
+		}
+		return recordSource(reg, name, destParentDir, pin)
+	case config.RegistryTypeIndex:
+		return installIndexed(archivePath, index.Entry{Name: name}, sourceInfo(reg, name, pin), destParentDir, opts)
+	}
+	return fmt.Errorf("unsupported registry type %q", reg.Type)
+}
@@ -0,0 +1,71 @@
+package registry
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"forge/internal/config"
+)
+
+func TestPullPinnedAfterIndexMovesOn(t *testing.T) {
+	v1, v1Archive := indexedTemplate(t, "fastapi", fastapiYAML)
+	v1.URL = "archives/fastapi-2.0.0.zip"
+	archives := map[string][]byte{
+		"/idx/index.json":                 indexJSON(t, v1),
+		"/idx/archives/fastapi-2.0.0.zip": v1Archive,
+	}
+	srv := serveRegistries(t, archives)
+	reg := config.Registry{Name: "internal", URL: srv.URL + "/idx/index.json", Type: config.RegistryTypeIndex}
+	cfg := &config.Config{Registries: []config.Registry{reg}}
+
+	_, pin, err := Pull(cfg, Ref{Name: "fastapi"}, t.TempDir(), Options{})
+	if err != nil {
+		t.Fatalf("Pull() error = %v", err)
+	}
+	if pin.Version != "2.0.0" || pin.URL != srv.URL+"/idx/archives/fastapi-2.0.0.zip" {
+		t.Fatalf("Pull() pin = %+v, want the version and absolute archive URL", pin)
+	}
+
+	// The registry publishes 3.0.0; the pin still installs 2.0.0
+	v3YAML := strings.Replace(fastapiYAML, "2.0.0", "3.0.0", 1)
+	v3, v3Archive := indexedTemplate(t, "fastapi", v3YAML)
+	v3.URL = "archives/fastapi-3.0.0.zip"
+	archives["/idx/archives/fastapi-3.0.0.zip"] = v3Archive
+	archives["/idx/index.json"] = indexJSON(t, v3)
+
+	dest := t.TempDir()
+	if err := PullPinned(reg, "fastapi", pin, dest, Options{}); err != nil {
+		t.Fatalf("PullPinned() error = %v", err)
+	}
+	if got := readInstalled(t, dest, "fastapi"); got != fastapiYAML {
+		t.Errorf("PullPinned() installed %q, want the pinned 2.0.0", got)
+	}
+
+	if err := PullPinned(reg, "fastapi", Pin{}, dest, Options{}); err == nil {
+		t.Error("PullPinned() without an archive URL should fail")
+	}
+}
+
+func TestZipPinRecordsArchiveCommit(t *testing.T) {
+	commit := strings.Repeat("ab", 20)
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, _ := zw.Create("forge-templates-main/python/template.yaml")
+	w.Write([]byte("name: python\n"))
+	zw.SetComment(commit)
+	zw.Close()
+
+	path := filepath.Join(t.TempDir(), "main.zip")
+	os.WriteFile(path, buf.Bytes(), 0644)
+
+	reg := config.Registry{Name: "official", URL: "https://github.com/org/forge-templates/archive/refs/heads/main.zip", Type: config.RegistryTypeZip}
+	pin := zipPin(reg, path)
+	want := "https://github.com/org/forge-templates/archive/" + commit + ".zip"
+	if pin.Commit != commit || pin.URL != want {
+		t.Errorf("zipPin() = %+v, want commit %s at %s", pin, commit, want)
+	}
+}
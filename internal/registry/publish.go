@@ -0,0 +1,94 @@
+package registry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"forge/internal/config"
+	"forge/internal/index"
+	"forge/internal/pack"
+	"forge/internal/remote"
+)
+
+// Publish uploads a packed template and its manifest to an index registry
+// and returns the entry the registry now lists
+func Publish(reg config.Registry, archivePath string) (index.Entry, error) {
+	if reg.Type != config.RegistryTypeIndex {
+		return index.Entry{}, fmt.Errorf("registry '%s' is a %s registry; only index registries accept uploads", reg.Name, reg.Type)
+	}
+	m, err := pack.ReadManifest(pack.ManifestPath(archivePath))
+	if err != nil {
+		return index.Entry{}, err
+	}
+	// Catch a stale or mismatched manifest before uploading anything
+	if err := m.Verify(archivePath); err != nil {
+		return index.Entry{}, err
+	}
+
+	body, contentType, err := publishBody(archivePath, m)
+	if err != nil {
+		return index.Entry{}, err
+	}
+	target, err := resolveURL(reg.URL, index.PublishPath)
+	if err != nil {
+		return index.Entry{}, err
+	}
+
+	resp, err := remote.DefaultClient().Post(target, contentType, body)
+	if err != nil {
+		return index.Entry{}, fmt.Errorf("failed to publish to %s: %w", reg.Name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return index.Entry{}, fmt.Errorf("registry '%s' rejected %s %s: http %d: %s",
+			reg.Name, m.Name, m.Version, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	var entry index.Entry
+	if err := json.NewDecoder(resp.Body).Decode(&entry); err != nil {
+		return index.Entry{}, fmt.Errorf("invalid publish response: %w", err)
+	}
+	return entry, nil
+}
+
+// publishBody builds the multipart upload with the manifest and archive
+func publishBody(archivePath string, m *pack.Manifest) ([]byte, string, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+
+	manifestData, err := json.Marshal(m)
+	if err != nil {
+		return nil, "", err
+	}
+	w, err := mw.CreateFormFile("manifest", filepath.Base(pack.ManifestPath(archivePath)))
+	if err != nil {
+		return nil, "", err
+	}
+	w.Write(manifestData)
+
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read archive: %w", err)
+	}
+	defer f.Close()
+	if w, err = mw.CreateFormFile("archive", m.Archive); err != nil {
+		return nil, "", err
+	}
+	if _, err := io.Copy(w, f); err != nil {
+		return nil, "", fmt.Errorf("failed to read archive: %w", err)
+	}
+
+	if err := mw.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), mw.FormDataContentType(), nil
+}
@@ -0,0 +1,127 @@
+package registry
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"forge/internal/cache"
+	"forge/internal/config"
+	"forge/internal/pack"
+	"forge/internal/regserver"
+)
+
+func TestPublishThenPull(t *testing.T) {
+	store, err := regserver.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(regserver.New(store, regserver.Options{}))
+	defer srv.Close()
+	reg := config.Registry{Name: "internal", URL: srv.URL + "/index.json", Type: config.RegistryTypeIndex}
+
+	src := filepath.Join(t.TempDir(), "fastapi")
+	os.MkdirAll(src, 0755)
+	os.WriteFile(filepath.Join(src, "template.yaml"), []byte(fastapiYAML), 0644)
+	out := t.TempDir()
+	m, err := pack.Pack(src, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive := filepath.Join(out, m.Archive)
+
+	entry, err := Publish(reg, archive)
+	if err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if entry.Name != "fastapi" || entry.Version != "2.0.0" {
+		t.Errorf("Publish() = %+v", entry)
+	}
+
+	_, err = Publish(reg, archive)
+	if err == nil || !strings.Contains(err.Error(), "http 409") {
+		t.Errorf("second Publish() error = %v, want a conflict", err)
+	}
+
+	dest := t.TempDir()
+	cfg := &config.Config{Registries: []config.Registry{reg}}
+	if _, _, err := Pull(cfg, Ref{Name: "fastapi"}, dest, Options{}); err != nil {
+		t.Fatalf("Pull() error = %v", err)
+	}
+	if got := readInstalled(t, dest, "fastapi"); got != fastapiYAML {
+		t.Errorf("installed template.yaml = %q", got)
+	}
+}
+
+func TestPublishNeedsIndexRegistry(t *testing.T) {
+	reg := config.Registry{Name: "official", URL: "https://example.com/t.zip", Type: config.RegistryTypeZip}
+	if _, err := Publish(reg, "t.tar.gz"); err == nil {
+		t.Error("Publish() to a zip registry should fail")
+	}
+}
+
+func TestServedDirectoryRegistry(t *testing.T) {
+	store, err := regserver.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := filepath.Join(t.TempDir(), "fastapi")
+	os.MkdirAll(src, 0755)
+	os.WriteFile(filepath.Join(src, "template.yaml"), []byte(fastapiYAML), 0644)
+	// forge pack straight into the served directory, no upload
+	if _, err := pack.Pack(src, store.Dir()); err != nil {
+		t.Fatal(err)
+	}
+
+	notModified := 0
+	handler := regserver.New(store, regserver.Options{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w}
+		handler.ServeHTTP(rec, r)
+		if rec.status == http.StatusNotModified {
+			notModified++
+		}
+	}))
+	defer srv.Close()
+
+	reg := config.Registry{
+		Name:     "internal",
+		URL:      srv.URL + "/index.json",
+		Type:     config.RegistryTypeIndex,
+		Manifest: srv.URL + "/" + regserver.ChecksumsFile,
+	}
+	cfg := &config.Config{Registries: []config.Registry{reg}}
+	opts := Options{Cache: cache.New(t.TempDir())}
+
+	results, errs := Search(cfg.Registries, "fast", opts)
+	if len(errs) > 0 || len(results) != 1 || results[0].Version != "2.0.0" {
+		t.Fatalf("Search() = %+v, %v", results, errs)
+	}
+
+	dest := t.TempDir()
+	for i := 0; i < 2; i++ {
+		if _, _, err := Pull(cfg, Ref{Name: "fastapi"}, dest, opts); err != nil {
+			t.Fatalf("Pull() #%d error = %v", i+1, err)
+		}
+	}
+	if got := readInstalled(t, dest, "fastapi"); got != fastapiYAML {
+		t.Errorf("installed template.yaml = %q", got)
+	}
+	// The second pull revalidates index, checksums and archive by ETag
+	if notModified < 3 {
+		t.Errorf("%d responses were 304 Not Modified, want at least 3", notModified)
+	}
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
@@ -0,0 +1,337 @@
+package registry
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"forge/internal/cache"
+	"forge/internal/config"
+	"forge/internal/remote"
+	"forge/internal/scaffold"
+)
+
+// Ref identifies a template, optionally qualified by the registry that
+// provides it: "python" or "team/python"
+type Ref struct {
+	Registry string
+	Name     string
+}
+
+// ParseRef parses a template reference
+func ParseRef(s string) (Ref, error) {
+	var ref Ref
+	if i := strings.Index(s, "/"); i >= 0 {
+		ref.Registry, ref.Name = s[:i], s[i+1:]
+		if ref.Registry == "" {
+			return Ref{}, fmt.Errorf("invalid template reference '%s': empty registry name", s)
+		}
+	} else {
+		ref.Name = s
+	}
+
+	if err := scaffold.ValidateName(ref.Name); err != nil {
+		return Ref{}, fmt.Errorf("invalid template reference '%s': %w", s, err)
+	}
+	return ref, nil
+}
+
+// String returns the reference in registry/name form
+func (r Ref) String() string {
+	if r.Registry == "" {
+		return r.Name
+	}
+	return r.Registry + "/" + r.Name
+}
+
+// Candidates returns the registries to search for ref, in order: the named
+// registry when ref is qualified, otherwise all registries by priority
+func Candidates(cfg *config.Config, ref Ref) ([]config.Registry, error) {
+	if ref.Registry == "" {
+		return cfg.SortedRegistries(), nil
+	}
+
+	reg, ok := cfg.Registry(ref.Registry)
+	if !ok {
+		return nil, fmt.Errorf("unknown registry '%s' (see 'forge registry list')", ref.Registry)
+	}
+	return []config.Registry{reg}, nil
+}
+
+// Options control how registry archives are downloaded and verified
+type Options struct {
+	// TrustedKeys verify manifest signatures of signed registries
+	TrustedKeys []ed25519.PublicKey
+	// InsecureSkipVerify disables checksum and signature verification
+	InsecureSkipVerify bool
+	// Cache, when set, keeps downloads so unchanged archives are not
+	// downloaded again
+	Cache *cache.Cache
+	// Offline installs from Cache only, without network access
+	Offline bool
+}
+
+// OptionsFromConfig returns the options configured in cfg
+func OptionsFromConfig(cfg *config.Config) (Options, error) {
+	var opts Options
+	for _, k := range cfg.TrustedKeys {
+		key, err := remote.ParsePublicKey(k.Key)
+		if err != nil {
+			return Options{}, fmt.Errorf("trusted key '%s': %w", k.Name, err)
+		}
+		opts.TrustedKeys = append(opts.TrustedKeys, key)
+	}
+	return opts, nil
+}
+
+// Pull installs the template named by ref into destParentDir/<name> from
+// the first candidate registry that provides it, and returns that registry
+// and a pin to fetch the same content again
+func Pull(cfg *config.Config, ref Ref, destParentDir string, opts Options) (config.Registry, Pin, error) {
+	regs, err := Candidates(cfg, ref)
+	if err != nil {
+		return config.Registry{}, Pin{}, err
+	}
+
+	var failures []string
+	for _, reg := range regs {
+		pin, err := pullFrom(reg, ref.Name, destParentDir, opts)
+		if err == nil {
+			return reg, pin, nil
+		}
+		if errors.Is(err, remote.ErrTemplateNotFound) {
+			continue
+		}
+		// An explicitly named registry reports its own error directly
+		if ref.Registry != "" {
+			return config.Registry{}, Pin{}, err
+		}
+		failures = append(failures, fmt.Sprintf("%s: %v", reg.Name, err))
+	}
+
+	msg := fmt.Sprintf("template '%s' not found", ref)
+	if ref.Registry == "" {
+		msg = fmt.Sprintf("template '%s' not found in any registry", ref.Name)
+	}
+	if len(failures) > 0 {
+		msg += " (" + strings.Join(failures, "; ") + ")"
+	}
+	return config.Registry{}, Pin{}, errors.New(msg)
+}
+
+// Installed records a template installed by PullAll
+type Installed struct {
+	Name     string
+	Registry string
+	Pin      Pin
+}
+
+// PullAll installs every template from the given registries. Registries are
+// processed lowest priority first, so on a name clash the template from the
+// highest priority registry is the one left installed.
+func PullAll(regs []config.Registry, destParentDir string, opts Options) ([]Installed, error) {
+	ordered := make([]config.Registry, len(regs))
+	for i, reg := range regs {
+		ordered[len(regs)-1-i] = reg
+	}
+
+	byName := map[string]int{}
+	var installed []Installed
+	for _, reg := range ordered {
+		pulled, err := pullAllFrom(reg, destParentDir, opts)
+		if err != nil {
+			return installed, fmt.Errorf("registry '%s': %w", reg.Name, err)
+		}
+		for _, t := range pulled {
+			if i, ok := byName[t.Name]; ok {
+				installed[i] = t
+				continue
+			}
+			byName[t.Name] = len(installed)
+			installed = append(installed, t)
+		}
+	}
+	return installed, nil
+}
+
+func pullFrom(reg config.Registry, name, destParentDir string, opts Options) (Pin, error) {
+	switch reg.Type {
+	case config.RegistryTypeZip:
+		zipPath, cleanup, err := download(reg, opts)
+		if err != nil {
+			return Pin{}, err
+		}
+		defer cleanup()
+		if err := remote.InstallSingleTemplate(zipPath, name, destParentDir); err != nil {
+			return Pin{}, err
+		}
+		pin := zipPin(reg, zipPath)
+		if err := recordSource(reg, name, destParentDir, pin); err != nil {
+			return Pin{}, err
+		}
+		return pin, nil
+
+	case config.RegistryTypeIndex:
+		idx, err := FetchIndex(reg, opts)
+		if err != nil {
+			return Pin{}, err
+		}
+		e, ok := idx.Find(name)
+		if !ok {
+			return Pin{}, remote.ErrTemplateNotFound
+		}
+		if err := pullIndexed(reg, e, destParentDir, opts); err != nil {
+			return Pin{}, err
+		}
+		return indexPin(e), nil
+	}
+	return Pin{}, fmt.Errorf("unsupported registry type %q", reg.Type)
+}
+
+func pullAllFrom(reg config.Registry, destParentDir string, opts Options) ([]Installed, error) {
+	var installed []Installed
+	switch reg.Type {
+	case config.RegistryTypeZip:
+		zipPath, cleanup, err := download(reg, opts)
+		if err != nil {
+			return nil, err
+		}
+		defer cleanup()
+		names, err := remote.InstallAllTemplates(zipPath, destParentDir)
+		if err != nil {
+			return nil, err
+		}
+		pin := zipPin(reg, zipPath)
+		for _, name := range names {
+			if err := recordSource(reg, name, destParentDir, pin); err != nil {
+				return installed, err
+			}
+			installed = append(installed, Installed{Name: name, Registry: reg.Name, Pin: pin})
+		}
+		return installed, nil
+
+	case config.RegistryTypeIndex:
+		idx, err := FetchIndex(reg, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range idx.Templates {
+			if err := pullIndexed(reg, e, destParentDir, opts); err != nil {
+				return installed, fmt.Errorf("template '%s': %w", e.Name, err)
+			}
+			installed = append(installed, Installed{Name: e.Name, Registry: reg.Name, Pin: indexPin(e)})
+		}
+		return installed, nil
+	}
+	return nil, fmt.Errorf("unsupported registry type %q", reg.Type)
+}
+
+// sourceInfo describes a template installed from reg, so it is not mistaken
+// for one the user made
+func sourceInfo(reg config.Registry, name string, pin Pin) remote.SourceInfo {
+	return remote.SourceInfo{Source: reg.Name + "/" + name, Commit: pin.Commit}
+}
+
+// recordSource writes the source of a template installed into
+// destParentDir/<name>, replacing any source file its archive carried
+func recordSource(reg config.Registry, name, destParentDir string, pin Pin) error {
+	return remote.WriteSourceInfo(filepath.Join(destParentDir, name), sourceInfo(reg, name, pin))
+}
+
+// download fetches the registry archive, through the cache if configured,
+// and verifies it. cleanup removes the file unless it belongs to the cache.
+func download(reg config.Registry, opts Options) (path string, cleanup func(), err error) {
+	path, cleanup, err = fetchArchive(reg.URL, opts)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if err := Verify(reg, path, opts); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return path, cleanup, nil
+}
+
+// fetchArchive downloads an archive, through the cache if configured.
+// cleanup removes the file unless it belongs to the cache.
+func fetchArchive(url string, opts Options) (path string, cleanup func(), err error) {
+	cleanup = func() {}
+	if opts.Cache != nil {
+		path, err = remote.FetchCached(opts.Cache, url, opts.Offline)
+	} else if opts.Offline {
+		return "", nil, fmt.Errorf("cannot download %s in offline mode without a cache", url)
+	} else {
+		path, err = remote.DownloadRepoZip(url)
+		cleanup = func() { os.Remove(path) }
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	return path, cleanup, nil
+}
+
+// fetchBytes downloads a small file such as a manifest, through the cache
+// if configured
+func fetchBytes(url string, opts Options) ([]byte, error) {
+	if opts.Cache == nil {
+		return remote.FetchBytes(url)
+	}
+	path, err := remote.FetchCached(opts.Cache, url, opts.Offline)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(path)
+}
+
+// Verify checks a downloaded registry archive against the registry's
+// checksum manifest and, for signed registries, the manifest signature.
+// Registries without a manifest are not verified.
+func Verify(reg config.Registry, archivePath string, opts Options) error {
+	return verifyArchive(reg, archiveName(reg.URL), archivePath, opts)
+}
+
+// verifyArchive checks archivePath against the digest the registry's
+// manifest lists for name
+func verifyArchive(reg config.Registry, name, archivePath string, opts Options) error {
+	if opts.InsecureSkipVerify || reg.Manifest == "" {
+		return nil
+	}
+
+	manifestData, err := fetchBytes(reg.Manifest, opts)
+	if err != nil {
+		return fmt.Errorf("failed to fetch checksum manifest: %w", err)
+	}
+
+	if reg.Signed {
+		sig, err := fetchBytes(reg.Manifest+".sig", opts)
+		if err != nil {
+			return fmt.Errorf("failed to fetch manifest signature: %w", err)
+		}
+		if err := remote.VerifySignature(manifestData, sig, opts.TrustedKeys); err != nil {
+			return fmt.Errorf("registry '%s': %w", reg.Name, err)
+		}
+	}
+
+	manifest, err := remote.ParseManifest(manifestData)
+	if err != nil {
+		return fmt.Errorf("registry '%s': %w", reg.Name, err)
+	}
+	if err := manifest.Verify(name, archivePath); err != nil {
+		return fmt.Errorf("registry '%s': %w", reg.Name, err)
+	}
+	return nil
+}
+
+// archiveName returns the file name a manifest lists an archive URL under
+func archiveName(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil {
+		return path.Base(u.Path)
+	}
+	return path.Base(rawURL)
+}
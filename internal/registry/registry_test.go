@@ -0,0 +1,337 @@
+package registry
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"forge/internal/cache"
+	"forge/internal/config"
+	"forge/internal/remote"
+)
+
+// zipArchive builds a GitHub-style archive: prefix/<template>/template.yaml
+func zipArchive(t *testing.T, prefix string, templates map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, yamlContent := range templates {
+		w, err := zw.Create(prefix + name + "/template.yaml")
+		if err != nil {
+			t.Fatalf("zip Create error = %v", err)
+		}
+		if _, err := w.Write([]byte(yamlContent)); err != nil {
+			t.Fatalf("zip Write error = %v", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("zip Close error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+// serveRegistries starts a server with one zip archive per path
+func serveRegistries(t *testing.T, archives map[string][]byte) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := archives[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func testConfig(srv *httptest.Server) *config.Config {
+	return &config.Config{Registries: []config.Registry{
+		{Name: "official", URL: srv.URL + "/official.zip", Type: config.RegistryTypeZip},
+		{Name: "team", URL: srv.URL + "/team.zip", Type: config.RegistryTypeZip, Priority: 10},
+	}}
+}
+
+func readInstalled(t *testing.T, dir, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, name, "template.yaml"))
+	if err != nil {
+		t.Fatalf("template %s not installed: %v", name, err)
+	}
+	return string(data)
+}
+
+func TestParseRef(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Ref
+		wantErr bool
+	}{
+		{"python", Ref{Name: "python"}, false},
+		{"team/python", Ref{Registry: "team", Name: "python"}, false},
+		{"/python", Ref{}, true},
+		{"team/", Ref{}, true},
+		{"team/a/b", Ref{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseRef(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRef(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseRef(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPullByPriority(t *testing.T) {
+	srv := serveRegistries(t, map[string][]byte{
+		"/official.zip": zipArchive(t, "forge-templates-main/", map[string]string{
+			"python": "name: python\ndescription: official\n",
+			"go":     "name: go\n",
+		}),
+		"/team.zip": zipArchive(t, "team-templates-main/", map[string]string{
+			"python": "name: python\ndescription: team\n",
+		}),
+	})
+	cfg := testConfig(srv)
+	dest := t.TempDir()
+
+	// Highest priority registry wins for unqualified names
+	reg, _, err := Pull(cfg, Ref{Name: "python"}, dest, Options{})
+	if err != nil {
+		t.Fatalf("Pull() error = %v", err)
+	}
+	if reg.Name != "team" || !strings.Contains(readInstalled(t, dest, "python"), "team") {
+		t.Errorf("Pull(python) used registry %q, want team", reg.Name)
+	}
+
+	// Falls through to lower priority registries
+	reg, _, err = Pull(cfg, Ref{Name: "go"}, dest, Options{})
+	if err != nil {
+		t.Fatalf("Pull() error = %v", err)
+	}
+	if reg.Name != "official" {
+		t.Errorf("Pull(go) used registry %q, want official", reg.Name)
+	}
+
+	// Registry prefix selects explicitly
+	if _, _, err := Pull(cfg, Ref{Registry: "official", Name: "python"}, dest, Options{}); err != nil {
+		t.Fatalf("Pull() error = %v", err)
+	}
+	if !strings.Contains(readInstalled(t, dest, "python"), "official") {
+		t.Error("Pull(official/python) did not install the official template")
+	}
+}
+
+func TestPullNotFound(t *testing.T) {
+	srv := serveRegistries(t, map[string][]byte{
+		"/official.zip": zipArchive(t, "forge-templates-main/", map[string]string{"go": "name: go\n"}),
+		"/team.zip":     zipArchive(t, "team-templates-main/", map[string]string{"node": "name: node\n"}),
+	})
+	cfg := testConfig(srv)
+	dest := t.TempDir()
+
+	// An existing install must survive a failed lookup
+	if err := os.MkdirAll(filepath.Join(dest, "rust"), 0755); err != nil {
+		t.Fatalf("MkdirAll error = %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dest, "rust", "template.yaml"), []byte("name: rust\n"), 0644); err != nil {
+		t.Fatalf("WriteFile error = %v", err)
+	}
+
+	if _, _, err := Pull(cfg, Ref{Name: "rust"}, dest, Options{}); err == nil || !strings.Contains(err.Error(), "not found in any registry") {
+		t.Errorf("Pull(rust) error = %v, want not found in any registry", err)
+	}
+	readInstalled(t, dest, "rust")
+
+	if _, _, err := Pull(cfg, Ref{Registry: "missing", Name: "go"}, dest, Options{}); err == nil || !strings.Contains(err.Error(), "unknown registry") {
+		t.Errorf("Pull(missing/go) error = %v, want unknown registry", err)
+	}
+}
+
+func TestPullAll(t *testing.T) {
+	srv := serveRegistries(t, map[string][]byte{
+		"/official.zip": zipArchive(t, "forge-templates-main/", map[string]string{
+			"python": "name: python\ndescription: official\n",
+			"go":     "name: go\n",
+		}),
+		"/team.zip": zipArchive(t, "team-templates-main/", map[string]string{
+			"python": "name: python\ndescription: team\n",
+		}),
+	})
+	cfg := testConfig(srv)
+	dest := t.TempDir()
+
+	installed, err := PullAll(cfg.SortedRegistries(), dest, Options{})
+	if err != nil {
+		t.Fatalf("PullAll() error = %v", err)
+	}
+
+	got := map[string]string{}
+	for _, i := range installed {
+		got[i.Name] = i.Registry
+	}
+	if len(got) != 2 || got["python"] != "team" || got["go"] != "official" {
+		t.Errorf("PullAll() = %+v, want python from team and go from official", installed)
+	}
+	if !strings.Contains(readInstalled(t, dest, "python"), "team") {
+		t.Error("PullAll() left the lower priority python template installed")
+	}
+}
+
+func TestPullVerifiesManifestAndSignature(t *testing.T) {
+	archive := zipArchive(t, "team-templates-main/", map[string]string{"python": "name: python\n"})
+	sum := sha256.Sum256(archive)
+	manifest := remote.Manifest{"team.zip": hex.EncodeToString(sum[:])}.Bytes()
+
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherPriv, _ := ed25519.GenerateKey(nil)
+
+	srv := serveRegistries(t, map[string][]byte{
+		"/team.zip":              archive,
+		"/SHA256SUMS":            manifest,
+		"/SHA256SUMS.sig":        remote.SignManifest(manifest, priv),
+		"/bad/SHA256SUMS":        remote.Manifest{"team.zip": hex.EncodeToString(make([]byte, 32))}.Bytes(),
+		"/forged/SHA256SUMS":     manifest,
+		"/forged/SHA256SUMS.sig": remote.SignManifest(manifest, otherPriv),
+	})
+
+	reg := config.Registry{Name: "team", URL: srv.URL + "/team.zip", Type: config.RegistryTypeZip}
+	trusted := Options{TrustedKeys: []ed25519.PublicKey{pub}}
+
+	tests := []struct {
+		name     string
+		manifest string
+		signed   bool
+		opts     Options
+		wantErr  error
+	}{
+		{name: "no manifest", opts: trusted},
+		{name: "checksum ok", manifest: "/SHA256SUMS", opts: trusted},
+		{name: "signed ok", manifest: "/SHA256SUMS", signed: true, opts: trusted},
+		{name: "checksum mismatch", manifest: "/bad/SHA256SUMS", opts: trusted, wantErr: remote.ErrChecksumMismatch},
+		{name: "untrusted signature", manifest: "/forged/SHA256SUMS", signed: true, opts: trusted, wantErr: remote.ErrBadSignature},
+		{name: "no trusted keys", manifest: "/SHA256SUMS", signed: true, wantErr: remote.ErrBadSignature},
+		{name: "skip verify", manifest: "/bad/SHA256SUMS", signed: true, opts: Options{InsecureSkipVerify: true}},
+	}
+
+	for _, tt := range tests {
+		r := reg
+		if tt.manifest != "" {
+			r.Manifest = srv.URL + tt.manifest
+		}
+		r.Signed = tt.signed
+		cfg := &config.Config{Registries: []config.Registry{r}}
+
+		dest := t.TempDir()
+		_, _, err := Pull(cfg, Ref{Registry: "team", Name: "python"}, dest, tt.opts)
+		if tt.wantErr == nil {
+			if err != nil {
+				t.Errorf("%s: Pull() error = %v", tt.name, err)
+			}
+			continue
+		}
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: Pull() error = %v, want %v", tt.name, err, tt.wantErr)
+		}
+		if _, statErr := os.Stat(filepath.Join(dest, "python")); statErr == nil {
+			t.Errorf("%s: template installed despite failed verification", tt.name)
+		}
+	}
+}
+
+func TestPullOfflineFromCache(t *testing.T) {
+	srv := serveRegistries(t, map[string][]byte{
+		"/team.zip": zipArchive(t, "team-templates-main/", map[string]string{"python": "name: python\n"}),
+	})
+	cfg := &config.Config{Registries: []config.Registry{
+		{Name: "team", URL: srv.URL + "/team.zip", Type: config.RegistryTypeZip},
+	}}
+	opts := Options{Cache: cache.New(t.TempDir())}
+
+	offline := opts
+	offline.Offline = true
+	if _, _, err := Pull(cfg, Ref{Name: "python"}, t.TempDir(), offline); err == nil || !strings.Contains(err.Error(), remote.ErrNotCached.Error()) {
+		t.Fatalf("offline Pull() before caching error = %v, want %v", err, remote.ErrNotCached)
+	}
+
+	if _, _, err := Pull(cfg, Ref{Name: "python"}, t.TempDir(), opts); err != nil {
+		t.Fatalf("Pull() error = %v", err)
+	}
+	srv.Close()
+
+	dest := t.TempDir()
+	if _, _, err := Pull(cfg, Ref{Name: "python"}, dest, offline); err != nil {
+		t.Fatalf("offline Pull() error = %v", err)
+	}
+	readInstalled(t, dest, "python")
+}
+
+func TestPullRecordsSource(t *testing.T) {
+	// The archive claims a source of its own, which must not be kept
+	var spoofed bytes.Buffer
+	zw := zip.NewWriter(&spoofed)
+	for name, content := range map[string]string{
+		"team-templates-main/python/template.yaml":        "name: python\n",
+		"team-templates-main/python/" + remote.SourceFile: "source: ./templates/python\n",
+	} {
+		w, _ := zw.Create(name)
+		w.Write([]byte(content))
+	}
+	zw.Close()
+	entry, archive := indexedTemplate(t, "fastapi", fastapiYAML)
+	srv := serveRegistries(t, map[string][]byte{
+		"/team.zip":             spoofed.Bytes(),
+		"/index.json":           indexJSON(t, entry),
+		"/archives/fastapi.zip": archive,
+	})
+	cfg := &config.Config{Registries: []config.Registry{
+		{Name: "team", URL: srv.URL + "/team.zip", Type: config.RegistryTypeZip},
+		{Name: "internal", URL: srv.URL + "/index.json", Type: config.RegistryTypeIndex},
+	}}
+
+	tests := []struct {
+		name string
+		ref  Ref
+		want string
+	}{
+		{"zip", Ref{Registry: "team", Name: "python"}, "team/python"},
+		{"index", Ref{Registry: "internal", Name: "fastapi"}, "internal/fastapi"},
+	}
+	for _, tt := range tests {
+		dest := t.TempDir()
+		if _, _, err := Pull(cfg, tt.ref, dest, Options{}); err != nil {
+			t.Fatalf("%s: Pull() error = %v", tt.name, err)
+		}
+		info, err := remote.ReadSourceInfo(filepath.Join(dest, tt.ref.Name))
+		if err != nil || info == nil || info.Source != tt.want {
+			t.Errorf("%s: source info = %+v, %v, want %s", tt.name, info, err, tt.want)
+		}
+	}
+
+	dest := t.TempDir()
+	if _, err := PullAll(cfg.SortedRegistries(), dest, Options{}); err != nil {
+		t.Fatalf("PullAll() error = %v", err)
+	}
+	for _, name := range []string{"python", "fastapi"} {
+		if info, err := remote.ReadSourceInfo(filepath.Join(dest, name)); err != nil || info == nil {
+			t.Errorf("PullAll() left %s without source info (%v)", name, err)
+		}
+	}
+}
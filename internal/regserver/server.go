@@ -0,0 +1,210 @@
+package regserver
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"forge/internal/index"
+	"forge/internal/pack"
+)
+
+// maxUpload bounds the size of a publish request
+const maxUpload = 256 << 20
+
+// ChecksumsFile is the sha256sum manifest of all stored archives
+const ChecksumsFile = "SHA256SUMS"
+
+// Options configure the registry server
+type Options struct {
+	// Token, when set, must be sent as a bearer token to publish
+	Token string
+	// Logger receives one line per publish; nil disables logging
+	Logger *log.Logger
+}
+
+// Server serves a Store over HTTP:
+//
+//	GET  /index.json                     registry index (latest versions)
+//	GET  /SHA256SUMS                     checksums of all archives
+//	GET  /<name>-<version>.tar.gz        template archive
+//	GET  /<name>-<version>.manifest.json pack manifest
+//	POST /api/publish                    multipart upload of "manifest" and "archive"
+//
+// Every GET response carries a content-derived ETag, so clients can
+// revalidate with If-None-Match and get 304 Not Modified.
+type Server struct {
+	store *Store
+	opts  Options
+}
+
+// New returns a server for store
+func New(store *Store, opts Options) *Server {
+	return &Server{store: store, opts: opts}
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p := path.Clean("/" + r.URL.Path)
+	switch {
+	case p == "/"+index.PublishPath:
+		s.publish(w, r)
+	case r.Method != http.MethodGet && r.Method != http.MethodHead:
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	case p == "/"+index.FileName:
+		s.serveIndex(w, r)
+	case p == "/"+ChecksumsFile:
+		s.serveChecksums(w, r)
+	default:
+		s.serveFile(w, r, strings.TrimPrefix(p, "/"))
+	}
+}
+
+func (s *Server) serveIndex(w http.ResponseWriter, r *http.Request) {
+	idx, err := s.store.Index()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	data, err := idx.Bytes()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	serveBytes(w, r, index.FileName, "application/json", data)
+}
+
+func (s *Server) serveChecksums(w http.ResponseWriter, r *http.Request) {
+	sums, err := s.store.Checksums()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	serveBytes(w, r, ChecksumsFile, "text/plain; charset=utf-8", sums.Bytes())
+}
+
+// serveBytes serves generated content with an ETag of its SHA-256
+func serveBytes(w http.ResponseWriter, r *http.Request, name, contentType string, data []byte) {
+	sum := sha256.Sum256(data)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:])+`"`)
+	http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(data))
+}
+
+// serveFile serves archives and manifests; nothing else in the directory,
+// such as in-progress uploads, is exposed. Archives are tagged with the
+// SHA-256 their manifest records, which is also sent as X-Checksum-Sha256.
+func (s *Server) serveFile(w http.ResponseWriter, r *http.Request, name string) {
+	if strings.Contains(name, "/") || strings.HasPrefix(name, ".") {
+		http.NotFound(w, r)
+		return
+	}
+	filePath := filepath.Join(s.store.Dir(), name)
+
+	switch {
+	case strings.HasSuffix(name, pack.ManifestSuffix):
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		serveBytes(w, r, name, "application/json", data)
+	case strings.HasSuffix(name, ".tar.gz"):
+		m, err := pack.ReadManifest(pack.ManifestPath(filePath))
+		if err != nil || m.Archive != name {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("ETag", `"`+m.ArchiveSHA256+`"`)
+		w.Header().Set("X-Checksum-Sha256", m.ArchiveSHA256)
+		http.ServeFile(w, r, filePath)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) publish(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="forge"`)
+		http.Error(w, "publishing requires a valid token", http.StatusUnauthorized)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxUpload)
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		http.Error(w, "invalid upload: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	manifest, err := formFile(r, "manifest")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	manifestData, err := io.ReadAll(io.LimitReader(manifest, 1<<20))
+	manifest.Close()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	archive, err := formFile(r, "archive")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer archive.Close()
+
+	m, err := s.store.Publish(manifestData, archive)
+	switch {
+	case errors.Is(err, ErrExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, ErrInvalid):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if s.opts.Logger != nil {
+		s.opts.Logger.Printf("published %s %s", m.Name, m.Version)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(m.Entry)
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	if s.opts.Token == "" {
+		return true
+	}
+	got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(got), []byte(s.opts.Token)) == 1
+}
+
+func formFile(r *http.Request, field string) (io.ReadCloser, error) {
+	f, _, err := r.FormFile(field)
+	if err != nil {
+		return nil, errors.New("missing form file " + field)
+	}
+	return f, nil
+}
@@ -0,0 +1,228 @@
+package regserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"forge/internal/index"
+	"forge/internal/pack"
+	"forge/internal/remote"
+)
+
+// packTemplate packs a minimal template with the given version
+func packTemplate(t *testing.T, name, version string) (archive string, m *pack.Manifest) {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), name)
+	os.MkdirAll(dir, 0755)
+	yaml := "name: " + name + "\nversion: " + version + "\ncommands:\n  - cmd: [\"git\", \"init\"]\nfiles:\n  copy: []\n"
+	if err := os.WriteFile(filepath.Join(dir, "template.yaml"), []byte(yaml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out := t.TempDir()
+	m, err := pack.Pack(dir, out)
+	if err != nil {
+		t.Fatalf("Pack() error = %v", err)
+	}
+	return filepath.Join(out, m.Archive), m
+}
+
+func upload(t *testing.T, srv *httptest.Server, token string, manifest []byte, archivePath string) *http.Response {
+	t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	w, _ := mw.CreateFormFile("manifest", "manifest.json")
+	w.Write(manifest)
+	data, err := os.ReadFile(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, _ = mw.CreateFormFile("archive", filepath.Base(archivePath))
+	w.Write(data)
+	mw.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/"+index.PublishPath, &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp
+}
+
+func newServer(t *testing.T, opts Options) (*httptest.Server, *Store) {
+	t.Helper()
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(New(store, opts))
+	t.Cleanup(srv.Close)
+	return srv, store
+}
+
+func manifestJSON(t *testing.T, m *pack.Manifest) []byte {
+	t.Helper()
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestPublishAndServe(t *testing.T) {
+	srv, _ := newServer(t, Options{})
+
+	for _, v := range []string{"1.0.0", "1.10.0", "1.2.0"} {
+		archive, m := packTemplate(t, "node", v)
+		if resp := upload(t, srv, "", manifestJSON(t, m), archive); resp.StatusCode != http.StatusCreated {
+			t.Fatalf("publish %s: status %d", v, resp.StatusCode)
+		}
+	}
+
+	resp, err := http.Get(srv.URL + "/index.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var idx index.Index
+	if err := json.NewDecoder(resp.Body).Decode(&idx); err != nil {
+		t.Fatal(err)
+	}
+	if len(idx.Templates) != 1 || idx.Templates[0].Version != "1.10.0" {
+		t.Fatalf("index = %+v, want node at the highest version 1.10.0", idx.Templates)
+	}
+	if idx.Templates[0].URL != "node-1.10.0.tar.gz" || idx.Templates[0].Requires[0] != "git" {
+		t.Errorf("entry = %+v", idx.Templates[0])
+	}
+
+	archive, err := http.Get(srv.URL + "/node-1.2.0.tar.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive.Body.Close()
+	if archive.StatusCode != http.StatusOK {
+		t.Errorf("archive download status %d", archive.StatusCode)
+	}
+}
+
+func TestPublishRejections(t *testing.T) {
+	srv, store := newServer(t, Options{Token: "s3cret"})
+	archive, m := packTemplate(t, "node", "1.0.0")
+
+	if resp := upload(t, srv, "", manifestJSON(t, m), archive); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("publish without token: status %d, want 401", resp.StatusCode)
+	}
+	if resp := upload(t, srv, "s3cret", manifestJSON(t, m), archive); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("publish: status %d, want 201", resp.StatusCode)
+	}
+	if resp := upload(t, srv, "s3cret", manifestJSON(t, m), archive); resp.StatusCode != http.StatusConflict {
+		t.Errorf("republish: status %d, want 409", resp.StatusCode)
+	}
+
+	_, other := packTemplate(t, "node", "2.0.0")
+	if resp := upload(t, srv, "s3cret", manifestJSON(t, other), archive); resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("archive not matching its manifest: status %d, want 422", resp.StatusCode)
+	}
+
+	evil := *m
+	evil.Archive = "../escape.tar.gz"
+	if resp := upload(t, srv, "s3cret", manifestJSON(t, &evil), archive); resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("non-canonical archive name: status %d, want 422", resp.StatusCode)
+	}
+
+	manifests, err := store.Manifests()
+	if err != nil || len(manifests) != 1 {
+		t.Errorf("store has %d versions (%v), want only the accepted one", len(manifests), err)
+	}
+}
+
+func TestServeOnlyPackages(t *testing.T) {
+	srv, store := newServer(t, Options{})
+	os.WriteFile(filepath.Join(store.Dir(), "notes.txt"), []byte("private"), 0644)
+
+	for _, p := range []string{"/notes.txt", "/../etc/passwd.tar.gz", "/.upload-1.tar.gz"} {
+		resp, err := http.Get(srv.URL + p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("GET %s: status %d, want 404", p, resp.StatusCode)
+		}
+	}
+
+	resp, err := http.Post(srv.URL+"/index.json", "text/plain", strings.NewReader("x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("POST /index.json: status %d, want 405", resp.StatusCode)
+	}
+}
+
+func TestETagsAndChecksums(t *testing.T) {
+	srv, _ := newServer(t, Options{})
+	archive, m := packTemplate(t, "node", "1.0.0")
+	if resp := upload(t, srv, "", manifestJSON(t, m), archive); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("publish: status %d", resp.StatusCode)
+	}
+
+	for _, p := range []string{"/index.json", "/" + ChecksumsFile, "/node-1.0.0.tar.gz", "/node-1.0.0.manifest.json"} {
+		resp, err := http.Get(srv.URL + p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		etag := resp.Header.Get("ETag")
+		if resp.StatusCode != http.StatusOK || etag == "" {
+			t.Fatalf("GET %s: status %d, ETag %q", p, resp.StatusCode, etag)
+		}
+
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+p, nil)
+		req.Header.Set("If-None-Match", etag)
+		resp, err = http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotModified {
+			t.Errorf("GET %s with If-None-Match: status %d, want 304", p, resp.StatusCode)
+		}
+	}
+
+	resp, err := http.Get(srv.URL + "/node-1.0.0.tar.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got := resp.Header.Get("X-Checksum-Sha256"); got != m.ArchiveSHA256 {
+		t.Errorf("X-Checksum-Sha256 = %q, want %q", got, m.ArchiveSHA256)
+	}
+
+	resp, err = http.Get(srv.URL + "/" + ChecksumsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	sums, err := remote.ParseManifest(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sums.Verify(m.Archive, archive); err != nil {
+		t.Errorf("SHA256SUMS does not verify the archive: %v", err)
+	}
+}
@@ -0,0 +1,214 @@
+package regserver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"forge/internal/index"
+	"forge/internal/pack"
+	"forge/internal/remote"
+	"forge/internal/scaffold"
+	"forge/internal/template"
+	"forge/internal/version"
+)
+
+// ErrExists is returned when publishing a version that is already stored
+var ErrExists = errors.New("template version already published")
+
+// ErrInvalid wraps publish requests rejected for their content
+var ErrInvalid = errors.New("invalid template package")
+
+// Store keeps packed templates in a flat directory: each version is a
+// name-version.tar.gz archive next to its name-version.manifest.json, as
+// written by forge pack
+type Store struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewStore returns a store in dir, creating the directory if needed
+func NewStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create registry directory: %w", err)
+	}
+	return &Store{dir: dir}, nil
+}
+
+// Dir returns the directory the store serves
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Manifests returns the manifest of every stored version whose archive is
+// present, sorted by name and then version
+func (s *Store) Manifests() ([]*pack.Manifest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.manifests()
+}
+
+func (s *Store) manifests() ([]*pack.Manifest, error) {
+	files, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read registry directory: %w", err)
+	}
+
+	var manifests []*pack.Manifest
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), pack.ManifestSuffix) {
+			continue
+		}
+		m, err := pack.ReadManifest(filepath.Join(s.dir, f.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name(), err)
+		}
+		if _, err := os.Stat(filepath.Join(s.dir, m.Archive)); err != nil {
+			continue
+		}
+		manifests = append(manifests, m)
+	}
+
+	sort.Slice(manifests, func(i, j int) bool {
+		if manifests[i].Name != manifests[j].Name {
+			return manifests[i].Name < manifests[j].Name
+		}
+		return versionLess(manifests[i].Version, manifests[j].Version)
+	})
+	return manifests, nil
+}
+
+// Index returns the registry index, listing the highest version of each
+// template with archive URLs relative to the index
+func (s *Store) Index() (*index.Index, error) {
+	manifests, err := s.Manifests()
+	if err != nil {
+		return nil, err
+	}
+
+	idx := &index.Index{}
+	for _, m := range manifests {
+		// Sorted by version, so later entries replace earlier ones
+		e := m.Entry
+		e.URL = m.Archive
+		idx.Set(e)
+	}
+	return idx, nil
+}
+
+// Checksums returns a sha256sum manifest of the index and every stored
+// archive, usable as a registry's checksum manifest
+func (s *Store) Checksums() (remote.Manifest, error) {
+	manifests, err := s.Manifests()
+	if err != nil {
+		return nil, err
+	}
+	idx, err := s.Index()
+	if err != nil {
+		return nil, err
+	}
+	data, err := idx.Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	sums := remote.Manifest{index.FileName: hex.EncodeToString(sum[:])}
+	for _, m := range manifests {
+		sums[m.Archive] = m.ArchiveSHA256
+	}
+	return sums, nil
+}
+
+// Publish verifies an uploaded archive against its manifest and stores
+// both. Existing versions are never overwritten.
+func (s *Store) Publish(manifestData []byte, archive io.Reader) (*pack.Manifest, error) {
+	m, err := pack.ParseManifest(manifestData)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	if err := scaffold.ValidateName(m.Name); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	if err := version.Validate(m.Version); err != nil {
+		return nil, fmt.Errorf("%w: version %q: %v", ErrInvalid, m.Version, err)
+	}
+	// The archive name becomes a file name, so it must be the canonical one
+	if m.Archive != pack.ArchiveName(m.Name, m.Version) {
+		return nil, fmt.Errorf("%w: archive must be named %s", ErrInvalid, pack.ArchiveName(m.Name, m.Version))
+	}
+
+	tmp, err := os.CreateTemp(s.dir, ".upload-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to store upload: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = io.Copy(tmp, archive)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to store upload: %w", err)
+	}
+
+	if err := m.Verify(tmp.Name()); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	if err := checkTemplateName(tmp.Name(), m.Name); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	archivePath := filepath.Join(s.dir, m.Archive)
+	if _, err := os.Stat(archivePath); err == nil {
+		return nil, fmt.Errorf("%w: %s %s", ErrExists, m.Name, m.Version)
+	}
+	m.URL = m.Archive
+	if err := m.Write(pack.ManifestPath(archivePath)); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp.Name(), archivePath); err != nil {
+		os.Remove(pack.ManifestPath(archivePath))
+		return nil, fmt.Errorf("failed to store archive: %w", err)
+	}
+	return m, nil
+}
+
+// checkTemplateName makes sure the packed template.yaml declares name
+func checkTemplateName(archivePath, name string) error {
+	tmp, err := os.MkdirTemp("", "forge-publish-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	dir := filepath.Join(tmp, name)
+	if err := remote.ExtractTemplate(archivePath, name, dir); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	tmpl, err := template.Load(dir)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	if tmpl.Name != name {
+		return fmt.Errorf("%w: template.yaml declares name %q, manifest %q", ErrInvalid, tmpl.Name, name)
+	}
+	return nil
+}
+
+// versionLess orders semantic versions, falling back to string order
+func versionLess(a, b string) bool {
+	if c, err := version.Compare(a, b); err == nil {
+		return c < 0
+	}
+	return a < b
+}
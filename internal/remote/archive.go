@@ -0,0 +1,328 @@
+package remote
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Limits bound what extracting a single archive may produce, protecting
+// against archive bombs
+type Limits struct {
+	MaxBytes   int64 // total uncompressed bytes
+	MaxEntries int   // files and directories
+}
+
+// DefaultLimits returns the extraction limits used unless configured otherwise
+func DefaultLimits() Limits {
+	return Limits{MaxBytes: 512 << 20, MaxEntries: 20000}
+}
+
+// ExtractLimits are the limits applied when opening archives
+var ExtractLimits = DefaultLimits()
+
+// ErrArchiveTooLarge is returned when an archive exceeds ExtractLimits
+var ErrArchiveTooLarge = errors.New("archive exceeds extraction limits")
+
+// archiveEntry is a file or directory inside a template archive or directory
+type archiveEntry struct {
+	Name string // slash-separated; directories end in "/"
+	Mode os.FileMode
+	open func() (io.ReadCloser, error)
+}
+
+func (e archiveEntry) isDir() bool {
+	return strings.HasSuffix(e.Name, "/")
+}
+
+// archive is a uniform view over zip files, tar(.gz) files and directories
+type archive struct {
+	entries []archiveEntry
+	close   func() error
+	limits  Limits
+	written int64 // bytes extracted so far, checked against limits.MaxBytes
+}
+
+// add appends an entry after checking its name and the entry limit
+func (a *archive) add(e archiveEntry) error {
+	if err := checkEntryName(e.Name); err != nil {
+		return err
+	}
+	if a.limits.MaxEntries > 0 && len(a.entries) >= a.limits.MaxEntries {
+		return fmt.Errorf("%w: more than %d entries", ErrArchiveTooLarge, a.limits.MaxEntries)
+	}
+	a.entries = append(a.entries, e)
+	return nil
+}
+
+// checkEntryName rejects entry names that could escape the extraction
+// directory: absolute paths, drive letters and ".." elements. Backslashes
+// count as separators since they are on Windows.
+func checkEntryName(name string) error {
+	normalized := strings.ReplaceAll(name, "\\", "/")
+	if strings.HasPrefix(normalized, "/") || filepath.VolumeName(name) != "" ||
+		(len(normalized) >= 2 && normalized[1] == ':') {
+		return fmt.Errorf("unsafe path in archive: %s", name)
+	}
+	for _, elem := range strings.Split(normalized, "/") {
+		if elem == ".." {
+			return fmt.Errorf("unsafe path in archive: %s", name)
+		}
+	}
+	return nil
+}
+
+// safeJoin joins a slash-separated relative path onto dir, failing if the
+// result would lie outside dir
+func safeJoin(dir, rel string) (string, error) {
+	if err := checkEntryName(rel); err != nil {
+		return "", err
+	}
+	target := filepath.Join(dir, filepath.FromSlash(rel))
+	r, err := filepath.Rel(dir, target)
+	if err != nil || r == ".." || strings.HasPrefix(r, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("unsafe path in archive: %s", rel)
+	}
+	return target, nil
+}
+
+// copyLimited copies src to dst, adding the bytes copied to *used and
+// failing once the total exceeds max (zero means no limit)
+func copyLimited(dst io.Writer, src io.Reader, max int64, used *int64) error {
+	if max <= 0 {
+		n, err := io.Copy(dst, src)
+		*used += n
+		return err
+	}
+	remaining := max - *used
+	n, err := io.Copy(dst, io.LimitReader(src, remaining+1))
+	*used += n
+	if err != nil {
+		return err
+	}
+	if n > remaining {
+		return fmt.Errorf("%w: more than %d bytes", ErrArchiveTooLarge, max)
+	}
+	return nil
+}
+
+func (a *archive) Close() error {
+	if a.close == nil {
+		return nil
+	}
+	return a.close()
+}
+
+// openArchive opens a zip, tar or gzip-compressed tar file. The format is
+// detected from the file contents, not its extension.
+func openArchive(archivePath string) (*archive, error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer f.Close()
+
+	magic := make([]byte, 4)
+	n, _ := io.ReadFull(f, magic)
+	magic = magic[:n]
+
+	switch {
+	case bytes.HasPrefix(magic, []byte("PK")):
+		return openZip(archivePath)
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+		gz, err := gzip.NewReader(bufio.NewReader(f))
+		if err != nil {
+			return nil, fmt.Errorf("failed to open gzip: %w", err)
+		}
+		defer gz.Close()
+		return readTar(gz)
+	default:
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+		return readTar(f)
+	}
+}
+
+func openZip(archivePath string) (*archive, error) {
+	z, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open zip: %w", err)
+	}
+
+	a := &archive{close: z.Close, limits: ExtractLimits}
+	for _, f := range z.File {
+		if f.Mode()&os.ModeSymlink != 0 {
+			z.Close()
+			return nil, fmt.Errorf("unsupported symlink in archive: %s", f.Name)
+		}
+		err := a.add(archiveEntry{
+			Name: f.Name,
+			Mode: f.Mode(),
+			open: f.Open,
+		})
+		if err != nil {
+			z.Close()
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// readTar reads a tar stream into memory; tar has no index to open entries
+// lazily. The byte limit is enforced while reading.
+func readTar(r io.Reader) (*archive, error) {
+	tr := tar.NewReader(r)
+	a := &archive{limits: ExtractLimits}
+	var read int64
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tar: %w", err)
+		}
+
+		name := strings.TrimPrefix(hdr.Name, "./")
+		if name == "" {
+			continue
+		}
+
+		var entry archiveEntry
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if !strings.HasSuffix(name, "/") {
+				name += "/"
+			}
+			entry = archiveEntry{Name: name, Mode: os.ModeDir | 0755}
+		case tar.TypeReg:
+			var buf bytes.Buffer
+			if err := copyLimited(&buf, tr, a.limits.MaxBytes, &read); err != nil {
+				return nil, fmt.Errorf("failed to read tar entry %s: %w", hdr.Name, err)
+			}
+			data := buf.Bytes()
+			entry = archiveEntry{
+				Name: name,
+				Mode: hdr.FileInfo().Mode(),
+				open: func() (io.ReadCloser, error) {
+					return io.NopCloser(bytes.NewReader(data)), nil
+				},
+			}
+		case tar.TypeSymlink, tar.TypeLink:
+			return nil, fmt.Errorf("unsupported link in archive: %s", hdr.Name)
+		default:
+			// Devices, FIFOs and extended headers are not part of templates
+			continue
+		}
+		if err := a.add(entry); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// openDir presents a directory tree as an archive, skipping .git metadata
+func openDir(dir string) (*archive, error) {
+	a := &archive{limits: ExtractLimits}
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		name := filepath.ToSlash(rel)
+
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return a.add(archiveEntry{Name: name + "/", Mode: info.Mode()})
+		}
+		if !info.Mode().IsRegular() {
+			// Symlinks could point outside the directory; skip them
+			return nil
+		}
+
+		return a.add(archiveEntry{
+			Name: name,
+			Mode: info.Mode(),
+			open: func() (io.ReadCloser, error) { return os.Open(p) },
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+	return a, nil
+}
+
+// templateRoots returns the prefixes ("" or "dir/") of directories in the
+// archive containing template.yaml, ignoring any nested inside another
+// template
+func (a *archive) templateRoots() []string {
+	var roots []string
+	for _, e := range a.entries {
+		if e.isDir() || path.Base(e.Name) != "template.yaml" {
+			continue
+		}
+		dir := path.Dir(e.Name)
+		prefix := ""
+		if dir != "." {
+			prefix = dir + "/"
+		}
+		roots = append(roots, prefix)
+	}
+
+	var outer []string
+	for _, r := range roots {
+		nested := false
+		for _, other := range roots {
+			if other != r && strings.HasPrefix(r, other) {
+				nested = true
+				break
+			}
+		}
+		if !nested {
+			outer = append(outer, r)
+		}
+	}
+	return outer
+}
+
+// readFile returns the contents of the named entry, which may be no larger
+// than the archive's byte limit
+func (a *archive) readFile(name string) ([]byte, error) {
+	for _, e := range a.entries {
+		if e.Name == name && !e.isDir() {
+			rc, err := e.open()
+			if err != nil {
+				return nil, err
+			}
+			defer rc.Close()
+			var buf bytes.Buffer
+			var read int64
+			if err := copyLimited(&buf, rc, a.limits.MaxBytes, &read); err != nil {
+				return nil, err
+			}
+			return buf.Bytes(), nil
+		}
+	}
+	return nil, os.ErrNotExist
+}
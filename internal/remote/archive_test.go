@@ -0,0 +1,229 @@
+package remote
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// zipEntry is a fixture entry; Mode lets tests mark symlinks and executables
+type zipEntry struct {
+	Name    string
+	Mode    os.FileMode
+	Content string
+}
+
+func writeZipEntries(t *testing.T, entries []zipEntry) string {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		hdr := &zip.FileHeader{Name: e.Name, Method: zip.Deflate}
+		if e.Mode != 0 {
+			hdr.SetMode(e.Mode)
+		}
+		w, err := zw.CreateHeader(hdr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(e.Content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "fixture.zip")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func writeTarHeaders(t *testing.T, headers []*tar.Header) string {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, hdr := range headers {
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Size > 0 {
+			if _, err := tw.Write(bytes.Repeat([]byte("x"), int(hdr.Size))); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "fixture.tar")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// withLimits sets ExtractLimits for the duration of a test
+func withLimits(t *testing.T, l Limits) {
+	t.Helper()
+	old := ExtractLimits
+	ExtractLimits = l
+	t.Cleanup(func() { ExtractLimits = old })
+}
+
+func TestZipSlipRejected(t *testing.T) {
+	for _, name := range []string{
+		"repo-main/python/../../../evil.txt",
+		"../evil.txt",
+		"/etc/evil.txt",
+		"repo-main/python/..\\..\\evil.txt",
+		"C:/evil.txt",
+	} {
+		src := writeZipEntries(t, []zipEntry{
+			{Name: "repo-main/python/template.yaml", Content: "name: python\n"},
+			{Name: name, Content: "pwned"},
+		})
+		parent := filepath.Join(t.TempDir(), "templates")
+
+		err := InstallSingleTemplate(src, "python", parent)
+		if err == nil || !strings.Contains(err.Error(), "unsafe path") {
+			t.Errorf("entry %q: InstallSingleTemplate error = %v, want unsafe path", name, err)
+		}
+		if _, err := os.Stat(filepath.Join(filepath.Dir(parent), "evil.txt")); err == nil {
+			t.Errorf("entry %q escaped the destination", name)
+		}
+	}
+}
+
+func TestTarSlipRejected(t *testing.T) {
+	for _, name := range []string{"../evil.txt", "/tmp/evil.txt", "python/../../evil.txt"} {
+		src := writeTarHeaders(t, []*tar.Header{
+			{Name: "python/template.yaml", Mode: 0644, Size: 4, Typeflag: tar.TypeReg},
+			{Name: name, Mode: 0644, Size: 5, Typeflag: tar.TypeReg},
+		})
+		if _, err := InstallLocal(src, t.TempDir()); err == nil || !strings.Contains(err.Error(), "unsafe path") {
+			t.Errorf("entry %q: InstallLocal error = %v, want unsafe path", name, err)
+		}
+	}
+}
+
+func TestSymlinkEntriesRejected(t *testing.T) {
+	src := writeZipEntries(t, []zipEntry{
+		{Name: "repo-main/python/template.yaml", Content: "name: python\n"},
+		{Name: "repo-main/python/link", Mode: os.ModeSymlink | 0777, Content: "/etc/passwd"},
+	})
+	if err := InstallSingleTemplate(src, "python", t.TempDir()); err == nil || !strings.Contains(err.Error(), "symlink") {
+		t.Errorf("zip symlink: error = %v, want symlink rejection", err)
+	}
+
+	tarSrc := writeTarHeaders(t, []*tar.Header{
+		{Name: "python/template.yaml", Mode: 0644, Size: 4, Typeflag: tar.TypeReg},
+		{Name: "python/link", Linkname: "/etc/passwd", Typeflag: tar.TypeSymlink},
+	})
+	if _, err := InstallLocal(tarSrc, t.TempDir()); err == nil || !strings.Contains(err.Error(), "link") {
+		t.Errorf("tar symlink: error = %v, want link rejection", err)
+	}
+}
+
+func TestArchiveByteLimit(t *testing.T) {
+	withLimits(t, Limits{MaxBytes: 1024, MaxEntries: 100})
+
+	// 1 MiB of zeros compresses to a few KiB
+	src := writeZipEntries(t, []zipEntry{
+		{Name: "repo-main/python/template.yaml", Content: "name: python\n"},
+		{Name: "repo-main/python/bomb.bin", Content: strings.Repeat("\x00", 1<<20)},
+	})
+	parent := t.TempDir()
+	err := InstallSingleTemplate(src, "python", parent)
+	if !errors.Is(err, ErrArchiveTooLarge) {
+		t.Fatalf("zip bomb: error = %v, want ErrArchiveTooLarge", err)
+	}
+	if _, err := os.Stat(filepath.Join(parent, "python")); !os.IsNotExist(err) {
+		t.Error("partial extraction left behind")
+	}
+
+	tarSrc := writeTarHeaders(t, []*tar.Header{
+		{Name: "python/template.yaml", Mode: 0644, Size: 4, Typeflag: tar.TypeReg},
+		{Name: "python/big.bin", Mode: 0644, Size: 4096, Typeflag: tar.TypeReg},
+	})
+	if _, err := InstallLocal(tarSrc, t.TempDir()); !errors.Is(err, ErrArchiveTooLarge) {
+		t.Errorf("tar bomb: error = %v, want ErrArchiveTooLarge", err)
+	}
+}
+
+func TestReadFileLimited(t *testing.T) {
+	withLimits(t, Limits{MaxBytes: 1024, MaxEntries: 100})
+
+	src := writeZipEntries(t, []zipEntry{{Name: "template.yaml", Content: strings.Repeat("#", 4096)}})
+	a, err := openArchive(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	if _, err := a.readFile("template.yaml"); !errors.Is(err, ErrArchiveTooLarge) {
+		t.Errorf("readFile() error = %v, want ErrArchiveTooLarge", err)
+	}
+}
+
+func TestArchiveEntryLimit(t *testing.T) {
+	withLimits(t, Limits{MaxBytes: 1 << 20, MaxEntries: 3})
+
+	entries := []zipEntry{{Name: "repo-main/python/template.yaml", Content: "name: python\n"}}
+	for i := 0; i < 5; i++ {
+		entries = append(entries, zipEntry{Name: "repo-main/python/" + string(rune('a'+i)), Content: "x"})
+	}
+	src := writeZipEntries(t, entries)
+
+	if err := InstallSingleTemplate(src, "python", t.TempDir()); !errors.Is(err, ErrArchiveTooLarge) {
+		t.Errorf("error = %v, want ErrArchiveTooLarge", err)
+	}
+}
+
+func TestExecutableBitsPreserved(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are not preserved on Windows")
+	}
+
+	src := writeZipEntries(t, []zipEntry{
+		{Name: "repo-main/python/template.yaml", Mode: 0644, Content: "name: python\n"},
+		{Name: "repo-main/python/files/run.sh", Mode: 0755, Content: "#!/bin/sh\n"},
+	})
+	parent := t.TempDir()
+	if err := InstallSingleTemplate(src, "python", parent); err != nil {
+		t.Fatalf("InstallSingleTemplate error: %v", err)
+	}
+
+	info, err := os.Stat(filepath.Join(parent, "python", "files", "run.sh"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm()&0100 == 0 {
+		t.Errorf("run.sh mode = %v, want executable", info.Mode())
+	}
+	info, err = os.Stat(filepath.Join(parent, "python", "template.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm()&0111 != 0 {
+		t.Errorf("template.yaml mode = %v, want not executable", info.Mode())
+	}
+}
+
+func TestSafeJoin(t *testing.T) {
+	dir := t.TempDir()
+	if got, err := safeJoin(dir, "a/b.txt"); err != nil || got != filepath.Join(dir, "a", "b.txt") {
+		t.Errorf("safeJoin(a/b.txt) = %q, %v", got, err)
+	}
+	for _, rel := range []string{"../x", "a/../../x", "/x", "a\\..\\..\\x", "C:\\x"} {
+		if _, err := safeJoin(dir, rel); err == nil {
+			t.Errorf("safeJoin(%q) expected error", rel)
+		}
+	}
+}
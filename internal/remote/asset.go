@@ -0,0 +1,203 @@
+package remote
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"forge/internal/version"
+)
+
+// Formats a forge binary may be published in
+const (
+	FormatBinary = "binary"
+	FormatZip    = "zip"
+	FormatTarGz  = "tar.gz"
+)
+
+// ErrNoBinaryAsset is returned when a release has no forge binary for the
+// requested platform
+var ErrNoBinaryAsset = errors.New("no forge binary for this platform")
+
+// BinaryAsset is the release asset holding the forge binary for a platform
+type BinaryAsset struct {
+	GitHubAsset
+	Format string
+}
+
+// assetSuffixes map file name endings to formats, most preferred first
+var assetSuffixes = []struct{ suffix, format string }{
+	{".exe", FormatBinary},
+	{".tar.gz", FormatTarGz},
+	{".tgz", FormatTarGz},
+	{".zip", FormatZip},
+}
+
+// osNames and archNames list the spellings accepted in asset names for each
+// GOOS and GOARCH
+var (
+	osNames = map[string][]string{
+		"darwin":  {"darwin", "macos", "osx"},
+		"linux":   {"linux"},
+		"windows": {"windows", "win"},
+	}
+	archNames = map[string][]string{
+		"amd64": {"amd64", "x86_64", "x64"},
+		"arm64": {"arm64", "aarch64"},
+		"386":   {"386", "i386", "x86"},
+		"arm":   {"arm", "armv7"},
+	}
+)
+
+// legacyAssets are the names used before releases were built per
+// architecture; they only ever held amd64 binaries
+var legacyAssets = map[string]string{
+	"darwin":  "forge-darwin",
+	"linux":   "forge-linux",
+	"windows": "forge.exe",
+}
+
+// BinaryName returns the file name of the forge executable on goos
+func BinaryName(goos string) string {
+	if goos == "windows" {
+		return "forge.exe"
+	}
+	return "forge"
+}
+
+// SelectBinaryAsset picks the asset holding the forge binary for goos and
+// goarch. Assets are named forge_<os>_<arch>, with "-" also accepted as the
+// separator, optionally with a version in between, and either a bare binary
+// (.exe on Windows) or packed as .zip, .tar.gz or .tgz; aliases such as
+// x86_64 and aarch64 are understood. A plain binary is preferred over
+// archives. Releases that only publish the legacy forge-linux, forge-darwin
+// and forge.exe assets are used on amd64.
+func SelectBinaryAsset(release *GitHubRelease, goos, goarch string) (BinaryAsset, error) {
+	var best BinaryAsset
+	bestRank := len(assetSuffixes)
+	for _, a := range release.Assets {
+		format, rank, ok := matchAsset(a.Name, goos, goarch)
+		if ok && rank < bestRank {
+			best, bestRank = BinaryAsset{GitHubAsset: a, Format: format}, rank
+		}
+	}
+	if bestRank < len(assetSuffixes) {
+		return best, nil
+	}
+
+	if goarch == "amd64" {
+		if a, ok := release.Asset(legacyAssets[goos]); ok {
+			return BinaryAsset{GitHubAsset: a, Format: FormatBinary}, nil
+		}
+	}
+
+	available := "none"
+	if len(release.Assets) > 0 {
+		names := make([]string, len(release.Assets))
+		for i, a := range release.Assets {
+			names[i] = a.Name
+		}
+		available = strings.Join(names, ", ")
+	}
+	return BinaryAsset{}, fmt.Errorf("%w: release %s has no asset for %s/%s (available: %s)",
+		ErrNoBinaryAsset, release.TagName, goos, goarch, available)
+}
+
+// matchAsset reports whether name follows the asset naming convention for
+// goos/goarch, returning its format and preference rank. Only "forge", an
+// optional version, the OS and the architecture may appear in the name, so
+// companion files such as forge_linux_amd64.sig or forge_linux_amd64_sbom.json
+// are never taken for the binary. A bare binary ends in .exe on Windows and
+// has no extension elsewhere.
+func matchAsset(name, goos, goarch string) (format string, rank int, ok bool) {
+	lower := strings.ToLower(name)
+	for i, s := range assetSuffixes {
+		if strings.HasSuffix(lower, s.suffix) {
+			lower = strings.TrimSuffix(lower, s.suffix)
+			format, rank = s.format, i
+			break
+		}
+	}
+	switch {
+	case format == FormatBinary && goos != "windows":
+		return "", 0, false
+	case format == "" && goos == "windows":
+		return "", 0, false
+	case format == "":
+		format, rank = FormatBinary, 0
+	}
+
+	// x86_64 would otherwise be split at its underscore
+	lower = strings.ReplaceAll(lower, "x86_64", "amd64")
+	fields := strings.FieldsFunc(lower, func(r rune) bool { return r == '_' || r == '-' })
+	if len(fields) < 3 || fields[0] != "forge" {
+		return "", 0, false
+	}
+
+	// A pre-release version such as 1.2.0-beta.1 spans several fields
+	n := len(fields)
+	if n > 3 {
+		if _, err := version.Parse(strings.Join(fields[1:n-2], "-")); err != nil {
+			return "", 0, false
+		}
+	}
+	osName, arch := fields[n-2], fields[n-1]
+	if osName != goos && !contains(osNames[goos], osName) {
+		return "", 0, false
+	}
+	if arch != goarch && !contains(archNames[goarch], arch) {
+		return "", 0, false
+	}
+	return format, rank, true
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ExtractBinary copies the file called name out of a zip or tar(.gz)
+// archive to dest, making it executable. The shallowest match wins, so
+// both forge and forge_linux_amd64/forge layouts work.
+func ExtractBinary(archivePath, name, dest string) error {
+	a, err := openArchive(archivePath)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	var found *archiveEntry
+	for i, e := range a.entries {
+		if e.isDir() || path.Base(e.Name) != name {
+			continue
+		}
+		if found == nil || strings.Count(e.Name, "/") < strings.Count(found.Name, "/") {
+			found = &a.entries[i]
+		}
+	}
+	if found == nil {
+		return fmt.Errorf("archive does not contain %s", name)
+	}
+
+	rc, err := found.open()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", found.Name, err)
+	}
+	defer rc.Close()
+
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", dest, err)
+	}
+	if err := copyLimited(out, rc, a.limits.MaxBytes, &a.written); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to extract %s: %w", found.Name, err)
+	}
+	return out.Close()
+}
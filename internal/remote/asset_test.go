@@ -0,0 +1,121 @@
+package remote
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func releaseWith(names ...string) *GitHubRelease {
+	r := &GitHubRelease{TagName: "v1.2.0"}
+	for _, n := range names {
+		r.Assets = append(r.Assets, GitHubAsset{Name: n, DownloadURL: "https://example.com/" + n})
+	}
+	return r
+}
+
+func TestSelectBinaryAsset(t *testing.T) {
+	release := releaseWith(
+		"checksums.txt",
+		"forge_1.2.0_linux_x86_64.tar.gz",
+		"forge_1.2.0_linux_arm64.tar.gz",
+		"forge_1.2.0_linux_arm64.tar.gz.sig",
+		"forge-darwin-aarch64.zip",
+		"forge_windows_amd64.exe",
+		"forge_windows_amd64.zip",
+		"forge_windows_arm64.zip",
+		"forge-linux",
+	)
+	tests := []struct {
+		goos, goarch string
+		want, format string
+	}{
+		{"linux", "amd64", "forge_1.2.0_linux_x86_64.tar.gz", FormatTarGz},
+		{"linux", "arm64", "forge_1.2.0_linux_arm64.tar.gz", FormatTarGz},
+		{"darwin", "arm64", "forge-darwin-aarch64.zip", FormatZip},
+		// The plain binary is preferred over the zip
+		{"windows", "amd64", "forge_windows_amd64.exe", FormatBinary},
+		{"windows", "arm64", "forge_windows_arm64.zip", FormatZip},
+	}
+	for _, tt := range tests {
+		got, err := SelectBinaryAsset(release, tt.goos, tt.goarch)
+		if err != nil {
+			t.Fatalf("SelectBinaryAsset(%s/%s) error = %v", tt.goos, tt.goarch, err)
+		}
+		if got.Name != tt.want || got.Format != tt.format {
+			t.Errorf("SelectBinaryAsset(%s/%s) = %s (%s), want %s (%s)", tt.goos, tt.goarch, got.Name, got.Format, tt.want, tt.format)
+		}
+	}
+}
+
+func TestSelectBinaryAssetIgnoresDecoys(t *testing.T) {
+	tests := []struct {
+		goos, goarch string
+		assets       []string
+		want         string
+	}{
+		{"linux", "amd64", []string{"forge_linux_amd64_sbom.json", "forge_linux_amd64.tar.gz"}, "forge_linux_amd64.tar.gz"},
+		{"linux", "amd64", []string{"forge_linux_amd64_debug", "forge_linux_amd64.zip"}, "forge_linux_amd64.zip"},
+		{"linux", "amd64", []string{"forge_linux_amd64.exe", "forge_linux_amd64.sig", "forge_linux_amd64.tar.gz"}, "forge_linux_amd64.tar.gz"},
+		{"linux", "amd64", []string{"forge_linux_amd64.tar.gz", "forge_linux_amd64"}, "forge_linux_amd64"},
+		{"linux", "amd64", []string{"forge_debug_linux_amd64", "forge_v1.2.0-beta.1_linux_amd64.tgz"}, "forge_v1.2.0-beta.1_linux_amd64.tgz"},
+		{"darwin", "arm64", []string{"forge_darwin_arm64.exe", "forge_darwin_arm64.pkg", "forge_darwin_arm64.zip"}, "forge_darwin_arm64.zip"},
+		{"windows", "amd64", []string{"forge_windows_amd64", "forge_windows_amd64.msi", "forge_windows_amd64.zip"}, "forge_windows_amd64.zip"},
+	}
+	for _, tt := range tests {
+		got, err := SelectBinaryAsset(releaseWith(tt.assets...), tt.goos, tt.goarch)
+		if err != nil || got.Name != tt.want {
+			t.Errorf("SelectBinaryAsset(%s/%s, %v) = %s, %v, want %s", tt.goos, tt.goarch, tt.assets, got.Name, err, tt.want)
+		}
+	}
+
+	// Only decoys: nothing may be picked
+	release := releaseWith("forge_linux_amd64_sbom.json", "forge_linux_amd64_debug", "forge_linux_amd64.exe")
+	if got, err := SelectBinaryAsset(release, "linux", "amd64"); !errors.Is(err, ErrNoBinaryAsset) {
+		t.Errorf("SelectBinaryAsset(decoys) = %s, %v, want ErrNoBinaryAsset", got.Name, err)
+	}
+}
+
+func TestSelectBinaryAssetLegacyNames(t *testing.T) {
+	release := releaseWith("forge-linux", "forge-darwin", "forge.exe")
+	for goos, want := range map[string]string{"linux": "forge-linux", "darwin": "forge-darwin", "windows": "forge.exe"} {
+		got, err := SelectBinaryAsset(release, goos, "amd64")
+		if err != nil || got.Name != want {
+			t.Errorf("SelectBinaryAsset(%s/amd64) = %s, %v, want %s", goos, got.Name, err, want)
+		}
+	}
+
+	// Legacy assets are amd64 builds and must not be installed elsewhere
+	_, err := SelectBinaryAsset(release, "linux", "arm64")
+	if !errors.Is(err, ErrNoBinaryAsset) {
+		t.Fatalf("SelectBinaryAsset(linux/arm64) error = %v, want ErrNoBinaryAsset", err)
+	}
+	for _, want := range []string{"linux/arm64", "v1.2.0", "forge-linux, forge-darwin, forge.exe"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %s", err, want)
+		}
+	}
+}
+
+func TestExtractBinary(t *testing.T) {
+	dest := filepath.Join(t.TempDir(), "forge")
+
+	archive := writeZipEntries(t, []zipEntry{
+		{Name: "forge_linux_amd64/docs/forge", Content: "nested"},
+		{Name: "forge_linux_amd64/forge", Content: "binary"},
+		{Name: "forge_linux_amd64/LICENSE", Content: "MIT"},
+	})
+	if err := ExtractBinary(archive, "forge", dest); err != nil {
+		t.Fatalf("ExtractBinary() error = %v", err)
+	}
+	data, err := os.ReadFile(dest)
+	if err != nil || string(data) != "binary" {
+		t.Errorf("extracted = %q, %v, want the shallowest forge", data, err)
+	}
+
+	if err := ExtractBinary(archive, "forge.exe", dest); err == nil || !strings.Contains(err.Error(), "forge.exe") {
+		t.Errorf("ExtractBinary() of a missing binary error = %v", err)
+	}
+}
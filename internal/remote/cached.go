@@ -0,0 +1,68 @@
+package remote
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+
+	"forge/internal/cache"
+)
+
+// ErrNotCached is returned in offline mode for URLs that were never downloaded
+var ErrNotCached = errors.New("not in the download cache")
+
+// FetchCached returns the path of an up-to-date cached copy of url. A cached
+// copy is revalidated with If-None-Match / If-Modified-Since, so unchanged
+// files are not downloaded again; if the server cannot be reached the cached
+// copy is used as is. In offline mode no request is made at all. An
+// interrupted download is kept in the cache directory and resumed by the
+// next call. The returned file belongs to the cache and must not be removed.
+func FetchCached(c *cache.Cache, url string, offline bool) (string, error) {
+	entry, cachedPath, ok := c.Lookup(url)
+	if offline {
+		if !ok {
+			return "", fmt.Errorf("%s: %w (run without --offline first)", url, ErrNotCached)
+		}
+		return cachedPath, nil
+	}
+
+	header := http.Header{}
+	if ok {
+		if entry.ETag != "" {
+			header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+
+	part, err := c.PartialPath(url)
+	if err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(part), ".download-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create cache file: %w", err)
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+
+	respHeader, err := downloadResumable(url, part, tmp.Name(), path.Base(url), header)
+	var status *httpStatusError
+	switch {
+	case errors.Is(err, errNotModified) && ok:
+		if err := c.Touch(url); err != nil {
+			return "", err
+		}
+		return cachedPath, nil
+	case err != nil && ok && !errors.As(err, &status):
+		// Unreachable or interrupted: the cached copy will do
+		return cachedPath, nil
+	case err != nil:
+		return "", fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	return c.StoreFile(url, tmp.Name(), respHeader.Get("ETag"), respHeader.Get("Last-Modified"))
+}
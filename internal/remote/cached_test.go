@@ -0,0 +1,169 @@
+package remote
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"forge/internal/cache"
+)
+
+func TestFetchCachedRevalidatesWithETag(t *testing.T) {
+	var downloads, notModified atomic.Int32
+	body := "archive v1"
+	etag := `"v1"`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == etag {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		downloads.Add(1)
+		w.Header().Set("ETag", etag)
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	c := cache.New(t.TempDir())
+	url := srv.URL + "/templates.zip"
+
+	for i := 0; i < 2; i++ {
+		path, err := FetchCached(c, url, false)
+		if err != nil {
+			t.Fatalf("FetchCached() error = %v", err)
+		}
+		if data, _ := os.ReadFile(path); string(data) != "archive v1" {
+			t.Errorf("FetchCached() data = %q", data)
+		}
+	}
+	if downloads.Load() != 1 || notModified.Load() != 1 {
+		t.Errorf("downloads = %d, not modified = %d; want 1 and 1", downloads.Load(), notModified.Load())
+	}
+
+	// A changed resource is downloaded again
+	body, etag = "archive v2", `"v2"`
+	path, err := FetchCached(c, url, false)
+	if err != nil {
+		t.Fatalf("FetchCached() error = %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "archive v2" {
+		t.Errorf("FetchCached() after change = %q, want v2", data)
+	}
+}
+
+func TestFetchCachedLastModified(t *testing.T) {
+	const lastModified = "Mon, 02 Jan 2006 15:04:05 GMT"
+	var downloads atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-Modified-Since") == lastModified {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		downloads.Add(1)
+		w.Header().Set("Last-Modified", lastModified)
+		w.Write([]byte("data"))
+	}))
+	defer srv.Close()
+
+	c := cache.New(t.TempDir())
+	for i := 0; i < 3; i++ {
+		if _, err := FetchCached(c, srv.URL+"/a.zip", false); err != nil {
+			t.Fatalf("FetchCached() error = %v", err)
+		}
+	}
+	if downloads.Load() != 1 {
+		t.Errorf("downloads = %d, want 1", downloads.Load())
+	}
+}
+
+func TestFetchCachedOffline(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte("data"))
+	}))
+	url := srv.URL + "/a.zip"
+	c := cache.New(t.TempDir())
+
+	if _, err := FetchCached(c, url, true); !errors.Is(err, ErrNotCached) {
+		t.Errorf("offline miss error = %v, want ErrNotCached", err)
+	}
+	if _, err := FetchCached(c, url, false); err != nil {
+		t.Fatalf("FetchCached() error = %v", err)
+	}
+
+	srv.Close()
+	before := requests.Load()
+	if _, err := FetchCached(c, url, true); err != nil {
+		t.Errorf("offline hit error = %v", err)
+	}
+	if requests.Load() != before {
+		t.Error("offline mode made a request")
+	}
+
+	// With the server gone, a cached copy is still used
+	if _, err := FetchCached(c, url, false); err != nil {
+		t.Errorf("FetchCached() with server down error = %v, want cached copy", err)
+	}
+}
+
+func TestFetchCachedHTTPError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	c := cache.New(t.TempDir())
+	if _, err := FetchCached(c, srv.URL+"/missing.zip", false); err == nil {
+		t.Error("expected error for 404")
+	}
+	if entries, _ := c.List(); len(entries) != 0 {
+		t.Errorf("failed download was cached: %+v", entries)
+	}
+}
+
+func TestFetchCachedResumesInterruptedDownload(t *testing.T) {
+	content := []byte(strings.Repeat("template-archive-", 400))
+	var ranges []string
+	interrupted := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		w.Header().Set("ETag", `"v1"`)
+		if !interrupted {
+			interrupted = true
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			w.Write(content[:1000])
+			return
+		}
+		http.ServeContent(w, r, "templates.zip", time.Time{}, bytes.NewReader(content))
+	}))
+	defer srv.Close()
+
+	c := cache.New(t.TempDir())
+	url := srv.URL + "/templates.zip"
+	if _, err := FetchCached(c, url, false); err == nil {
+		t.Fatal("FetchCached() of a truncated response should fail")
+	}
+	if entries, _ := c.List(); len(entries) != 0 {
+		t.Fatalf("interrupted download was cached: %+v", entries)
+	}
+
+	path, err := FetchCached(c, url, false)
+	if err != nil {
+		t.Fatalf("FetchCached() resume error = %v", err)
+	}
+	if len(ranges) != 2 || ranges[1] != "bytes=1000-" {
+		t.Errorf("Range headers = %q, want the second request to resume at 1000", ranges)
+	}
+	if data, _ := os.ReadFile(path); !bytes.Equal(data, content) {
+		t.Errorf("cached %d bytes, want %d", len(data), len(content))
+	}
+	if entry, _, ok := c.Lookup(url); !ok || entry.ETag != `"v1"` {
+		t.Errorf("cache entry = %+v, want the ETag recorded", entry)
+	}
+}
@@ -0,0 +1,295 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ClientOptions configure the HTTP client used for all downloads
+type ClientOptions struct {
+	ConnectTimeout time.Duration // dialing and TLS handshake
+	ReadTimeout    time.Duration // waiting for headers and between body reads
+	Retries        int           // retries after the first attempt
+	RetryDelay     time.Duration // first backoff delay, doubled per retry
+	MaxRetryDelay  time.Duration // cap for backoff and Retry-After
+
+	Proxy    string // proxy URL; empty uses HTTP(S)_PROXY from the environment
+	CABundle string // PEM file with extra trusted certificate authorities
+
+	// Tokens maps host names to bearer tokens. Hosts not listed fall back
+	// to GITHUB_TOKEN (or GH_TOKEN) for GitHub hosts.
+	Tokens    map[string]string
+	UserAgent string
+}
+
+// DefaultClientOptions returns the options used unless configured otherwise
+func DefaultClientOptions() ClientOptions {
+	return ClientOptions{
+		ConnectTimeout: 15 * time.Second,
+		ReadTimeout:    60 * time.Second,
+		Retries:        3,
+		RetryDelay:     500 * time.Millisecond,
+		MaxRetryDelay:  30 * time.Second,
+		UserAgent:      "forge",
+	}
+}
+
+// githubHosts receive GITHUB_TOKEN. Redirects to other hosts, such as
+// release asset storage, do not carry the Authorization header.
+var githubHosts = map[string]bool{
+	"github.com":                true,
+	"api.github.com":            true,
+	"codeload.github.com":       true,
+	"raw.githubusercontent.com": true,
+}
+
+// Client is an HTTP client with timeouts, retries and authentication
+type Client struct {
+	http *http.Client
+	opts ClientOptions
+}
+
+// NewClient builds a client from opts
+func NewClient(opts ClientOptions) (*Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: opts.ConnectTimeout, KeepAlive: 30 * time.Second}).DialContext
+	transport.TLSHandshakeTimeout = opts.ConnectTimeout
+	transport.ResponseHeaderTimeout = opts.ReadTimeout
+
+	if opts.Proxy != "" {
+		proxyURL, err := url.Parse(opts.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if opts.CABundle != "" {
+		pem, err := os.ReadFile(opts.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", opts.CABundle)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	return &Client{http: &http.Client{Transport: transport}, opts: opts}, nil
+}
+
+var (
+	defaultClientMu sync.Mutex
+	defaultClient   *Client
+)
+
+// DefaultClient returns the client used by package-level download functions
+func DefaultClient() *Client {
+	defaultClientMu.Lock()
+	defer defaultClientMu.Unlock()
+	if defaultClient == nil {
+		// The default options need no files or URLs, so this cannot fail
+		defaultClient, _ = NewClient(DefaultClientOptions())
+	}
+	return defaultClient
+}
+
+// SetDefaultClient replaces the client used by package-level functions
+func SetDefaultClient(c *Client) {
+	defaultClientMu.Lock()
+	defer defaultClientMu.Unlock()
+	defaultClient = c
+}
+
+// token returns the bearer token for host, if any
+func (c *Client) token(host string) string {
+	if t, ok := c.opts.Tokens[host]; ok {
+		return t
+	}
+	if githubHosts[host] {
+		if t := os.Getenv("GITHUB_TOKEN"); t != "" {
+			return t
+		}
+		return os.Getenv("GH_TOKEN")
+	}
+	return ""
+}
+
+// Get requests rawURL with the given extra headers, retrying connection
+// errors, 5xx and 429 responses with exponential backoff (honouring
+// Retry-After). The caller must close the response body.
+func (c *Client) Get(rawURL string, header http.Header) (*http.Response, error) {
+	return c.send(http.MethodGet, rawURL, header, nil, true)
+}
+
+// Post sends body to rawURL with the given content type. The server may
+// have acted on a request that failed or got a 5xx response, so Post only
+// retries 429 responses and requests that could not be sent at all. The
+// caller must close the response body.
+func (c *Client) Post(rawURL, contentType string, body []byte) (*http.Response, error) {
+	return c.send(http.MethodPost, rawURL, http.Header{"Content-Type": {contentType}}, body, false)
+}
+
+// send makes the request, retrying as described for Get when idempotent
+// and as described for Post otherwise
+func (c *Client) send(method, rawURL string, header http.Header, body []byte, idempotent bool) (*http.Response, error) {
+	var lastErr error
+	for attempt := 0; ; attempt++ {
+		resp, sent, err := c.do(method, rawURL, header, body)
+		if err == nil && !retryableStatus(resp.StatusCode) {
+			return resp, nil
+		}
+		if !idempotent {
+			if err != nil && sent {
+				return nil, err
+			}
+			if err == nil && resp.StatusCode != http.StatusTooManyRequests {
+				return resp, nil
+			}
+		}
+
+		delay := c.backoff(attempt)
+		if err != nil {
+			lastErr = err
+		} else {
+			lastErr = fmt.Errorf("http %d", resp.StatusCode)
+			if after, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+				delay = after
+			}
+		}
+
+		if attempt >= c.opts.Retries {
+			if err == nil {
+				// Hand the final response to the caller to report
+				return resp, nil
+			}
+			return nil, lastErr
+		}
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+
+		if c.opts.MaxRetryDelay > 0 && delay > c.opts.MaxRetryDelay {
+			delay = c.opts.MaxRetryDelay
+		}
+		time.Sleep(delay)
+	}
+}
+
+// do makes a single request. sent reports whether any of it reached the
+// connection, after which the server may have acted on it.
+func (c *Client) do(method, rawURL string, header http.Header, body []byte) (resp *http.Response, sent bool, err error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var wrote atomic.Bool
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		WroteHeaders: func() { wrote.Store(true) },
+	})
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, reqBody)
+	if err != nil {
+		cancel()
+		return nil, false, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if c.opts.UserAgent != "" {
+		req.Header.Set("User-Agent", c.opts.UserAgent)
+	}
+	if t := c.token(req.URL.Hostname()); t != "" {
+		req.Header.Set("Authorization", "Bearer "+t)
+	}
+
+	resp, err = c.http.Do(req)
+	if err != nil {
+		cancel()
+		return nil, wrote.Load(), err
+	}
+	resp.Body = newIdleTimeoutBody(resp.Body, c.opts.ReadTimeout, cancel)
+	return resp, true, nil
+}
+
+func (c *Client) backoff(attempt int) time.Duration {
+	return c.opts.RetryDelay << attempt
+}
+
+func retryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= 500
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date
+func retryAfter(v string) (time.Duration, bool) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// idleTimeoutBody cancels the request when no data arrives for timeout,
+// so a stalled download fails instead of hanging
+type idleTimeoutBody struct {
+	body    io.ReadCloser
+	timer   *time.Timer
+	timeout time.Duration
+	cancel  context.CancelFunc
+}
+
+func newIdleTimeoutBody(body io.ReadCloser, timeout time.Duration, cancel context.CancelFunc) io.ReadCloser {
+	if timeout <= 0 {
+		return &idleTimeoutBody{body: body, cancel: cancel}
+	}
+	return &idleTimeoutBody{body: body, timer: time.AfterFunc(timeout, cancel), timeout: timeout, cancel: cancel}
+}
+
+func (b *idleTimeoutBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	if b.timer != nil {
+		b.timer.Reset(b.timeout)
+	}
+	if err != nil && errors.Is(err, context.Canceled) {
+		err = fmt.Errorf("download stalled for %s: %w", b.timeout, err)
+	}
+	return n, err
+}
+
+func (b *idleTimeoutBody) Close() error {
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+	err := b.body.Close()
+	b.cancel()
+	return err
+}
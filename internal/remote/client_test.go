@@ -0,0 +1,239 @@
+package remote
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestMain keeps retry backoff short for every test that downloads
+func TestMain(m *testing.M) {
+	c, err := NewClient(fastRetries())
+	if err != nil {
+		panic(err)
+	}
+	SetDefaultClient(c)
+	os.Exit(m.Run())
+}
+
+func testClient(t *testing.T, opts ClientOptions) *Client {
+	t.Helper()
+	c, err := NewClient(opts)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	return c
+}
+
+func fastRetries() ClientOptions {
+	opts := DefaultClientOptions()
+	opts.RetryDelay = time.Millisecond
+	opts.MaxRetryDelay = 50 * time.Millisecond
+	return opts
+}
+
+func TestClientRetriesServerErrors(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch calls.Add(1) {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			io.WriteString(w, "ok")
+		}
+	}))
+	defer srv.Close()
+
+	resp, err := testClient(t, fastRetries()).Get(srv.URL, nil)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != "ok" {
+		t.Errorf("got %d %q, want 200 \"ok\"", resp.StatusCode, body)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("server called %d times, want 3", calls.Load())
+	}
+}
+
+func TestClientGivesUpAfterRetries(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	opts := fastRetries()
+	opts.Retries = 2
+	resp, err := testClient(t, opts).Get(srv.URL, nil)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Errorf("status = %d, want the final 502", resp.StatusCode)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("server called %d times, want 3", calls.Load())
+	}
+}
+
+func TestClientDoesNotRetryClientErrors(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	resp, err := testClient(t, fastRetries()).Get(srv.URL, nil)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+	if calls.Load() != 1 {
+		t.Errorf("server called %d times, want 1", calls.Load())
+	}
+}
+
+func TestClientPostDoesNotRetryServerErrors(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The upload is stored, but the response still reports a failure
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusConflict)
+	}))
+	defer srv.Close()
+
+	resp, err := testClient(t, fastRetries()).Post(srv.URL, "application/octet-stream", []byte("archive"))
+	if err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusInternalServerError || calls.Load() != 1 {
+		t.Errorf("got %d after %d calls, want 500 after 1", resp.StatusCode, calls.Load())
+	}
+}
+
+func TestClientPostRetriesTooManyRequests(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		if body, _ := io.ReadAll(r.Body); string(body) != "archive" {
+			t.Errorf("retried body = %q", body)
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	resp, err := testClient(t, fastRetries()).Post(srv.URL, "application/octet-stream", []byte("archive"))
+	if err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated || calls.Load() != 2 {
+		t.Errorf("got %d after %d calls, want 201 after 2", resp.StatusCode, calls.Load())
+	}
+}
+
+func TestClientAppliesTokens(t *testing.T) {
+	var auth, agent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		agent = r.Header.Get("User-Agent")
+	}))
+	defer srv.Close()
+
+	opts := fastRetries()
+	opts.UserAgent = "forge/test"
+	opts.Tokens = map[string]string{"127.0.0.1": "secret"}
+	resp, err := testClient(t, opts).Get(srv.URL, nil)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+	if auth != "Bearer secret" {
+		t.Errorf("Authorization = %q, want %q", auth, "Bearer secret")
+	}
+	if agent != "forge/test" {
+		t.Errorf("User-Agent = %q, want forge/test", agent)
+	}
+}
+
+func TestClientGitHubTokenFromEnv(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "from-env")
+	c := testClient(t, DefaultClientOptions())
+	if got := c.token("api.github.com"); got != "from-env" {
+		t.Errorf("token(api.github.com) = %q, want from-env", got)
+	}
+	if got := c.token("example.com"); got != "" {
+		t.Errorf("token(example.com) = %q, GITHUB_TOKEN must not leak to other hosts", got)
+	}
+}
+
+func TestClientReadTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "partial")
+		w.(http.Flusher).Flush()
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	opts := fastRetries()
+	opts.ReadTimeout = 100 * time.Millisecond
+	resp, err := testClient(t, opts).Get(srv.URL, nil)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	defer resp.Body.Close()
+
+	_, err = io.ReadAll(resp.Body)
+	if err == nil || !strings.Contains(err.Error(), "stalled") {
+		t.Errorf("ReadAll error = %v, want a stalled download error", err)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	if d, ok := retryAfter("3"); !ok || d != 3*time.Second {
+		t.Errorf("retryAfter(3) = %v, %v", d, ok)
+	}
+	date := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	if d, ok := retryAfter(date); !ok || d < 59*time.Minute {
+		t.Errorf("retryAfter(date) = %v, %v", d, ok)
+	}
+	if _, ok := retryAfter("soon"); ok {
+		t.Error("retryAfter(soon) should not parse")
+	}
+}
+
+func TestNewClientRejectsBadCABundle(t *testing.T) {
+	opts := DefaultClientOptions()
+	opts.CABundle = filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(opts.CABundle, []byte("not a certificate"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewClient(opts); err == nil {
+		t.Error("NewClient() should reject a CA bundle without certificates")
+	}
+}
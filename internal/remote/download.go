@@ -2,117 +2,144 @@ package remote
 
 import (
 	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
 	"strings"
 )
 
+// ErrTemplateNotFound is returned when an archive does not contain the requested template
+var ErrTemplateNotFound = errors.New("template not found in archive")
+
 // DownloadRepoZip downloads the given URL into a temporary file and
 // returns the path to the downloaded zip file. Caller must remove the file.
+// Downloads happen in a per-user directory, and an interrupted download is
+// resumed by the next call for the same URL.
 func DownloadRepoZip(url string) (string, error) {
-	resp, err := http.Get(url)
+	dir, err := downloadDir()
 	if err != nil {
-		return "", fmt.Errorf("failed to download repository: %w", err)
+		return "", err
 	}
-	defer resp.Body.Close()
+	sum := sha256.Sum256([]byte(url))
+	part := filepath.Join(dir, "templates-"+hex.EncodeToString(sum[:8])+".part")
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return "", fmt.Errorf("failed to download repository: http %d", resp.StatusCode)
+	// Each caller gets its own finished file, so concurrent pulls of the
+	// same URL cannot remove or replace each other's archive
+	f, err := os.CreateTemp(dir, "templates-*.zip")
+	if err != nil {
+		return "", fmt.Errorf("failed to create download file: %w", err)
 	}
+	f.Close()
 
-	tmp, err := os.CreateTemp("", "forge-templates-*.zip")
+	if _, err := downloadResumable(url, part, f.Name(), path.Base(url), nil); err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("failed to download repository: %w", err)
+	}
+	return f.Name(), nil
+}
+
+// FetchBytes downloads a small file, such as a checksum manifest, into memory
+func FetchBytes(url string) ([]byte, error) {
+	resp, err := DefaultClient().Get(url, nil)
 	if err != nil {
-		return "", fmt.Errorf("failed to create temp file: %w", err)
+		return nil, fmt.Errorf("failed to download %s: %w", url, err)
 	}
-	defer tmp.Close()
+	defer resp.Body.Close()
 
-	if _, err := io.Copy(tmp, resp.Body); err != nil {
-		os.Remove(tmp.Name())
-		return "", fmt.Errorf("failed to write to temp file: %w", err)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed to download %s: http %d", url, resp.StatusCode)
 	}
 
-	return tmp.Name(), nil
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", url, err)
+	}
+	return data, nil
 }
 
-// detectZipPrefix returns the top-level prefix present in paths inside the zip,
+// detectPrefix returns the top-level prefix present in paths inside the archive,
 // e.g. "forge-templates-main/". If none found, empty string is returned.
-func detectZipPrefix(r *zip.Reader) string {
-	for _, f := range r.File {
-		name := f.Name
-		if idx := strings.Index(name, "/"); idx > 0 {
-			return name[:idx+1]
+func detectPrefix(a *archive) string {
+	for _, e := range a.entries {
+		if idx := strings.Index(e.Name, "/"); idx > 0 {
+			return e.Name[:idx+1]
 		}
 	}
 	return ""
 }
 
-// ListTopLevelTemplates lists the top-level directories (template names)
-// contained in the zip archive. It does not extract files.
-func ListTopLevelTemplates(zipPath string) ([]string, error) {
-	z, err := zip.OpenReader(zipPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open zip: %w", err)
+// hasPrefixedEntry reports whether any entry in the archive starts with prefix
+func hasPrefixedEntry(a *archive, prefix string) bool {
+	for _, e := range a.entries {
+		if strings.HasPrefix(e.Name, prefix) {
+			return true
+		}
 	}
-	defer z.Close()
-
-	prefix := detectZipPrefix(&z.Reader)
-	names := map[string]struct{}{}
+	return false
+}
 
-	for _, f := range z.File {
-		name := f.Name
-		if !strings.HasPrefix(name, prefix) {
+// topLevelNames returns the names of the directories directly under prefix
+func topLevelNames(a *archive, prefix string) []string {
+	seen := map[string]struct{}{}
+	var names []string
+	for _, e := range a.entries {
+		if !strings.HasPrefix(e.Name, prefix) {
 			continue
 		}
-		rest := strings.TrimPrefix(name, prefix)
+		rest := strings.TrimPrefix(e.Name, prefix)
 		parts := strings.SplitN(rest, "/", 2)
-		if len(parts) > 0 && parts[0] != "" {
-			names[parts[0]] = struct{}{}
+		if len(parts) < 2 || parts[0] == "" {
+			continue
+		}
+		if _, ok := seen[parts[0]]; !ok {
+			seen[parts[0]] = struct{}{}
+			names = append(names, parts[0])
 		}
 	}
-
-	out := make([]string, 0, len(names))
-	for n := range names {
-		out = append(out, n)
-	}
-	return out, nil
+	return names
 }
 
-// extractPrefixedFiles extracts all files under zipPrefix/templateName/ into destDir.
-// It validates presence of template.yaml inside the extracted content.
-func extractPrefixedFiles(zipPath, zipPrefix, templateName, destDir string) error {
-	z, err := zip.OpenReader(zipPath)
+// ListTopLevelTemplates lists the top-level directories (template names)
+// contained in the archive. It does not extract files.
+func ListTopLevelTemplates(archivePath string) ([]string, error) {
+	a, err := openArchive(archivePath)
 	if err != nil {
-		return fmt.Errorf("failed to open zip: %w", err)
+		return nil, err
 	}
-	defer z.Close()
+	defer a.Close()
 
-	wantedPrefix := zipPrefix + templateName + "/"
+	return topLevelNames(a, detectPrefix(a)), nil
+}
+
+// extractPrefixedFiles extracts all entries under wantedPrefix into destDir,
+// which should be a fresh staging directory; the caller removes it on error.
+// It validates presence of template.yaml inside the extracted content.
+func extractPrefixedFiles(a *archive, wantedPrefix, destDir string) error {
 	foundAny := false
 	foundYAML := false
 
-	// Remove existing destination and recreate
-	if err := os.RemoveAll(destDir); err != nil {
-		return fmt.Errorf("failed to remove existing template dir: %w", err)
-	}
-
-	for _, f := range z.File {
-		if !strings.HasPrefix(f.Name, wantedPrefix) {
+	for _, e := range a.entries {
+		if !strings.HasPrefix(e.Name, wantedPrefix) {
 			continue
 		}
 		foundAny = true
-		rel := strings.TrimPrefix(f.Name, wantedPrefix)
+		rel := strings.TrimPrefix(e.Name, wantedPrefix)
 		if rel == "" {
 			// This is the template root directory entry
 			continue
 		}
-		targetPath := filepath.Join(destDir, filepath.FromSlash(rel))
+		targetPath, err := safeJoin(destDir, rel)
+		if err != nil {
+			return err
+		}
 
-		if strings.HasSuffix(f.Name, "/") {
-			// Directory
+		if e.isDir() {
 			if err := os.MkdirAll(targetPath, 0755); err != nil {
 				return fmt.Errorf("failed to create dir %s: %w", targetPath, err)
 			}
@@ -124,94 +151,94 @@ func extractPrefixedFiles(zipPath, zipPrefix, templateName, destDir string) erro
 			return fmt.Errorf("failed to create parent dir %s: %w", filepath.Dir(targetPath), err)
 		}
 
-		rc, err := f.Open()
-		if err != nil {
-			return fmt.Errorf("failed to open zip entry: %w", err)
-		}
-		defer rc.Close()
-
-		out, err := os.Create(targetPath)
-		if err != nil {
-			rc.Close()
-			return fmt.Errorf("failed to create file %s: %w", targetPath, err)
-		}
-
-		if _, err := io.Copy(out, rc); err != nil {
-			out.Close()
-			rc.Close()
-			return fmt.Errorf("failed to write file %s: %w", targetPath, err)
+		if err := a.extractFile(e, targetPath); err != nil {
+			return err
 		}
-		out.Close()
-		rc.Close()
 
-		if filepath.Base(targetPath) == "template.yaml" {
+		if rel == "template.yaml" {
 			foundYAML = true
 		}
 	}
 
 	if !foundAny {
-		return errors.New("template not found in archive")
+		return ErrTemplateNotFound
 	}
 	if !foundYAML {
-		// Clean up partial extraction
-		os.RemoveAll(destDir)
 		return errors.New("invalid template: missing template.yaml")
 	}
 	return nil
 }
 
-// InstallSingleTemplate extracts the requested template from the zip archive into destParentDir/templateName
-func InstallSingleTemplate(zipPath, templateName, destParentDir string) error {
-	zf, err := zip.OpenReader(zipPath)
+// extractFile writes one entry to targetPath, keeping its executable bits
+func (a *archive) extractFile(e archiveEntry, targetPath string) error {
+	rc, err := e.open()
 	if err != nil {
-		return fmt.Errorf("failed to open zip: %w", err)
+		return fmt.Errorf("failed to open archive entry: %w", err)
 	}
-	defer zf.Close()
+	defer rc.Close()
 
-	prefix := detectZipPrefix(&zf.Reader)
-	if prefix == "" {
-		return errors.New("unable to detect zip prefix")
+	perm := os.FileMode(0644)
+	if e.Mode.Perm()&0111 != 0 {
+		perm = 0755
+	}
+	out, err := os.OpenFile(targetPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return fmt.Errorf("failed to create file %s: %w", targetPath, err)
+	}
+
+	if err := copyLimited(out, rc, a.limits.MaxBytes, &a.written); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to write file %s: %w", targetPath, err)
 	}
+	return out.Close()
+}
 
-	destDir := filepath.Join(destParentDir, templateName)
-	if err := extractPrefixedFiles(zipPath, prefix, templateName, destDir); err != nil {
+// InstallSingleTemplate extracts the requested template from a registry
+// archive (zip, tar or tar.gz) into destParentDir/templateName
+func InstallSingleTemplate(archivePath, templateName, destParentDir string) error {
+	a, err := openArchive(archivePath)
+	if err != nil {
 		return err
 	}
-	return nil
+	defer a.Close()
+
+	prefix := detectPrefix(a)
+	if prefix == "" {
+		return errors.New("unable to detect archive prefix")
+	}
+
+	// Check before extracting so an existing install is left alone when the
+	// archive does not provide the template
+	wanted := prefix + templateName + "/"
+	if !hasPrefixedEntry(a, wanted) {
+		return ErrTemplateNotFound
+	}
+
+	return installStaged(destParentDir, templateName, func(staging string) error {
+		return extractPrefixedFiles(a, wanted, staging)
+	})
 }
 
 // InstallAllTemplates extracts top-level directories that contain template.yaml
 // into destParentDir. It returns a slice of installed template names and an error.
-func InstallAllTemplates(zipPath, destParentDir string) ([]string, error) {
-	zf, err := zip.OpenReader(zipPath)
+func InstallAllTemplates(archivePath, destParentDir string) ([]string, error) {
+	a, err := openArchive(archivePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open zip: %w", err)
+		return nil, err
 	}
-	defer zf.Close()
+	defer a.Close()
 
-	prefix := detectZipPrefix(&zf.Reader)
+	prefix := detectPrefix(a)
 	if prefix == "" {
-		return nil, errors.New("unable to detect zip prefix")
-	}
-
-	// Collect candidate top-level names
-	candidates := map[string]struct{}{}
-	for _, f := range zf.File {
-		name := f.Name
-		if !strings.HasPrefix(name, prefix) {
-			continue
-		}
-		rest := strings.TrimPrefix(name, prefix)
-		parts := strings.SplitN(rest, "/", 2)
-		if len(parts) > 0 && parts[0] != "" {
-			candidates[parts[0]] = struct{}{}
-		}
+		return nil, errors.New("unable to detect archive prefix")
 	}
 
+	candidates := topLevelNames(a, prefix)
 	installed := make([]string, 0, len(candidates))
-	for name := range candidates {
-		destDir := filepath.Join(destParentDir, name)
-		err := extractPrefixedFiles(zipPath, prefix, name, destDir)
+	for _, name := range candidates {
+		err := installStaged(destParentDir, name, func(staging string) error {
+			return extractPrefixedFiles(a, prefix+name+"/", staging)
+		})
 		if err != nil {
 			// skip invalid templates, but continue
 			continue
@@ -221,3 +248,63 @@ func InstallAllTemplates(zipPath, destParentDir string) ([]string, error) {
 
 	return installed, nil
 }
+
+// ExtractTemplate extracts the named template from an archive into destDir,
+// which must not exist yet. The template may sit at the archive root, as in
+// archives written by forge pack, or in a directory named after it.
+func ExtractTemplate(archivePath, templateName, destDir string) error {
+	a, err := openArchive(archivePath)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	for _, root := range a.templateRoots() {
+		if root != "" && path.Base(strings.TrimSuffix(root, "/")) != templateName {
+			continue
+		}
+		if err := os.Mkdir(destDir, 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", destDir, err)
+		}
+		if err := extractPrefixedFiles(a, root, destDir); err != nil {
+			os.RemoveAll(destDir)
+			return err
+		}
+		return nil
+	}
+	return ErrTemplateNotFound
+}
+
+// ArchiveCommit returns the commit a zip archive was built from, which git
+// archive (and so GitHub's archive downloads) stores as the zip comment.
+// It returns "" for other archives.
+func ArchiveCommit(archivePath string) string {
+	z, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return ""
+	}
+	defer z.Close()
+
+	comment := strings.TrimSpace(z.Comment)
+	if len(comment) != 40 && len(comment) != 64 {
+		return ""
+	}
+	if _, err := hex.DecodeString(comment); err != nil {
+		return ""
+	}
+	return comment
+}
+
+// githubArchive matches GitHub archive URLs of a branch, tag or commit
+var githubArchive = regexp.MustCompile(`^(https://github\.com/[^/]+/[^/]+/archive/)(?:refs/(?:heads|tags)/)?[^/]+\.(zip|tar\.gz)$`)
+
+// CommitArchiveURL returns the URL of the archive of commit in the same
+// repository as a GitHub archive URL, such as .../archive/refs/heads/main.zip.
+// Other URLs, or an empty commit, return archiveURL unchanged.
+func CommitArchiveURL(archiveURL, commit string) string {
+	m := githubArchive.FindStringSubmatch(archiveURL)
+	if commit == "" || m == nil {
+		return archiveURL
+	}
+	return m[1] + commit + "." + m[2]
+}
@@ -1,6 +1,7 @@
 package remote
 
 import (
+	"path/filepath"
 	"testing"
 )
 
@@ -10,3 +11,31 @@ func TestDownloadRepoZip_InvalidURL(t *testing.T) {
 		t.Error("expected error for invalid URL, got nil")
 	}
 }
+
+func TestCommitArchiveURL(t *testing.T) {
+	commit := "0123456789abcdef0123456789abcdef01234567"
+	tests := map[string]string{
+		"https://github.com/org/repo/archive/refs/heads/main.zip":   "https://github.com/org/repo/archive/" + commit + ".zip",
+		"https://github.com/org/repo/archive/refs/tags/v1.0.tar.gz": "https://github.com/org/repo/archive/" + commit + ".tar.gz",
+		"https://github.com/org/repo/archive/main.zip":              "https://github.com/org/repo/archive/" + commit + ".zip",
+		"https://example.com/templates.zip":                         "https://example.com/templates.zip",
+	}
+	for in, want := range tests {
+		if got := CommitArchiveURL(in, commit); got != want {
+			t.Errorf("CommitArchiveURL(%s) = %s, want %s", in, got, want)
+		}
+	}
+	if got := CommitArchiveURL("https://github.com/org/repo/archive/refs/heads/main.zip", ""); got != "https://github.com/org/repo/archive/refs/heads/main.zip" {
+		t.Errorf("CommitArchiveURL() without a commit = %s", got)
+	}
+}
+
+func TestArchiveCommit(t *testing.T) {
+	plain := writeZipEntries(t, []zipEntry{{Name: "a/template.yaml", Content: "name: a\n"}})
+	if got := ArchiveCommit(plain); got != "" {
+		t.Errorf("ArchiveCommit() of a zip without a comment = %q", got)
+	}
+	if got := ArchiveCommit(filepath.Join(t.TempDir(), "missing.zip")); got != "" {
+		t.Errorf("ArchiveCommit() of a missing file = %q", got)
+	}
+}
@@ -0,0 +1,208 @@
+package remote
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// GitPrefix marks a template source as a git repository
+const GitPrefix = "git+"
+
+// GitSource is a template located in a git repository, written as
+//
+//	git+<repo-url>[//<subdir>][@<ref>]
+//
+// for example git+https://host/org/repo//templates/python@v1.4.0.
+// The ref may be a tag, branch or commit; it defaults to the remote HEAD.
+type GitSource struct {
+	RepoURL string // URL passed to git clone
+	Subdir  string // slash-separated path inside the repository, may be empty
+	Ref     string
+}
+
+// gitSchemes are the URL schemes accepted in git sources. Others, such as
+// ext::, can run commands and are refused.
+var gitSchemes = []string{"https", "ssh", "git", "file"}
+
+// IsGitSource reports whether s uses the git+ source syntax
+func IsGitSource(s string) bool {
+	return strings.HasPrefix(s, GitPrefix)
+}
+
+// ParseGitSource parses a git+ template source
+func ParseGitSource(s string) (GitSource, error) {
+	if !IsGitSource(s) {
+		return GitSource{}, fmt.Errorf("not a git source: %s", s)
+	}
+	rest := strings.TrimPrefix(s, GitPrefix)
+
+	schemeEnd := strings.Index(rest, "://")
+	if schemeEnd < 0 {
+		return GitSource{}, fmt.Errorf("invalid git source '%s': expected git+<scheme>://...", s)
+	}
+	afterScheme := schemeEnd + len("://")
+	if scheme := rest[:schemeEnd]; !contains(gitSchemes, scheme) {
+		return GitSource{}, fmt.Errorf("invalid git source '%s': unsupported scheme %q (use %s)", s, scheme, strings.Join(gitSchemes, ", "))
+	}
+
+	var src GitSource
+	// A ref is only recognised after the last slash, so user@host is not mistaken for one
+	if at := strings.LastIndex(rest, "@"); at > strings.LastIndex(rest, "/") && at > afterScheme {
+		src.Ref = rest[at+1:]
+		rest = rest[:at]
+		if src.Ref == "" {
+			return GitSource{}, fmt.Errorf("invalid git source '%s': empty ref after '@'", s)
+		}
+	}
+
+	if sep := strings.Index(rest[afterScheme:], "//"); sep >= 0 {
+		src.Subdir = strings.Trim(rest[afterScheme+sep+2:], "/")
+		rest = rest[:afterScheme+sep]
+		if src.Subdir == "" || strings.Contains("/"+src.Subdir+"/", "/../") {
+			return GitSource{}, fmt.Errorf("invalid git source '%s': bad subdirectory", s)
+		}
+	}
+
+	src.RepoURL = rest
+	if strings.TrimRight(rest[afterScheme:], "/") == "" {
+		return GitSource{}, fmt.Errorf("invalid git source '%s': missing repository", s)
+	}
+	if err := src.validate(); err != nil {
+		return GitSource{}, fmt.Errorf("invalid git source '%s': %w", s, err)
+	}
+	return src, nil
+}
+
+// validate rejects a URL or ref that git would read as an option
+func (g GitSource) validate() error {
+	if strings.HasPrefix(g.RepoURL, "-") {
+		return fmt.Errorf("repository URL may not start with '-'")
+	}
+	if strings.HasPrefix(g.Ref, "-") {
+		return fmt.Errorf("ref may not start with '-'")
+	}
+	return nil
+}
+
+// TemplateName returns the name the template is installed under: the last
+// element of the subdirectory, or the repository name
+func (g GitSource) TemplateName() string {
+	if g.Subdir != "" {
+		return path.Base(g.Subdir)
+	}
+	return strings.TrimSuffix(path.Base(strings.TrimRight(g.RepoURL, "/")), ".git")
+}
+
+// String returns the source in git+ syntax
+func (g GitSource) String() string {
+	s := GitPrefix + g.RepoURL
+	if g.Subdir != "" {
+		s += "//" + g.Subdir
+	}
+	if g.Ref != "" {
+		s += "@" + g.Ref
+	}
+	return s
+}
+
+// FetchGit clones the repository with the local git executable, checks out
+// the ref and copies the subdirectory into destDir, which must not exist.
+// It returns the resolved commit SHA.
+func FetchGit(src GitSource, destDir string) (commit string, err error) {
+	if err := src.validate(); err != nil {
+		return "", fmt.Errorf("invalid git source: %w", err)
+	}
+
+	cloneDir, err := os.MkdirTemp("", "forge-git-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(cloneDir)
+
+	if _, err := runGit("", "clone", "--quiet", "--no-checkout", "--", src.RepoURL, cloneDir); err != nil {
+		return "", fmt.Errorf("failed to clone %s: %w", src.RepoURL, err)
+	}
+
+	ref := src.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	if _, err := runGit(cloneDir, "checkout", "--quiet", ref); err != nil {
+		return "", fmt.Errorf("failed to check out '%s': %w", ref, err)
+	}
+
+	out, err := runGit(cloneDir, "rev-parse", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve commit: %w", err)
+	}
+	commit = strings.TrimSpace(out)
+
+	srcDir := filepath.Join(cloneDir, filepath.FromSlash(src.Subdir))
+	if _, err := os.Stat(filepath.Join(srcDir, "template.yaml")); err != nil {
+		if src.Subdir == "" {
+			return "", errors.New("invalid template: repository root has no template.yaml")
+		}
+		return "", fmt.Errorf("invalid template: %s has no template.yaml", src.Subdir)
+	}
+
+	if err := copyTree(srcDir, destDir); err != nil {
+		return "", fmt.Errorf("failed to copy template: %w", err)
+	}
+	return commit, nil
+}
+
+// runGit runs git in dir and returns its stdout; stderr is included in errors
+func runGit(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	// Never block on credential prompts
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	return stdout.String(), nil
+}
+
+// copyTree copies a directory tree, skipping .git metadata
+func copyTree(src, dst string) error {
+	return filepath.Walk(src, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+
+		target := filepath.Join(dst, rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		if !info.Mode().IsRegular() {
+			// Symlinks and special files are not part of templates
+			return nil
+		}
+
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, info.Mode().Perm())
+	})
+}
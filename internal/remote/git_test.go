@@ -0,0 +1,250 @@
+package remote
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseGitSource(t *testing.T) {
+	tests := []struct {
+		in   string
+		want GitSource
+		name string
+	}{
+		{
+			in:   "git+https://host/org/repo//templates/python@v1.4.0",
+			want: GitSource{RepoURL: "https://host/org/repo", Subdir: "templates/python", Ref: "v1.4.0"},
+			name: "python",
+		},
+		{
+			in:   "git+https://host/org/repo.git",
+			want: GitSource{RepoURL: "https://host/org/repo.git"},
+			name: "repo",
+		},
+		{
+			in:   "git+ssh://git@host/org/repo@main",
+			want: GitSource{RepoURL: "ssh://git@host/org/repo", Ref: "main"},
+			name: "repo",
+		},
+		{
+			in:   "git+ssh://git@host/org/repo//go",
+			want: GitSource{RepoURL: "ssh://git@host/org/repo", Subdir: "go"},
+			name: "go",
+		},
+		{
+			in:   "git+file:///srv/repo//node@0123abc",
+			want: GitSource{RepoURL: "file:///srv/repo", Subdir: "node", Ref: "0123abc"},
+			name: "node",
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseGitSource(tt.in)
+		if err != nil {
+			t.Errorf("ParseGitSource(%q) error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseGitSource(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+		if got.TemplateName() != tt.name {
+			t.Errorf("TemplateName(%q) = %q, want %q", tt.in, got.TemplateName(), tt.name)
+		}
+		if got.String() != tt.in {
+			t.Errorf("String() = %q, want %q", got.String(), tt.in)
+		}
+	}
+}
+
+func TestParseGitSourceInvalid(t *testing.T) {
+	for _, in := range []string{
+		"https://host/org/repo",
+		"git+host/org/repo",
+		"git+https://",
+		"git+https://host/repo@",
+		"git+https://host/repo//../etc",
+		"git+ext::sh -c touch% /tmp/pwned://x",
+		"git+--upload-pack=touch /tmp/pwned;://x",
+		"git+https://host/repo@-oProxyCommand=x",
+	} {
+		if _, err := ParseGitSource(in); err == nil {
+			t.Errorf("ParseGitSource(%q) expected error", in)
+		}
+	}
+}
+
+// gitRepo creates a repository with templates/python at two tagged versions
+func gitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	dir := t.TempDir()
+	run := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+			"GIT_CONFIG_GLOBAL="+os.DevNull, "GIT_CONFIG_NOSYSTEM=1")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	write := func(rel, content string) {
+		t.Helper()
+		path := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run("init", "--quiet", "-b", "main")
+	write("templates/python/template.yaml", "name: python\nversion: 1.0.0\n")
+	write("README.md", "templates\n")
+	run("add", "-A")
+	run("commit", "--quiet", "-m", "v1")
+	run("tag", "v1.0.0")
+
+	write("templates/python/template.yaml", "name: python\nversion: 2.0.0\n")
+	run("add", "-A")
+	run("commit", "--quiet", "-m", "v2")
+	return dir
+}
+
+func gitRev(t *testing.T, dir, rev string) string {
+	t.Helper()
+	out, err := runGit(dir, "rev-parse", rev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimSpace(out)
+}
+
+func TestFetchGitRefAndSubdir(t *testing.T) {
+	repo := gitRepo(t)
+	repoURL := "file://" + filepath.ToSlash(repo)
+
+	tests := []struct {
+		ref     string
+		version string
+		commit  string
+	}{
+		{ref: "v1.0.0", version: "1.0.0", commit: gitRev(t, repo, "v1.0.0")},
+		{ref: "main", version: "2.0.0", commit: gitRev(t, repo, "main")},
+		{ref: "", version: "2.0.0", commit: gitRev(t, repo, "HEAD")},
+		{ref: gitRev(t, repo, "v1.0.0"), version: "1.0.0", commit: gitRev(t, repo, "v1.0.0")},
+	}
+
+	for _, tt := range tests {
+		dest := filepath.Join(t.TempDir(), "python")
+		src := GitSource{RepoURL: repoURL, Subdir: "templates/python", Ref: tt.ref}
+		commit, err := FetchGit(src, dest)
+		if err != nil {
+			t.Fatalf("FetchGit(%q) error: %v", tt.ref, err)
+		}
+		if commit != tt.commit {
+			t.Errorf("FetchGit(%q) commit = %s, want %s", tt.ref, commit, tt.commit)
+		}
+
+		data, err := os.ReadFile(filepath.Join(dest, "template.yaml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), "version: "+tt.version) {
+			t.Errorf("FetchGit(%q) got template %q, want version %s", tt.ref, data, tt.version)
+		}
+		if _, err := os.Stat(filepath.Join(dest, "README.md")); err == nil {
+			t.Errorf("files outside the subdirectory should not be copied")
+		}
+	}
+}
+
+func TestFetchGitErrors(t *testing.T) {
+	repo := gitRepo(t)
+	repoURL := "file://" + filepath.ToSlash(repo)
+
+	if _, err := FetchGit(GitSource{RepoURL: repoURL, Subdir: "templates/python", Ref: "v9"}, filepath.Join(t.TempDir(), "x")); err == nil {
+		t.Error("expected error for unknown ref")
+	}
+	if _, err := FetchGit(GitSource{RepoURL: repoURL, Subdir: "templates/missing"}, filepath.Join(t.TempDir(), "x")); err == nil {
+		t.Error("expected error for missing subdirectory")
+	}
+	if _, err := FetchGit(GitSource{RepoURL: repoURL}, filepath.Join(t.TempDir(), "x")); err == nil {
+		t.Error("expected error for repository root without template.yaml")
+	}
+}
+
+func TestFetchGitRejectsOptions(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "pwned")
+
+	src, err := ParseGitSource("git+--upload-pack=touch " + marker + ";://x")
+	if err == nil {
+		t.Fatalf("ParseGitSource() of an --upload-pack source = %+v, want an error", src)
+	}
+	if _, err := FetchGit(GitSource{RepoURL: "--upload-pack=touch " + marker}, filepath.Join(t.TempDir(), "x")); err == nil {
+		t.Error("FetchGit() with an option as the URL should fail")
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Fatal("the --upload-pack command was run")
+	}
+
+	repo := gitRepo(t)
+	ref := GitSource{RepoURL: "file://" + filepath.ToSlash(repo), Subdir: "templates/python", Ref: "--orphan=x"}
+	if _, err := FetchGit(ref, filepath.Join(t.TempDir(), "x")); err == nil || !strings.Contains(err.Error(), "ref") {
+		t.Errorf("FetchGit() with an option as the ref error = %v", err)
+	}
+}
+
+func TestInstallGitTemplateRecordsSource(t *testing.T) {
+	repo := gitRepo(t)
+	parent := t.TempDir()
+	src := GitSource{RepoURL: "file://" + filepath.ToSlash(repo), Subdir: "templates/python", Ref: "v1.0.0"}
+
+	name, commit, err := InstallGitTemplate(src, parent)
+	if err != nil {
+		t.Fatalf("InstallGitTemplate error: %v", err)
+	}
+	if name != "python" {
+		t.Errorf("name = %q, want python", name)
+	}
+
+	info, err := ReadSourceInfo(filepath.Join(parent, "python"))
+	if err != nil || info == nil {
+		t.Fatalf("ReadSourceInfo = %v, %v", info, err)
+	}
+	want := SourceInfo{Source: src.String(), Ref: "v1.0.0", Commit: commit}
+	if *info != want {
+		t.Errorf("source info = %+v, want %+v", *info, want)
+	}
+
+	// A failed fetch leaves the existing install in place
+	bad := src
+	bad.Ref = "does-not-exist"
+	if _, _, err := InstallGitTemplate(bad, parent); err == nil {
+		t.Fatal("expected error for unknown ref")
+	}
+	if _, err := os.Stat(filepath.Join(parent, "python", "template.yaml")); err != nil {
+		t.Errorf("existing install was removed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(parent, ".python.staging")); !os.IsNotExist(err) {
+		t.Errorf("staging directory left behind")
+	}
+}
+
+func TestReadSourceInfoMissing(t *testing.T) {
+	info, err := ReadSourceInfo(t.TempDir())
+	if err != nil || info != nil {
+		t.Errorf("ReadSourceInfo on local template = %v, %v; want nil, nil", info, err)
+	}
+}
@@ -0,0 +1,132 @@
+package remote
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"forge/internal/template"
+)
+
+// stagingPath is where a template is prepared before being swapped into place
+func stagingPath(destParentDir, name string) string {
+	return filepath.Join(destParentDir, "."+name+".staging")
+}
+
+// BackupPath is where the previously installed version of a template is kept
+func BackupPath(destParentDir, name string) string {
+	return filepath.Join(destParentDir, "."+name+".backup")
+}
+
+// installStaged prepares a template in a sibling staging directory using
+// fill, validates it, then swaps it into destParentDir/name. The previous
+// version is kept at BackupPath. On any failure the existing install is
+// left untouched.
+func installStaged(destParentDir, name string, fill func(staging string) error) error {
+	staging := stagingPath(destParentDir, name)
+	if err := os.RemoveAll(staging); err != nil {
+		return fmt.Errorf("failed to clean staging directory: %w", err)
+	}
+
+	if err := fill(staging); err != nil {
+		os.RemoveAll(staging)
+		return err
+	}
+	if err := validateTemplateDir(staging); err != nil {
+		os.RemoveAll(staging)
+		return err
+	}
+
+	if err := swapIn(staging, destParentDir, name); err != nil {
+		os.RemoveAll(staging)
+		return err
+	}
+	return nil
+}
+
+// validateTemplateDir checks that dir holds a parseable template.yaml
+func validateTemplateDir(dir string) error {
+	data, err := os.ReadFile(filepath.Join(dir, "template.yaml"))
+	if err != nil {
+		return errors.New("invalid template: missing template.yaml")
+	}
+	if _, err := template.Parse(data); err != nil {
+		return fmt.Errorf("invalid template: %w", err)
+	}
+	return nil
+}
+
+// swapIn moves staging to destParentDir/name, moving any existing install
+// to the backup location first and restoring it if the move fails
+func swapIn(staging, destParentDir, name string) error {
+	destDir := filepath.Join(destParentDir, name)
+	backup := BackupPath(destParentDir, name)
+
+	hadPrevious := exists(destDir)
+	if hadPrevious {
+		if err := os.RemoveAll(backup); err != nil {
+			return fmt.Errorf("failed to remove old backup: %w", err)
+		}
+		if err := os.Rename(destDir, backup); err != nil {
+			return fmt.Errorf("failed to back up existing template: %w", err)
+		}
+	}
+
+	if err := os.Rename(staging, destDir); err != nil {
+		if hadPrevious {
+			os.Rename(backup, destDir)
+		}
+		return fmt.Errorf("failed to install template: %w", err)
+	}
+	return nil
+}
+
+// Rollback restores the previous version of an installed template from its
+// backup. The replaced version becomes the new backup, so rolling back
+// twice returns to where you started.
+func Rollback(destParentDir, name string) error {
+	destDir := filepath.Join(destParentDir, name)
+	backup := BackupPath(destParentDir, name)
+	if !exists(backup) {
+		return fmt.Errorf("no previous version of '%s' to roll back to", name)
+	}
+
+	current := filepath.Join(destParentDir, "."+name+".rollback")
+	if err := os.RemoveAll(current); err != nil {
+		return fmt.Errorf("failed to prepare rollback: %w", err)
+	}
+
+	hadCurrent := exists(destDir)
+	if hadCurrent {
+		if err := os.Rename(destDir, current); err != nil {
+			return fmt.Errorf("failed to move current template aside: %w", err)
+		}
+	}
+	if err := os.Rename(backup, destDir); err != nil {
+		if hadCurrent {
+			os.Rename(current, destDir)
+		}
+		return fmt.Errorf("failed to restore backup: %w", err)
+	}
+	if hadCurrent {
+		if err := os.Rename(current, backup); err != nil {
+			return fmt.Errorf("failed to keep replaced version as backup: %w", err)
+		}
+	}
+	return nil
+}
+
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+// InstallPrepared moves a template already prepared in dir, which must be
+// on the same filesystem, into destParentDir/name with the same validation
+// and backup as any other install
+func InstallPrepared(dir, destParentDir, name string) error {
+	return installStaged(destParentDir, name, func(staging string) error {
+		return os.Rename(dir, staging)
+	})
+}
@@ -0,0 +1,95 @@
+package remote
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func registryZip(t *testing.T, templateYAML string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "registry.zip")
+	writeZip(t, path, map[string]string{
+		"repo-main/python/template.yaml": templateYAML,
+		"repo-main/python/files/a.txt":   templateYAML,
+	})
+	return path
+}
+
+func readInstalled(t *testing.T, dir string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, "template.yaml"))
+	if err != nil {
+		t.Fatalf("read installed template: %v", err)
+	}
+	return string(data)
+}
+
+func TestInstallKeepsBackupAndRollsBack(t *testing.T) {
+	parent := t.TempDir()
+	v1 := "name: python\nversion: 1.0.0\n"
+	v2 := "name: python\nversion: 2.0.0\n"
+
+	if err := InstallSingleTemplate(registryZip(t, v1), "python", parent); err != nil {
+		t.Fatalf("install v1: %v", err)
+	}
+	if err := InstallSingleTemplate(registryZip(t, v2), "python", parent); err != nil {
+		t.Fatalf("install v2: %v", err)
+	}
+
+	dest := filepath.Join(parent, "python")
+	if got := readInstalled(t, dest); got != v2 {
+		t.Errorf("installed = %q, want v2", got)
+	}
+	if got := readInstalled(t, BackupPath(parent, "python")); got != v1 {
+		t.Errorf("backup = %q, want v1", got)
+	}
+
+	if err := Rollback(parent, "python"); err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+	if got := readInstalled(t, dest); got != v1 {
+		t.Errorf("after rollback installed = %q, want v1", got)
+	}
+	if got := readInstalled(t, BackupPath(parent, "python")); got != v2 {
+		t.Errorf("after rollback backup = %q, want v2", got)
+	}
+	if _, err := os.Stat(filepath.Join(parent, ".python.rollback")); !os.IsNotExist(err) {
+		t.Error("rollback scratch directory left behind")
+	}
+}
+
+func TestFailedInstallLeavesExistingTemplate(t *testing.T) {
+	parent := t.TempDir()
+	good := "name: python\nversion: 1.0.0\n"
+	if err := InstallSingleTemplate(registryZip(t, good), "python", parent); err != nil {
+		t.Fatalf("install: %v", err)
+	}
+
+	// An unknown key makes the template fail strict validation
+	err := InstallSingleTemplate(registryZip(t, "name: python\nbogus: true\n"), "python", parent)
+	if err == nil || !strings.Contains(err.Error(), "invalid template") {
+		t.Fatalf("install invalid template: error = %v, want invalid template", err)
+	}
+
+	if got := readInstalled(t, filepath.Join(parent, "python")); got != good {
+		t.Errorf("installed = %q, want the original template", got)
+	}
+	if _, err := os.Stat(stagingPath(parent, "python")); !os.IsNotExist(err) {
+		t.Error("staging directory left behind")
+	}
+	if _, err := os.Stat(BackupPath(parent, "python")); !os.IsNotExist(err) {
+		t.Error("failed install should not replace the backup")
+	}
+}
+
+func TestRollbackWithoutBackup(t *testing.T) {
+	parent := t.TempDir()
+	if err := InstallSingleTemplate(registryZip(t, "name: python\n"), "python", parent); err != nil {
+		t.Fatalf("install: %v", err)
+	}
+	if err := Rollback(parent, "python"); err == nil {
+		t.Error("expected error when there is no backup")
+	}
+}
@@ -0,0 +1,117 @@
+package remote
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"forge/internal/scaffold"
+	"forge/internal/template"
+)
+
+// archiveExtensions are the file extensions recognised as local template archives
+var archiveExtensions = []string{".zip", ".tar", ".tar.gz", ".tgz"}
+
+// IsLocalSource reports whether s names a local archive or directory rather
+// than a registry template: an archive file name, an absolute path, or a
+// path starting with ./ or ../
+func IsLocalSource(s string) bool {
+	if filepath.IsAbs(s) || hasArchiveExtension(s) {
+		return true
+	}
+	for _, prefix := range []string{"./", "../", ".\\", "..\\"} {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasArchiveExtension(s string) bool {
+	lower := strings.ToLower(s)
+	for _, ext := range archiveExtensions {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// InstallLocal installs templates from a local zip, tar or tar.gz archive or
+// a directory into destParentDir and returns their names.
+//
+// A source with template.yaml at its root is a single template, named after
+// its name field (or the file name when that is not a valid template name).
+// Otherwise every directory containing template.yaml is installed as a
+// template named after that directory.
+func InstallLocal(sourcePath, destParentDir string) ([]string, error) {
+	absPath, err := filepath.Abs(sourcePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", sourcePath, err)
+	}
+	info, err := os.Stat(absPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", sourcePath, err)
+	}
+
+	var a *archive
+	if info.IsDir() {
+		a, err = openDir(absPath)
+	} else {
+		a, err = openArchive(absPath)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer a.Close()
+
+	roots := a.templateRoots()
+	if len(roots) == 0 {
+		return nil, fmt.Errorf("invalid template: no template.yaml found in %s", sourcePath)
+	}
+
+	var installed []string
+	for _, root := range roots {
+		name := path.Base(strings.TrimSuffix(root, "/"))
+		if root == "" {
+			name = rootTemplateName(a, absPath)
+		}
+		if err := scaffold.ValidateName(name); err != nil {
+			return installed, fmt.Errorf("cannot install '%s': %w", root, err)
+		}
+
+		err := installStaged(destParentDir, name, func(staging string) error {
+			if err := extractPrefixedFiles(a, root, staging); err != nil {
+				return err
+			}
+			return WriteSourceInfo(staging, SourceInfo{Source: absPath})
+		})
+		if err != nil {
+			return installed, fmt.Errorf("failed to install '%s': %w", name, err)
+		}
+		installed = append(installed, name)
+	}
+	return installed, nil
+}
+
+// rootTemplateName names a template whose template.yaml is at the root of
+// the source: its declared name if usable, otherwise the file name without
+// its archive extension
+func rootTemplateName(a *archive, sourcePath string) string {
+	if data, err := a.readFile("template.yaml"); err == nil {
+		if tmpl, err := template.Parse(data); err == nil && scaffold.ValidateName(tmpl.Name) == nil {
+			return tmpl.Name
+		}
+	}
+
+	base := filepath.Base(sourcePath)
+	lower := strings.ToLower(base)
+	for _, ext := range archiveExtensions {
+		if strings.HasSuffix(lower, ext) {
+			return base[:len(base)-len(ext)]
+		}
+	}
+	return base
+}
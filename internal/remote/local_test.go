@@ -0,0 +1,237 @@
+package remote
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// writeZip writes a zip archive with the given files; names ending in "/" are directories
+func writeZip(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range sortedKeys(files) {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(files[name])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeTar writes a tar archive, gzip-compressed when compress is set
+func writeTar(t *testing.T, path string, files map[string]string, compress bool) {
+	t.Helper()
+	var buf bytes.Buffer
+	var gz *gzip.Writer
+	tw := tar.NewWriter(&buf)
+	if compress {
+		gz = gzip.NewWriter(&buf)
+		tw = tar.NewWriter(gz)
+	}
+	for _, name := range sortedKeys(files) {
+		hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(files[name])), Typeflag: tar.TypeReg}
+		if name[len(name)-1] == '/' {
+			hdr = &tar.Header{Name: name, Mode: 0755, Typeflag: tar.TypeDir}
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(files[name])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func assertInstalled(t *testing.T, parent string, got []string, want ...string) {
+	t.Helper()
+	sort.Strings(got)
+	sort.Strings(want)
+	if len(got) != len(want) {
+		t.Fatalf("installed %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("installed %v, want %v", got, want)
+		}
+		if _, err := os.Stat(filepath.Join(parent, want[i], "template.yaml")); err != nil {
+			t.Errorf("%s: template.yaml not installed: %v", want[i], err)
+		}
+	}
+}
+
+func TestIsLocalSource(t *testing.T) {
+	for _, s := range []string{"./python", "../shared/python", "python-template.zip", "t.tar.gz", "t.TGZ", "t.tar", filepath.Join(t.TempDir(), "x")} {
+		if !IsLocalSource(s) {
+			t.Errorf("IsLocalSource(%q) = false, want true", s)
+		}
+	}
+	for _, s := range []string{"python", "team/python", "git+https://host/repo"} {
+		if IsLocalSource(s) {
+			t.Errorf("IsLocalSource(%q) = true, want false", s)
+		}
+	}
+}
+
+func TestInstallLocalZipRootTemplate(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "python-template.zip")
+	writeZip(t, src, map[string]string{
+		"template.yaml":  "name: python\n",
+		"files/main.py":  "print('hi')\n",
+		"files/.gitkeep": "",
+	})
+
+	parent := t.TempDir()
+	installed, err := InstallLocal(src, parent)
+	if err != nil {
+		t.Fatalf("InstallLocal error: %v", err)
+	}
+	assertInstalled(t, parent, installed, "python")
+
+	if _, err := os.Stat(filepath.Join(parent, "python", "files", "main.py")); err != nil {
+		t.Errorf("template files not installed: %v", err)
+	}
+	info, err := ReadSourceInfo(filepath.Join(parent, "python"))
+	if err != nil || info == nil || info.Source != src {
+		t.Errorf("source info = %+v, %v; want source %s", info, err, src)
+	}
+}
+
+func TestInstallLocalRootTemplateFallsBackToFileName(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "my-template.tar.gz")
+	writeTar(t, src, map[string]string{"./template.yaml": "name: My Template\n"}, true)
+
+	parent := t.TempDir()
+	installed, err := InstallLocal(src, parent)
+	if err != nil {
+		t.Fatalf("InstallLocal error: %v", err)
+	}
+	assertInstalled(t, parent, installed, "my-template")
+}
+
+func TestInstallLocalTarGzCollection(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "templates.tar.gz")
+	writeTar(t, src, map[string]string{
+		"templates-main/":                         "",
+		"templates-main/python/template.yaml":     "name: python\n",
+		"templates-main/go/template.yaml":         "name: go\n",
+		"templates-main/go/files/template.yaml":   "not a nested template\n",
+		"templates-main/README.md":                "docs\n",
+		"templates-main/not-a-template/notes.txt": "x\n",
+	}, true)
+
+	parent := t.TempDir()
+	installed, err := InstallLocal(src, parent)
+	if err != nil {
+		t.Fatalf("InstallLocal error: %v", err)
+	}
+	assertInstalled(t, parent, installed, "python", "go")
+
+	if _, err := os.Stat(filepath.Join(parent, "go", "files", "template.yaml")); err != nil {
+		t.Errorf("nested file not installed with its template: %v", err)
+	}
+}
+
+func TestInstallLocalPlainTar(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "python.tar")
+	writeTar(t, src, map[string]string{"python/template.yaml": "name: python\n"}, false)
+
+	parent := t.TempDir()
+	installed, err := InstallLocal(src, parent)
+	if err != nil {
+		t.Fatalf("InstallLocal error: %v", err)
+	}
+	assertInstalled(t, parent, installed, "python")
+}
+
+func TestInstallLocalDirectory(t *testing.T) {
+	shared := filepath.Join(t.TempDir(), "shared")
+	for _, dir := range []string{"python", "node", ".git"} {
+		if err := os.MkdirAll(filepath.Join(shared, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.WriteFile(filepath.Join(shared, "python", "template.yaml"), []byte("name: python\n"), 0644)
+	os.WriteFile(filepath.Join(shared, "node", "template.yaml"), []byte("name: node\n"), 0644)
+	os.WriteFile(filepath.Join(shared, ".git", "template.yaml"), []byte("name: git\n"), 0644)
+
+	parent := t.TempDir()
+	installed, err := InstallLocal(shared, parent)
+	if err != nil {
+		t.Fatalf("InstallLocal error: %v", err)
+	}
+	assertInstalled(t, parent, installed, "python", "node")
+
+	// A directory that is itself a template installs under its own name
+	parent = t.TempDir()
+	installed, err = InstallLocal(filepath.Join(shared, "python"), parent)
+	if err != nil {
+		t.Fatalf("InstallLocal error: %v", err)
+	}
+	assertInstalled(t, parent, installed, "python")
+}
+
+func TestInstallLocalWithoutTemplate(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "empty.zip")
+	writeZip(t, src, map[string]string{"README.md": "nothing here\n"})
+
+	if _, err := InstallLocal(src, t.TempDir()); err == nil {
+		t.Error("expected error for archive without template.yaml")
+	}
+	if _, err := InstallLocal(filepath.Join(t.TempDir(), "missing.zip"), t.TempDir()); err == nil {
+		t.Error("expected error for missing archive")
+	}
+}
+
+func TestInstallSingleTemplateFromTarGz(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "registry.tar.gz")
+	writeTar(t, src, map[string]string{
+		"templates-main/python/template.yaml": "name: python\n",
+		"templates-main/go/template.yaml":     "name: go\n",
+	}, true)
+
+	parent := t.TempDir()
+	if err := InstallSingleTemplate(src, "python", parent); err != nil {
+		t.Fatalf("InstallSingleTemplate error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(parent, "python", "template.yaml")); err != nil {
+		t.Errorf("template not installed: %v", err)
+	}
+	if err := InstallSingleTemplate(src, "rust", parent); err != ErrTemplateNotFound {
+		t.Errorf("InstallSingleTemplate(rust) = %v, want ErrTemplateNotFound", err)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !unix
+
+package remote
+
+import "os"
+
+// oNoFollow is not available; the Lstat checks before opening still apply
+const oNoFollow = 0
+
+// ownedByOtherUser reports whether info belongs to someone other than the
+// current user. Without Unix ownership the per-user profile directory
+// holding downloads is relied on instead.
+func ownedByOtherUser(info os.FileInfo) bool {
+	return false
+}
@@ -0,0 +1,18 @@
+//go:build unix
+
+package remote
+
+import (
+	"os"
+	"syscall"
+)
+
+// oNoFollow makes opening a symlink fail instead of following it
+const oNoFollow = syscall.O_NOFOLLOW
+
+// ownedByOtherUser reports whether info belongs to someone other than the
+// current user
+func ownedByOtherUser(info os.FileInfo) bool {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	return ok && int(st.Uid) != os.Getuid()
+}
@@ -0,0 +1,307 @@
+package remote
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"forge/internal/cache"
+)
+
+// ProgressFunc receives download progress for label. total is -1 while the
+// size is unknown; the final call always has done == total.
+type ProgressFunc func(label string, done, total int64)
+
+// DownloadProgress, when set, is called as downloads advance
+var DownloadProgress ProgressFunc
+
+// progressReader reports bytes read through DownloadProgress
+type progressReader struct {
+	r     io.Reader
+	label string
+	done  int64
+	total int64
+}
+
+func newProgressReader(r io.Reader, label string, done, total int64) *progressReader {
+	p := &progressReader{r: r, label: label, done: done, total: total}
+	p.report()
+	return p
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.done += int64(n)
+	if n > 0 {
+		p.report()
+	}
+	return n, err
+}
+
+func (p *progressReader) report() {
+	if DownloadProgress != nil && (p.total < 0 || p.done < p.total) {
+		DownloadProgress(p.label, p.done, p.total)
+	}
+}
+
+// finish sends the final progress update
+func (p *progressReader) finish() {
+	if DownloadProgress != nil {
+		DownloadProgress(p.label, p.done, p.done)
+	}
+}
+
+// errNotModified is returned by downloadResumable when a conditional
+// request finds the caller's copy still current
+var errNotModified = errors.New("not modified")
+
+// httpStatusError is an unexpected HTTP status, as opposed to a network error
+type httpStatusError struct {
+	code int
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("http %d", e.code)
+}
+
+// staleLockAge is how long a partial download may go untouched before its
+// lock is considered left behind by a process that died
+const staleLockAge = 10 * time.Minute
+
+// downloadResumable downloads url to dest. Data is written to part first;
+// if a previous attempt left it behind, the download continues from where
+// it stopped with a Range request, provided the server still serves the
+// same content (checked with If-Range) and supports ranges. header may add
+// conditional headers; a 304 response returns errNotModified. It returns
+// the response headers.
+//
+// part is locked while in use. When another process holds the lock, the
+// download goes to a private file instead and is not resumable.
+func downloadResumable(url, part, dest, label string, header http.Header) (http.Header, error) {
+	unlock, locked := lockPartial(part)
+	if !locked {
+		private, err := os.CreateTemp(filepath.Dir(part), filepath.Base(part)+".*")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create download file: %w", err)
+		}
+		private.Close()
+		defer os.Remove(private.Name())
+		return fetchPartial(url, private.Name(), dest, label, header, false)
+	}
+	defer unlock()
+	return fetchPartial(url, part, dest, label, header, true)
+}
+
+// fetchPartial does the work of downloadResumable with part locked.
+// Without resume, part is always started over and no validator is kept.
+func fetchPartial(url, part, dest, label string, header http.Header, resume bool) (http.Header, error) {
+	validatorPath := part + ".validator"
+
+	var offset int64
+	reqHeader := header.Clone()
+	if reqHeader == nil {
+		reqHeader = http.Header{}
+	}
+	if info, err := os.Lstat(part); resume && err == nil && info.Size() > 0 {
+		if v, err := readPrivate(validatorPath); err == nil && len(v) > 0 {
+			offset = info.Size()
+			reqHeader.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+			reqHeader.Set("If-Range", string(v))
+		}
+	}
+
+	resp, err := DefaultClient().Get(url, reqHeader)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	switch {
+	case resp.StatusCode == http.StatusNotModified:
+		return resp.Header, errNotModified
+	case resp.StatusCode == http.StatusPartialContent && offset > 0 && contentRangeStart(resp) == offset:
+		flags |= os.O_APPEND
+	case offset > 0 && (resp.StatusCode == http.StatusPartialContent || resp.StatusCode == http.StatusRequestedRangeNotSatisfiable):
+		// The partial file cannot be resumed; start over
+		os.Remove(part)
+		os.Remove(validatorPath)
+		return fetchPartial(url, part, dest, label, header, resume)
+	case resp.StatusCode >= 200 && resp.StatusCode < 300 && resp.StatusCode != http.StatusPartialContent:
+		// The server ignored the range or the file changed
+		flags |= os.O_TRUNC
+		offset = 0
+	default:
+		return nil, &httpStatusError{code: resp.StatusCode}
+	}
+
+	if offset == 0 && resume {
+		if err := saveValidator(validatorPath, resp); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := openPrivate(part, flags)
+	if err != nil {
+		return nil, err
+	}
+
+	total := int64(-1)
+	if resp.ContentLength >= 0 {
+		total = offset + resp.ContentLength
+	}
+	pr := newProgressReader(resp.Body, label, offset, total)
+	_, err = io.Copy(f, pr)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		// Keep the partial file so the next attempt can resume
+		return nil, fmt.Errorf("download interrupted: %w", err)
+	}
+	if total >= 0 && pr.done != total {
+		return nil, fmt.Errorf("download interrupted: got %d of %d bytes", pr.done, total)
+	}
+	pr.finish()
+
+	os.Remove(validatorPath)
+	if err := os.Rename(part, dest); err != nil {
+		return nil, fmt.Errorf("failed to move download into place: %w", err)
+	}
+	return resp.Header, nil
+}
+
+// lockPartial takes the lock guarding a partial download. A lock is taken
+// over when neither it nor the partial file has changed for staleLockAge.
+func lockPartial(part string) (unlock func(), ok bool) {
+	lockPath := part + ".lock"
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY|oNoFollow, 0600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(lockPath) }, true
+		}
+		if !errors.Is(err, os.ErrExist) || !lockIsStale(lockPath, part) {
+			return nil, false
+		}
+		os.Remove(lockPath)
+	}
+	return nil, false
+}
+
+func lockIsStale(lockPath, part string) bool {
+	for _, p := range []string{lockPath, part} {
+		if info, err := os.Lstat(p); err == nil && time.Since(info.ModTime()) < staleLockAge {
+			return false
+		}
+	}
+	return true
+}
+
+// checkPrivate refuses a download file another user could have planted:
+// a symlink, something other than a regular file, or a file they own
+func checkPrivate(path string) error {
+	info, err := os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() || ownedByOtherUser(info) {
+		return fmt.Errorf("refusing to use %s: not a regular file owned by the current user", path)
+	}
+	return nil
+}
+
+// openPrivate opens a download file for writing, readable by its owner only
+func openPrivate(path string, flags int) (*os.File, error) {
+	if err := checkPrivate(path); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, flags|oNoFollow, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	return f, nil
+}
+
+func readPrivate(path string) ([]byte, error) {
+	if err := checkPrivate(path); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(path)
+}
+
+// saveValidator records the ETag (or Last-Modified date) of a fresh download
+// so an interrupted transfer can later be resumed safely
+func saveValidator(path string, resp *http.Response) error {
+	v := resp.Header.Get("ETag")
+	if v == "" || strings.HasPrefix(v, "W/") {
+		// Weak ETags cannot be used with If-Range
+		v = resp.Header.Get("Last-Modified")
+	}
+	if v == "" || resp.Header.Get("Accept-Ranges") == "none" {
+		err := os.Remove(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	f, err := openPrivate(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write([]byte(v))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// downloadDir returns the per-user directory holding downloads in
+// progress (~/.forge/cache/downloads), accessible by its owner only
+func downloadDir() (string, error) {
+	base, err := cache.DefaultDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(base, "downloads")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create download directory: %w", err)
+	}
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() || ownedByOtherUser(info) {
+		return "", fmt.Errorf("refusing to use download directory %s: not a directory owned by the current user", dir)
+	}
+	if info.Mode().Perm() != 0700 {
+		if err := os.Chmod(dir, 0700); err != nil {
+			return "", fmt.Errorf("failed to restrict download directory: %w", err)
+		}
+	}
+	return dir, nil
+}
+
+// contentRangeStart returns the first byte position of a 206 response
+func contentRangeStart(resp *http.Response) int64 {
+	// Content-Range: bytes 100-999/1000
+	cr := strings.TrimPrefix(resp.Header.Get("Content-Range"), "bytes ")
+	start, _, ok := strings.Cut(cr, "-")
+	if !ok {
+		return -1
+	}
+	n, err := strconv.ParseInt(start, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
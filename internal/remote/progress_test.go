@@ -0,0 +1,199 @@
+package remote
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rangeServer serves content with ETag and Range support and records the
+// Range header of each request
+func rangeServer(t *testing.T, content []byte, etag string, ranges *[]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*ranges = append(*ranges, r.Header.Get("Range"))
+		w.Header().Set("ETag", etag)
+		http.ServeContent(w, r, "file", time.Time{}, bytes.NewReader(content))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestDownloadResumesPartialFile(t *testing.T) {
+	content := []byte(strings.Repeat("forge-", 1000))
+	var ranges []string
+	srv := rangeServer(t, content, `"v1"`, &ranges)
+
+	dest := filepath.Join(t.TempDir(), "forge.bin")
+	if err := os.WriteFile(dest+".part", content[:2000], 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dest+".part.validator", []byte(`"v1"`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var calls [][2]int64
+	DownloadProgress = func(label string, done, total int64) { calls = append(calls, [2]int64{done, total}) }
+	defer func() { DownloadProgress = nil }()
+
+	if err := DownloadReleaseBinary(srv.URL, dest); err != nil {
+		t.Fatalf("DownloadReleaseBinary() error = %v", err)
+	}
+
+	if len(ranges) != 1 || ranges[0] != "bytes=2000-" {
+		t.Errorf("Range headers = %q, want a single bytes=2000-", ranges)
+	}
+	got, _ := os.ReadFile(dest)
+	if !bytes.Equal(got, content) {
+		t.Errorf("downloaded %d bytes, want the original %d", len(got), len(content))
+	}
+	if _, err := os.Stat(dest + ".part"); !os.IsNotExist(err) {
+		t.Error("partial file should be removed after completion")
+	}
+
+	if len(calls) == 0 || calls[0][0] != 2000 {
+		t.Errorf("progress should start at the resumed offset, got %v", calls)
+	}
+	last := calls[len(calls)-1]
+	if last[0] != int64(len(content)) || last[1] != int64(len(content)) {
+		t.Errorf("final progress = %v, want done == total == %d", last, len(content))
+	}
+}
+
+func TestDownloadRestartsWhenContentChanged(t *testing.T) {
+	content := []byte(strings.Repeat("new-", 500))
+	var ranges []string
+	srv := rangeServer(t, content, `"v2"`, &ranges)
+
+	dest := filepath.Join(t.TempDir(), "forge.bin")
+	os.WriteFile(dest+".part", []byte("stale partial data"), 0644)
+	os.WriteFile(dest+".part.validator", []byte(`"v1"`), 0644)
+
+	if err := DownloadReleaseBinary(srv.URL, dest); err != nil {
+		t.Fatalf("DownloadReleaseBinary() error = %v", err)
+	}
+	got, _ := os.ReadFile(dest)
+	if !bytes.Equal(got, content) {
+		t.Errorf("stale partial data must be discarded, got %q...", got[:20])
+	}
+}
+
+func TestDownloadKeepsPartialOnInterruption(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Length", "1000")
+		w.Write([]byte("only part of it"))
+	}))
+	defer srv.Close()
+
+	dest := filepath.Join(t.TempDir(), "forge.bin")
+	if err := DownloadReleaseBinary(srv.URL, dest); err == nil {
+		t.Fatal("a short body should fail the download")
+	}
+	if data, _ := os.ReadFile(dest + ".part"); string(data) != "only part of it" {
+		t.Errorf("partial file = %q, want the received bytes kept for resuming", data)
+	}
+	if v, _ := os.ReadFile(dest + ".part.validator"); string(v) != `"v1"` {
+		t.Errorf("validator = %q, want the ETag", v)
+	}
+}
+
+func TestDownloadLockedPartialIsLeftAlone(t *testing.T) {
+	content := []byte(strings.Repeat("fresh-", 300))
+	var ranges []string
+	srv := rangeServer(t, content, `"v1"`, &ranges)
+
+	// Another process is downloading into the same partial file
+	dest := filepath.Join(t.TempDir(), "forge.bin")
+	os.WriteFile(dest+".part", []byte("theirs"), 0600)
+	os.WriteFile(dest+".part.validator", []byte(`"v1"`), 0600)
+	os.WriteFile(dest+".part.lock", nil, 0600)
+
+	if err := DownloadReleaseBinary(srv.URL, dest); err != nil {
+		t.Fatalf("DownloadReleaseBinary() error = %v", err)
+	}
+	if got, _ := os.ReadFile(dest); !bytes.Equal(got, content) {
+		t.Errorf("downloaded %q..., want the full content", got[:10])
+	}
+	if len(ranges) != 1 || ranges[0] != "" {
+		t.Errorf("Range headers = %q, a locked partial file must not be resumed", ranges)
+	}
+	if got, _ := os.ReadFile(dest + ".part"); string(got) != "theirs" {
+		t.Errorf("locked partial file = %q, should be untouched", got)
+	}
+
+	// A lock left behind by a process that died is taken over
+	old := time.Now().Add(-2 * staleLockAge)
+	for _, p := range []string{dest + ".part", dest + ".part.lock"} {
+		os.Chtimes(p, old, old)
+	}
+	ranges = nil
+	if err := DownloadReleaseBinary(srv.URL, dest); err != nil {
+		t.Fatalf("DownloadReleaseBinary() with a stale lock error = %v", err)
+	}
+	if len(ranges) != 1 || ranges[0] != "bytes=6-" {
+		t.Errorf("Range headers = %q, want the stale partial file resumed", ranges)
+	}
+	if _, err := os.Stat(dest + ".part.lock"); !os.IsNotExist(err) {
+		t.Error("the lock should be released after the download")
+	}
+}
+
+func TestDownloadRefusesSymlinkedPartial(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need extra privileges on Windows")
+	}
+	var ranges []string
+	srv := rangeServer(t, []byte("payload"), `"v1"`, &ranges)
+
+	dir := t.TempDir()
+	victim := filepath.Join(dir, "victim")
+	os.WriteFile(victim, []byte("precious"), 0600)
+	dest := filepath.Join(dir, "forge.bin")
+	if err := os.Symlink(victim, dest+".part"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := DownloadReleaseBinary(srv.URL, dest); err == nil {
+		t.Fatal("a symlinked partial file should be refused")
+	}
+	if got, _ := os.ReadFile(victim); string(got) != "precious" {
+		t.Errorf("symlink target = %q, must not be written through", got)
+	}
+}
+
+func TestDownloadRepoZipUsesPrivateDirectory(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	var ranges []string
+	srv := rangeServer(t, []byte("zip bytes"), `"v1"`, &ranges)
+
+	first, err := DownloadRepoZip(srv.URL + "/main.zip")
+	if err != nil {
+		t.Fatalf("DownloadRepoZip() error = %v", err)
+	}
+	defer os.Remove(first)
+	second, err := DownloadRepoZip(srv.URL + "/main.zip")
+	if err != nil {
+		t.Fatalf("DownloadRepoZip() error = %v", err)
+	}
+	defer os.Remove(second)
+
+	if first == second {
+		t.Error("each download should get its own file")
+	}
+	dir := filepath.Dir(first)
+	if !strings.HasPrefix(dir, home) {
+		t.Errorf("download directory = %s, want one inside the user's forge directory", dir)
+	}
+	if info, err := os.Stat(dir); err != nil || (runtime.GOOS != "windows" && info.Mode().Perm() != 0700) {
+		t.Errorf("download directory mode = %v, %v, want 0700", info.Mode(), err)
+	}
+}
@@ -2,93 +2,131 @@ package remote
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"os"
+	"net/url"
+	"path/filepath"
 	"runtime"
+	"strings"
 )
 
+// DefaultGitHubAPIBase is the root of the public GitHub REST API
+const DefaultGitHubAPIBase = "https://api.github.com"
+
+// GitHubAPIBase is the root of the GitHub REST API used for releases. It
+// can point at GitHub Enterprise (https://host/api/v3) or a stand-in server.
+var GitHubAPIBase = DefaultGitHubAPIBase
+
+// ErrReleaseNotFound is returned when a requested release tag does not exist
+var ErrReleaseNotFound = errors.New("release not found")
+
 // GitHubRelease represents a GitHub release API response (simplified)
 type GitHubRelease struct {
-	TagName string `json:"tag_name"`
-	Assets  []struct {
-		Name        string `json:"name"`
-		DownloadURL string `json:"browser_download_url"`
-	} `json:"assets"`
+	TagName    string        `json:"tag_name"`
+	Body       string        `json:"body"` // release notes, in Markdown
+	Draft      bool          `json:"draft"`
+	Prerelease bool          `json:"prerelease"`
+	Assets     []GitHubAsset `json:"assets"`
 }
 
-// FetchLatestReleaseVersion queries GitHub Releases API to get the latest version
-// and returns the version tag and Windows binary download URL
-func FetchLatestReleaseVersion(owner, repo string) (version string, downloadURL string, err error) {
-	apiURL := fmt.Sprintf("https://api.github.com/repos/%s/%s/releases/latest", owner, repo)
-
-	resp, err := http.Get(apiURL)
-	if err != nil {
-		return "", "", fmt.Errorf("failed to fetch releases from GitHub: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return "", "", fmt.Errorf("failed to fetch releases from GitHub: http %d", resp.StatusCode)
-	}
+// GitHubAsset is a file attached to a release
+type GitHubAsset struct {
+	Name        string `json:"name"`
+	DownloadURL string `json:"browser_download_url"`
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", "", fmt.Errorf("failed to read response body: %w", err)
+// Asset returns the release asset with the given name
+func (r *GitHubRelease) Asset(name string) (GitHubAsset, bool) {
+	for _, a := range r.Assets {
+		if a.Name == name {
+			return a, true
+		}
 	}
+	return GitHubAsset{}, false
+}
 
+// FetchLatestRelease queries the GitHub Releases API for the latest
+// stable release
+func FetchLatestRelease(owner, repo string) (*GitHubRelease, error) {
 	var release GitHubRelease
-	if err := json.Unmarshal(body, &release); err != nil {
-		return "", "", fmt.Errorf("failed to parse GitHub release response: %w", err)
+	if err := getGitHubJSON(fmt.Sprintf("repos/%s/%s/releases/latest", owner, repo), &release); err != nil {
+		return nil, err
 	}
-
 	if release.TagName == "" {
-		return "", "", fmt.Errorf("no releases found")
+		return nil, fmt.Errorf("no releases found")
 	}
+	return &release, nil
+}
 
-	// Find Windows binary asset
-	expectedAsset := "forge.exe"
-	switch runtime.GOOS {
-	case "darwin":
-		expectedAsset = "forge-darwin"
-	case "linux":
-		expectedAsset = "forge-linux"
+// FetchReleases returns the most recent releases, newest first, including
+// pre-releases and drafts visible to the caller
+func FetchReleases(owner, repo string) ([]GitHubRelease, error) {
+	var releases []GitHubRelease
+	if err := getGitHubJSON(fmt.Sprintf("repos/%s/%s/releases?per_page=100", owner, repo), &releases); err != nil {
+		return nil, err
 	}
+	return releases, nil
+}
 
-	for _, asset := range release.Assets {
-		if asset.Name == expectedAsset {
-			return release.TagName, asset.DownloadURL, nil
-		}
+// FetchReleaseByTag returns the release for tag, or ErrReleaseNotFound
+func FetchReleaseByTag(owner, repo, tag string) (*GitHubRelease, error) {
+	var release GitHubRelease
+	err := getGitHubJSON(fmt.Sprintf("repos/%s/%s/releases/tags/%s", owner, repo, url.PathEscape(tag)), &release)
+	if err != nil {
+		return nil, err
 	}
-
-	return "", "", fmt.Errorf("no suitable binary found for %s in release %s", runtime.GOOS, release.TagName)
+	return &release, nil
 }
 
-// DownloadReleaseBinary downloads a binary from the given URL to a temporary file
-// Returns the path to the downloaded file. Caller must remove the file.
-func DownloadReleaseBinary(downloadURL, tempPath string) error {
-	resp, err := http.Get(downloadURL)
+// getGitHubJSON fetches path below GitHubAPIBase and decodes the response
+func getGitHubJSON(path string, v any) error {
+	apiURL := strings.TrimSuffix(GitHubAPIBase, "/") + "/" + path
+
+	resp, err := DefaultClient().Get(apiURL, http.Header{"Accept": {"application/vnd.github+json"}})
 	if err != nil {
-		return fmt.Errorf("failed to download binary: %w", err)
+		return fmt.Errorf("failed to fetch releases from GitHub: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrReleaseNotFound
+	}
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("failed to download binary: http %d", resp.StatusCode)
+		return fmt.Errorf("failed to fetch releases from GitHub: http %d", resp.StatusCode)
 	}
 
-	// Create temp file
-	tmp, err := os.Create(tempPath)
+	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("failed to create temp file for binary: %w", err)
+		return fmt.Errorf("failed to read response body: %w", err)
 	}
-	defer tmp.Close()
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to parse GitHub release response: %w", err)
+	}
+	return nil
+}
 
-	if _, err := io.Copy(tmp, resp.Body); err != nil {
-		os.Remove(tempPath)
-		return fmt.Errorf("failed to write binary to temp file: %w", err)
+// FetchLatestReleaseVersion queries GitHub Releases API to get the latest version
+// and returns the version tag and the binary download URL for this platform
+func FetchLatestReleaseVersion(owner, repo string) (version string, downloadURL string, err error) {
+	release, err := FetchLatestRelease(owner, repo)
+	if err != nil {
+		return "", "", err
+	}
+	asset, err := SelectBinaryAsset(release, runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		return "", "", err
 	}
+	return release.TagName, asset.DownloadURL, nil
+}
 
+// DownloadReleaseBinary downloads a binary from the given URL to tempPath.
+// Caller must remove the file. An interrupted download is resumed by the
+// next call with the same tempPath.
+func DownloadReleaseBinary(downloadURL, tempPath string) error {
+	if _, err := downloadResumable(downloadURL, tempPath+".part", tempPath, filepath.Base(tempPath), nil); err != nil {
+		return fmt.Errorf("failed to download binary: %w", err)
+	}
 	return nil
 }
@@ -0,0 +1,68 @@
+package remote
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SourceFile is written into an installed template directory to record
+// where the template came from
+const SourceFile = ".forge-source.yaml"
+
+// SourceInfo describes the origin of an installed template
+type SourceInfo struct {
+	Source string `yaml:"source"`           // e.g. git+https://host/org/repo//python@v1
+	Ref    string `yaml:"ref,omitempty"`    // ref as requested
+	Commit string `yaml:"commit,omitempty"` // resolved commit SHA for git sources
+}
+
+// WriteSourceInfo records info in templateDir
+func WriteSourceInfo(templateDir string, info SourceInfo) error {
+	data, err := yaml.Marshal(info)
+	if err != nil {
+		return fmt.Errorf("failed to encode source info: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(templateDir, SourceFile), data, 0644); err != nil {
+		return fmt.Errorf("failed to write source info: %w", err)
+	}
+	return nil
+}
+
+// ReadSourceInfo reads the source info of an installed template. It returns
+// nil without error when the template has none (e.g. a local template).
+func ReadSourceInfo(templateDir string) (*SourceInfo, error) {
+	data, err := os.ReadFile(filepath.Join(templateDir, SourceFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source info: %w", err)
+	}
+
+	info := &SourceInfo{}
+	if err := yaml.Unmarshal(data, info); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", SourceFile, err)
+	}
+	return info, nil
+}
+
+// InstallGitTemplate fetches a git template into destParentDir/<name>,
+// replacing any existing install only once the fetch has succeeded
+func InstallGitTemplate(src GitSource, destParentDir string) (name, commit string, err error) {
+	name = src.TemplateName()
+	err = installStaged(destParentDir, name, func(staging string) error {
+		commit, err = FetchGit(src, staging)
+		if err != nil {
+			return err
+		}
+		return WriteSourceInfo(staging, SourceInfo{Source: src.String(), Ref: src.Ref, Commit: commit})
+	})
+	if err != nil {
+		return "", "", err
+	}
+	return name, commit, nil
+}
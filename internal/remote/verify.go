@@ -0,0 +1,155 @@
+package remote
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ErrChecksumMismatch is returned when a file does not match its manifest digest
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// ErrBadSignature is returned when a manifest signature does not verify
+var ErrBadSignature = errors.New("signature verification failed")
+
+// Manifest maps file names to SHA-256 digests. It is read from and written
+// in the sha256sum format, one "<hex digest>  <file name>" per line.
+type Manifest map[string]string
+
+// ParseManifest parses a sha256sum-style manifest. Blank lines and lines
+// starting with # are ignored.
+func ParseManifest(data []byte) (Manifest, error) {
+	m := Manifest{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("manifest line %d: expected '<sha256>  <file>'", n)
+		}
+		digest := strings.ToLower(fields[0])
+		// sha256sum marks binary mode with a leading '*'
+		name := strings.TrimPrefix(fields[1], "*")
+		if _, err := hex.DecodeString(digest); err != nil || len(digest) != sha256.Size*2 {
+			return nil, fmt.Errorf("manifest line %d: invalid SHA-256 digest", n)
+		}
+		m[name] = digest
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	return m, nil
+}
+
+// Bytes renders the manifest in sha256sum format, sorted by file name
+func (m Manifest) Bytes() []byte {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	for _, name := range names {
+		fmt.Fprintf(&buf, "%s  %s\n", m[name], name)
+	}
+	return buf.Bytes()
+}
+
+// Verify checks the file at path against the digest listed for name
+func (m Manifest) Verify(name, path string) error {
+	want, ok := m[name]
+	if !ok {
+		return fmt.Errorf("%w: %s is not listed in the manifest", ErrChecksumMismatch, name)
+	}
+	got, err := FileSHA256(path)
+	if err != nil {
+		return err
+	}
+	if got != want {
+		return fmt.Errorf("%w for %s: expected %s, got %s", ErrChecksumMismatch, name, want, got)
+	}
+	return nil
+}
+
+// FileSHA256 returns the hex SHA-256 digest of a file
+func FileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ParsePublicKey parses a base64-encoded ed25519 public key, optionally
+// prefixed with "ed25519:"
+func ParsePublicKey(s string) (ed25519.PublicKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(strings.TrimSpace(s), "ed25519:"))
+	if err != nil || len(raw) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid ed25519 public key: expected %d base64-encoded bytes", ed25519.PublicKeySize)
+	}
+	return ed25519.PublicKey(raw), nil
+}
+
+// ParseSignature reads a detached signature file: the first line that is
+// not a comment holds the base64-encoded ed25519 signature. Lines starting
+// with "untrusted comment:", "trusted comment:" or # are comments.
+func ParseSignature(data []byte) ([]byte, error) {
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") ||
+			strings.HasPrefix(line, "untrusted comment:") || strings.HasPrefix(line, "trusted comment:") {
+			continue
+		}
+		sig, err := base64.StdEncoding.DecodeString(line)
+		if err != nil || len(sig) != ed25519.SignatureSize {
+			return nil, fmt.Errorf("invalid signature: expected %d base64-encoded bytes", ed25519.SignatureSize)
+		}
+		return sig, nil
+	}
+	return nil, errors.New("invalid signature: file is empty")
+}
+
+// VerifySignature checks that sigFile is a valid signature of data by any
+// of the trusted keys
+func VerifySignature(data, sigFile []byte, keys []ed25519.PublicKey) error {
+	if len(keys) == 0 {
+		return fmt.Errorf("%w: no trusted keys configured", ErrBadSignature)
+	}
+	sig, err := ParseSignature(sigFile)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrBadSignature, err)
+	}
+	for _, key := range keys {
+		if ed25519.Verify(key, data, sig) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: not signed by any trusted key", ErrBadSignature)
+}
+
+// SignManifest returns a signature file for data, the counterpart of
+// ParseSignature
+func SignManifest(data []byte, key ed25519.PrivateKey) []byte {
+	sig := ed25519.Sign(key, data)
+	return []byte("untrusted comment: forge signature\n" + base64.StdEncoding.EncodeToString(sig) + "\n")
+}
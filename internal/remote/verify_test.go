@@ -0,0 +1,93 @@
+package remote
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseManifest(t *testing.T) {
+	digest := hex.EncodeToString(make([]byte, sha256.Size))
+	data := []byte("# checksums\n" + digest + "  templates.zip\n\n" + digest + " *forge.tar.gz\n")
+
+	m, err := ParseManifest(data)
+	if err != nil {
+		t.Fatalf("ParseManifest() error = %v", err)
+	}
+	if m["templates.zip"] != digest || m["forge.tar.gz"] != digest || len(m) != 2 {
+		t.Errorf("ParseManifest() = %v", m)
+	}
+
+	round, err := ParseManifest(m.Bytes())
+	if err != nil || len(round) != 2 {
+		t.Errorf("Bytes() did not round-trip: %v, %v", round, err)
+	}
+
+	for _, bad := range []string{"nothex  a.zip\n", digest + "\n", "abcd  a.zip\n"} {
+		if _, err := ParseManifest([]byte(bad)); err == nil {
+			t.Errorf("ParseManifest(%q) expected error", bad)
+		}
+	}
+}
+
+func TestManifestVerify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "templates.zip")
+	if err := os.WriteFile(path, []byte("archive bytes"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte("archive bytes"))
+	m := Manifest{"templates.zip": hex.EncodeToString(sum[:])}
+
+	if err := m.Verify("templates.zip", path); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+
+	os.WriteFile(path, []byte("tampered bytes"), 0644)
+	if err := m.Verify("templates.zip", path); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Verify() tampered error = %v, want ErrChecksumMismatch", err)
+	}
+	if err := m.Verify("other.zip", path); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Verify() unlisted error = %v, want ErrChecksumMismatch", err)
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPub, _, _ := ed25519.GenerateKey(nil)
+
+	key, err := ParsePublicKey("ed25519:" + base64.StdEncoding.EncodeToString(pub))
+	if err != nil {
+		t.Fatalf("ParsePublicKey() error = %v", err)
+	}
+
+	data := []byte("manifest contents\n")
+	sig := SignManifest(data, priv)
+
+	if err := VerifySignature(data, sig, []ed25519.PublicKey{otherPub, key}); err != nil {
+		t.Errorf("VerifySignature() error = %v", err)
+	}
+	if err := VerifySignature([]byte("changed\n"), sig, []ed25519.PublicKey{key}); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("VerifySignature() changed data error = %v, want ErrBadSignature", err)
+	}
+	if err := VerifySignature(data, sig, []ed25519.PublicKey{otherPub}); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("VerifySignature() untrusted key error = %v, want ErrBadSignature", err)
+	}
+	if err := VerifySignature(data, sig, nil); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("VerifySignature() no keys error = %v, want ErrBadSignature", err)
+	}
+	if err := VerifySignature(data, []byte("garbage"), []ed25519.PublicKey{key}); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("VerifySignature() garbage error = %v, want ErrBadSignature", err)
+	}
+
+	if _, err := ParsePublicKey("not a key"); err == nil {
+		t.Error("ParsePublicKey() expected error for invalid key")
+	}
+}
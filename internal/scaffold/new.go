@@ -6,6 +6,8 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+
+	"forge/internal/template"
 )
 
 // Compile regex pattern once at package level
@@ -96,12 +98,14 @@ func (g *Generator) Generate(templateName string) (string, error) {
 }
 
 func generateTemplateYAML(name string) string {
-	return fmt.Sprintf(`# Template: %s
+	return fmt.Sprintf(`%s
+# Template: %s
 # Generated by: forge new
 # 
 # This template creates new %s projects.
 # Customize the metadata, commands, and files sections below.
 
+apiVersion: %s
 name: %s
 description: "A brief description of your template"
 version: "1.0.0"
@@ -134,7 +138,7 @@ files:
     #   source: "patches/gitignore.append"
     # - target: "package.json"
     #   source: "patches/package.json.append"
-`, name, name, name)
+`, template.SchemaModeline, name, name, template.CurrentAPIVersion, name)
 }
 
 func generateReadme(name string) string {
@@ -3,7 +3,10 @@ package scaffold
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"forge/internal/template"
 )
 
 func TestValidateName(t *testing.T) {
@@ -160,3 +163,26 @@ func TestGenerateInvalidName(t *testing.T) {
 func contains(s, substr string) bool {
 	return len(s) >= len(substr)
 }
+
+func TestGenerateSchemaModeline(t *testing.T) {
+	gen := New(t.TempDir())
+
+	templateDir, err := gen.Generate("schema-check")
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(templateDir, "template.yaml"))
+	if err != nil {
+		t.Fatalf("ReadFile error = %v", err)
+	}
+
+	if !strings.HasPrefix(string(data), template.SchemaModeline+"\n") {
+		t.Errorf("template.yaml does not start with the schema modeline:\n%s", data)
+	}
+
+	// The scaffold must pass strict parsing as generated
+	if _, err := template.Parse(data); err != nil {
+		t.Errorf("generated template.yaml does not parse: %v", err)
+	}
+}
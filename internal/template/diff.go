@@ -0,0 +1,88 @@
+package template
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Diff summarises what changes between two versions of a template, one
+// line per change, e.g. "version: 1.0.0 -> 1.1.0" or "+ command: npm ci".
+// Commands are listed in full because they are what forge init will run.
+func Diff(from, to *Template) []string {
+	var changes []string
+	field := func(name, a, b string) {
+		if a != b {
+			changes = append(changes, fmt.Sprintf("%s: %s -> %s", name, orNone(a), orNone(b)))
+		}
+	}
+
+	field("version", from.Version, to.Version)
+	field("apiVersion", from.APIVersion, to.APIVersion)
+	field("min_forge_version", from.MinForgeVersion, to.MinForgeVersion)
+	if from.Description != to.Description {
+		changes = append(changes, "description changed")
+	}
+	field("tags", strings.Join(from.Tags, ", "), strings.Join(to.Tags, ", "))
+
+	changes = append(changes, diffLists("command", commandStrings(from.Commands), commandStrings(to.Commands))...)
+	changes = append(changes, diffLists("copy", from.Files.Copy, to.Files.Copy)...)
+	changes = append(changes, diffLists("append", appendStrings(from.Files.Append), appendStrings(to.Files.Append))...)
+	return changes
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
+
+func commandStrings(cmds []Command) []string {
+	out := make([]string, len(cmds))
+	for i, c := range cmds {
+		out[i] = c.String()
+		if c.Interactive {
+			out[i] += " (interactive)"
+		}
+	}
+	return out
+}
+
+func appendStrings(patches []AppendPatch) []string {
+	out := make([]string, len(patches))
+	for i, p := range patches {
+		out[i] = p.Source + " >> " + p.Target
+	}
+	return out
+}
+
+// diffLists reports items removed from and added to a list. When only the
+// order differs it says so, since command order matters.
+func diffLists(label string, from, to []string) []string {
+	count := map[string]int{}
+	for _, s := range from {
+		count[s]++
+	}
+	for _, s := range to {
+		count[s]--
+	}
+
+	var changes []string
+	for _, s := range from {
+		if count[s] > 0 {
+			changes = append(changes, fmt.Sprintf("- %s: %s", label, s))
+			count[s]--
+		}
+	}
+	for _, s := range to {
+		if count[s] < 0 {
+			changes = append(changes, fmt.Sprintf("+ %s: %s", label, s))
+			count[s]++
+		}
+	}
+
+	if len(changes) == 0 && strings.Join(from, "\x00") != strings.Join(to, "\x00") {
+		changes = append(changes, fmt.Sprintf("~ %s order changed", label))
+	}
+	return changes
+}
@@ -0,0 +1,55 @@
+package template
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	old := &Template{
+		Name:    "node",
+		Version: "1.0.0",
+		Commands: []Command{
+			{Cmd: []string{"npm", "init", "-y"}},
+			{Cmd: []string{"git", "init"}},
+		},
+		Files: FileOps{Copy: []string{".gitignore"}},
+	}
+	cur := &Template{
+		Name:    "node",
+		Version: "1.1.0",
+		Tags:    []string{"js"},
+		Commands: []Command{
+			{Cmd: []string{"npm", "init", "-y"}},
+			{Cmd: []string{"npm", "install", "typescript"}},
+		},
+		Files: FileOps{
+			Copy:   []string{".gitignore"},
+			Append: []AppendPatch{{Target: "package.json", Source: "scripts.json"}},
+		},
+	}
+
+	want := []string{
+		"version: 1.0.0 -> 1.1.0",
+		"tags: (none) -> js",
+		"- command: git init",
+		"+ command: npm install typescript",
+		"+ append: scripts.json >> package.json",
+	}
+	if got := Diff(old, cur); !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() =\n%q\nwant\n%q", got, want)
+	}
+
+	if got := Diff(old, old); len(got) != 0 {
+		t.Errorf("Diff() of identical templates = %q, want none", got)
+	}
+}
+
+func TestDiffCommandOrder(t *testing.T) {
+	a := &Template{Commands: []Command{{Cmd: []string{"a"}}, {Cmd: []string{"b"}}}}
+	b := &Template{Commands: []Command{{Cmd: []string{"b"}}, {Cmd: []string{"a"}}}}
+	want := []string{"~ command order changed"}
+	if got := Diff(a, b); !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,224 @@
+package template
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ParseError is a template.yaml problem with its location in the file
+type ParseError struct {
+	Path   string // file path, empty when parsing raw bytes
+	Line   int    // 1-based, 0 when unknown
+	Column int    // 1-based, 0 when unknown
+	Msg    string
+
+	source []byte
+}
+
+// Error implements the error interface using the file:line:col convention
+func (e *ParseError) Error() string {
+	loc := e.Path
+	if e.Line > 0 {
+		if loc != "" {
+			loc += ":"
+		}
+		loc += strconv.Itoa(e.Line)
+		if e.Column > 0 {
+			loc += ":" + strconv.Itoa(e.Column)
+		}
+	}
+	if loc == "" {
+		return e.Msg
+	}
+	return loc + ": " + e.Msg
+}
+
+// Render returns the error followed by the offending YAML lines and a
+// caret under the reported column, for display to template authors
+func (e *ParseError) Render() string {
+	var b strings.Builder
+	b.WriteString(e.Error())
+
+	lines := strings.Split(string(e.source), "\n")
+	if e.Line < 1 || e.Line > len(lines) {
+		return b.String()
+	}
+
+	width := len(strconv.Itoa(e.Line))
+	b.WriteString("\n")
+	if e.Line > 1 {
+		fmt.Fprintf(&b, " %*d | %s\n", width, e.Line-1, strings.TrimRight(lines[e.Line-2], "\r"))
+	}
+	fmt.Fprintf(&b, " %*d | %s\n", width, e.Line, strings.TrimRight(lines[e.Line-1], "\r"))
+	if e.Column > 0 {
+		fmt.Fprintf(&b, " %*s | %s^", width, "", strings.Repeat(" ", e.Column-1))
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// Document is a decoded template.yaml that keeps the YAML node tree so
+// callers can report positions for any field
+type Document struct {
+	Root     *yaml.Node
+	Template *Template
+
+	source []byte
+}
+
+// Decode parses YAML into a Document without strictness or validation.
+// Syntax and type errors are returned as *ParseError.
+func Decode(data []byte) (*Document, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, yamlError(err, data)
+	}
+
+	doc := &Document{Template: &Template{}, source: data}
+	if len(root.Content) == 0 {
+		return nil, &ParseError{Line: 1, Msg: "template file is empty", source: data}
+	}
+	doc.Root = root.Content[0]
+
+	if err := doc.Root.Decode(doc.Template); err != nil {
+		return nil, yamlError(err, data)
+	}
+
+	return doc, nil
+}
+
+// Node returns the YAML node at the given path of mapping keys (string)
+// and sequence indexes (int), or nil if the path does not exist
+func (d *Document) Node(path ...any) *yaml.Node {
+	node := d.Root
+	for _, p := range path {
+		if node == nil {
+			return nil
+		}
+		switch key := p.(type) {
+		case string:
+			if node.Kind != yaml.MappingNode {
+				return nil
+			}
+			var next *yaml.Node
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == key {
+					next = node.Content[i+1]
+					break
+				}
+			}
+			node = next
+		case int:
+			if node.Kind != yaml.SequenceNode || key < 0 || key >= len(node.Content) {
+				return nil
+			}
+			node = node.Content[key]
+		}
+	}
+	return node
+}
+
+// Errorf returns a ParseError positioned at node (or the document root when
+// node is nil)
+func (d *Document) Errorf(node *yaml.Node, format string, args ...any) *ParseError {
+	if node == nil {
+		node = d.Root
+	}
+	return &ParseError{
+		Line:   node.Line,
+		Column: node.Column,
+		Msg:    fmt.Sprintf(format, args...),
+		source: d.source,
+	}
+}
+
+// UnknownFields reports every mapping key that does not correspond to a
+// field of Template, in document order
+func (d *Document) UnknownFields() []*ParseError {
+	var errs []*ParseError
+	d.unknownFields(d.Root, reflect.TypeOf(Template{}), "", &errs)
+	return errs
+}
+
+func (d *Document) unknownFields(node *yaml.Node, typ reflect.Type, path string, errs *[]*ParseError) {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	switch typ.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		fields := yamlFields(typ)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			field, ok := fields[key.Value]
+			if !ok {
+				msg := fmt.Sprintf("unknown field %q", key.Value)
+				if path != "" {
+					msg += " in " + path
+				}
+				*errs = append(*errs, d.Errorf(key, "%s", msg))
+				continue
+			}
+			d.unknownFields(value, field.Type, joinFieldPath(path, key.Value), errs)
+		}
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			return
+		}
+		for i, item := range node.Content {
+			d.unknownFields(item, typ.Elem(), fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	}
+}
+
+// yamlFields maps yaml keys to struct fields using the yaml struct tags
+func yamlFields(typ reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField, typ.NumField())
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		if name == "-" || !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = f
+	}
+	return fields
+}
+
+func joinFieldPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+var yamlLinePattern = regexp.MustCompile(`line (\d+): (.*)`)
+
+// yamlError converts yaml.v3 errors, which embed "line N:" in their text,
+// into a ParseError. Only the first of several type errors is kept.
+func yamlError(err error, data []byte) *ParseError {
+	msg := err.Error()
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) && len(typeErr.Errors) > 0 {
+		msg = typeErr.Errors[0]
+	}
+
+	perr := &ParseError{Msg: strings.TrimPrefix(msg, "yaml: "), source: data}
+	if m := yamlLinePattern.FindStringSubmatch(msg); m != nil {
+		perr.Line, _ = strconv.Atoi(m[1])
+		perr.Msg = m[2]
+	}
+	return perr
+}
@@ -0,0 +1,138 @@
+package template
+
+import (
+	"fmt"
+	"strings"
+
+	"forge/internal/version"
+)
+
+// Template format versions, oldest first. Templates written before
+// versioning was introduced have no apiVersion at all.
+const (
+	APIVersionV1 = "forge/v1"
+
+	// CurrentAPIVersion is the format written by forge new and forge migrate
+	CurrentAPIVersion = APIVersionV1
+)
+
+// supportedAPIVersions lists every apiVersion this forge can read
+var supportedAPIVersions = []string{APIVersionV1}
+
+// migration rewrites template.yaml from one format version to the next
+type migration struct {
+	from  string
+	to    string
+	apply func(doc *Document) ([]byte, error)
+}
+
+// migrations is the ordered upgrade path; each step's from matches the
+// previous step's to
+var migrations = []migration{
+	{from: "", to: APIVersionV1, apply: addAPIVersion},
+}
+
+// CheckAPIVersion rejects templates written for a newer forge
+func (d *Document) CheckAPIVersion() error {
+	v := d.Template.APIVersion
+	if v == "" {
+		return nil
+	}
+	for _, supported := range supportedAPIVersions {
+		if v == supported {
+			return nil
+		}
+	}
+	return d.Errorf(d.Node("apiVersion"),
+		"template uses apiVersion %q, but this forge only understands up to %q; run 'forge update' to get a newer forge",
+		v, CurrentAPIVersion)
+}
+
+// CheckForgeVersion returns an error if the running forge does not satisfy
+// the template's min_forge_version, which is either a minimum version or a
+// constraint such as "^1.2". Development builds are not checked.
+func (t *Template) CheckForgeVersion(forgeVersion string) error {
+	if t.MinForgeVersion == "" || forgeVersion == "development" {
+		return nil
+	}
+
+	required, err := version.ParseRequirement(t.MinForgeVersion)
+	if err != nil {
+		return fmt.Errorf("invalid min_forge_version: %w", err)
+	}
+	running, err := version.Parse(forgeVersion)
+	if err != nil {
+		return fmt.Errorf("failed to compare forge versions: %w", err)
+	}
+	if required.CheckIncludingPrerelease(running) {
+		return nil
+	}
+	if required.IsMinimum() {
+		return fmt.Errorf("template '%s' requires forge %s or newer (running %s); run 'forge update'",
+			t.Name, t.MinForgeVersion, forgeVersion)
+	}
+	return fmt.Errorf("template '%s' requires forge %s (running %s); run 'forge update --version' with a matching release",
+		t.Name, t.MinForgeVersion, forgeVersion)
+}
+
+// Migrate rewrites template YAML to CurrentAPIVersion, applying each
+// migration step in turn. It returns the rewritten data and the version the
+// template was migrated from ("" for unversioned templates); when from is
+// already CurrentAPIVersion the data is returned unchanged.
+func Migrate(data []byte) (out []byte, from string, err error) {
+	doc, err := Decode(data)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := doc.CheckAPIVersion(); err != nil {
+		return nil, "", err
+	}
+
+	from = doc.Template.APIVersion
+	out = data
+	for _, m := range migrations {
+		if doc.Template.APIVersion != m.from {
+			continue
+		}
+		if out, err = m.apply(doc); err != nil {
+			return nil, "", fmt.Errorf("migration to %s failed: %w", m.to, err)
+		}
+		if doc, err = Decode(out); err != nil {
+			return nil, "", fmt.Errorf("migration to %s produced invalid YAML: %w", m.to, err)
+		}
+	}
+
+	return out, from, nil
+}
+
+// addAPIVersion inserts "apiVersion: forge/v1" above the first top-level
+// key. It edits the text rather than re-encoding the node tree so that
+// comments and formatting are preserved exactly.
+func addAPIVersion(doc *Document) ([]byte, error) {
+	if len(doc.Root.Content) == 0 {
+		return nil, fmt.Errorf("template has no top-level keys")
+	}
+
+	firstKey := doc.Root.Content[0]
+	lines := strings.SplitAfter(string(doc.source), "\n")
+	idx := firstKey.Line - 1
+	if idx < 0 || idx >= len(lines) {
+		return nil, fmt.Errorf("cannot locate first key on line %d", firstKey.Line)
+	}
+
+	newline := "\n"
+	if strings.HasSuffix(lines[idx], "\r\n") {
+		newline = "\r\n"
+	}
+	indent := strings.Repeat(" ", firstKey.Column-1)
+	inserted := indent + "apiVersion: " + APIVersionV1 + newline
+
+	var b strings.Builder
+	for i, line := range lines {
+		if i == idx {
+			b.WriteString(inserted)
+		}
+		b.WriteString(line)
+	}
+	return []byte(b.String()), nil
+}
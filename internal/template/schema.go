@@ -0,0 +1,93 @@
+package template
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// SchemaURL is where the JSON Schema for template.yaml is published.
+// The file is generated by `forge schema` and committed at schema/template.schema.json.
+const SchemaURL = "https://raw.githubusercontent.com/Vishnuj-n/forge/main/schema/template.schema.json"
+
+// SchemaModeline is the comment that points YAML language servers at the schema
+const SchemaModeline = "# yaml-language-server: $schema=" + SchemaURL
+
+// JSONSchema generates the JSON Schema for template.yaml from the Template
+// type, so editors can offer completion and validation
+func JSONSchema() ([]byte, error) {
+	schema := objectSchema(reflect.TypeOf(Template{}))
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["$id"] = SchemaURL
+	schema["title"] = "Forge template"
+	schema["description"] = "Configuration file (template.yaml) for a Forge project template"
+
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// typeSchema returns the schema for a Go type used in Template
+func typeSchema(typ reflect.Type) map[string]any {
+	switch typ.Kind() {
+	case reflect.Struct:
+		return objectSchema(typ)
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": typeSchema(typ.Elem())}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	default:
+		return map[string]any{"type": "string"}
+	}
+}
+
+// objectSchema builds a closed object schema from yaml, desc, required,
+// enum and minItems struct tags
+func objectSchema(typ reflect.Type) map[string]any {
+	properties := map[string]any{}
+	required := []string{}
+
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		if name == "-" || !f.IsExported() {
+			continue
+		}
+
+		prop := typeSchema(f.Type)
+		if k := f.Type.Kind(); k == reflect.Slice || k == reflect.Struct {
+			// An empty key (e.g. "copy:" followed only by comments) decodes as null
+			prop["type"] = []string{prop["type"].(string), "null"}
+		}
+		if desc := f.Tag.Get("desc"); desc != "" {
+			prop["description"] = desc
+		}
+		if enum := f.Tag.Get("enum"); enum != "" {
+			prop["enum"] = strings.Split(enum, ",")
+		}
+		if min := f.Tag.Get("minItems"); min != "" {
+			if n, err := strconv.Atoi(min); err == nil {
+				prop["minItems"] = n
+			}
+		}
+		if f.Tag.Get("required") == "true" {
+			required = append(required, name)
+		}
+		properties[name] = prop
+	}
+
+	schema := map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
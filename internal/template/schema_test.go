@@ -0,0 +1,75 @@
+package template
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestSchemaUpToDate keeps the committed schema in sync with the Go types.
+// Regenerate with: go run . schema --output schema/template.schema.json
+func TestSchemaUpToDate(t *testing.T) {
+	want, err := JSONSchema()
+	if err != nil {
+		t.Fatalf("JSONSchema() error = %v", err)
+	}
+
+	got, err := os.ReadFile(filepath.Join("..", "..", "schema", "template.schema.json"))
+	if err != nil {
+		t.Fatalf("failed to read committed schema: %v", err)
+	}
+
+	if !bytes.Equal(bytes.ReplaceAll(got, []byte("\r\n"), []byte("\n")), want) {
+		t.Error("schema/template.schema.json is out of date; regenerate it with: go run . schema --output schema/template.schema.json")
+	}
+}
+
+func TestSchemaCoversAllFields(t *testing.T) {
+	data, err := JSONSchema()
+	if err != nil {
+		t.Fatalf("JSONSchema() error = %v", err)
+	}
+
+	var schema map[string]any
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("schema is not valid JSON: %v", err)
+	}
+
+	checkSchemaFields(t, reflect.TypeOf(Template{}), schema)
+}
+
+// checkSchemaFields verifies every yaml field of typ has a described property
+func checkSchemaFields(t *testing.T, typ reflect.Type, schema map[string]any) {
+	t.Helper()
+	properties, _ := schema["properties"].(map[string]any)
+
+	for name, field := range yamlFields(typ) {
+		prop, ok := properties[name].(map[string]any)
+		if !ok {
+			t.Errorf("%s.%s: missing from schema", typ.Name(), name)
+			continue
+		}
+		if prop["description"] == nil {
+			t.Errorf("%s.%s: missing desc tag", typ.Name(), name)
+		}
+
+		elem := field.Type
+		if elem.Kind() == reflect.Slice {
+			elem = elem.Elem()
+			prop, _ = prop["items"].(map[string]any)
+		}
+		if elem.Kind() == reflect.Struct {
+			checkSchemaFields(t, elem, prop)
+		}
+	}
+}
+
+func TestSchemaAcceptsScaffoldedYAML(t *testing.T) {
+	// Keys written by the schema must round-trip through strict parsing
+	if _, err := Parse([]byte(SchemaModeline + "\nname: modeline\n")); err != nil {
+		t.Errorf("Parse() with schema modeline error = %v", err)
+	}
+}
@@ -1,40 +1,47 @@
 package template
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 
-	"gopkg.in/yaml.v3"
+	"forge/internal/version"
 )
 
 // Template represents a project template configuration
+//
+// The desc, required and minItems tags feed the published JSON Schema (see
+// schema.go); keep them in sync when adding fields.
 type Template struct {
-	Name        string    `yaml:"name"`
-	Description string    `yaml:"description,omitempty"`
-	Version     string    `yaml:"version,omitempty"`
-	Commands    []Command `yaml:"commands"`
-	Files       FileOps   `yaml:"files"`
+	APIVersion      string    `yaml:"apiVersion,omitempty" enum:"forge/v1" desc:"Format version of this file; templates without it are migrated by forge migrate"`
+	Name            string    `yaml:"name" required:"true" desc:"Template name; letters, numbers, hyphens and underscores only"`
+	Description     string    `yaml:"description,omitempty" desc:"Short human-readable description of the template"`
+	Version         string    `yaml:"version,omitempty" desc:"Template version, e.g. 1.0.0"`
+	Tags            []string  `yaml:"tags,omitempty" desc:"Keywords that help forge search find the template"`
+	MinForgeVersion string    `yaml:"min_forge_version,omitempty" desc:"Forge releases able to run this template: a minimum such as 0.3.0 or a constraint such as ^1.2"`
+	Commands        []Command `yaml:"commands" desc:"Commands executed in order in the target directory"`
+	Files           FileOps   `yaml:"files" desc:"File operations applied after all commands have run"`
 }
 
 // Command represents a single command to execute
 type Command struct {
-	Cmd         []string `yaml:"cmd"`
-	Interactive bool     `yaml:"interactive"`
-	TestCmd     []string `yaml:"test_cmd"`
+	Cmd         []string `yaml:"cmd" required:"true" minItems:"1" desc:"Executable and arguments as separate tokens (no shell strings)"`
+	Interactive bool     `yaml:"interactive" desc:"Set to true if the command prompts the user"`
+	TestCmd     []string `yaml:"test_cmd" minItems:"1" desc:"Non-interactive replacement used by forge test for interactive commands"`
 }
 
 // FileOps represents file operations (copy and append)
 type FileOps struct {
-	Copy   []string      `yaml:"copy"`
-	Append []AppendPatch `yaml:"append"`
+	Copy   []string      `yaml:"copy" desc:"Files or directories, relative to the template, copied into the project root"`
+	Append []AppendPatch `yaml:"append" desc:"Append-only patches applied to files that already exist in the project"`
 }
 
 // AppendPatch represents an append-only patch operation
 type AppendPatch struct {
-	Target string `yaml:"target"`
-	Source string `yaml:"source"`
+	Target string `yaml:"target" required:"true" desc:"Existing file in the project to append to"`
+	Source string `yaml:"source" required:"true" desc:"Patch file, relative to the template, whose content is appended"`
 }
 
 // String returns a human-readable representation of the command
@@ -108,21 +115,28 @@ func getSearchPaths(templateName string) []string {
 	return paths
 }
 
-// loadFromPath loads a template from a resolved path
-func loadFromPath(resolvedPath string) (*Template, error) {
+// YAMLPath returns the template.yaml file for a resolved template path,
+// which may be a template directory or the YAML file itself
+func YAMLPath(resolvedPath string) (string, error) {
 	// Check if it's a directory or file
 	info, err := os.Stat(resolvedPath)
 	if err != nil {
-		return nil, fmt.Errorf("template path not found: %w", err)
+		return "", fmt.Errorf("template path not found: %w", err)
 	}
 
-	var yamlPath string
 	if info.IsDir() {
 		// If directory, look for template.yaml inside
-		yamlPath = filepath.Join(resolvedPath, "template.yaml")
-	} else {
-		// If file, use it directly
-		yamlPath = resolvedPath
+		return filepath.Join(resolvedPath, "template.yaml"), nil
+	}
+	// If file, use it directly
+	return resolvedPath, nil
+}
+
+// loadFromPath loads a template from a resolved path
+func loadFromPath(resolvedPath string) (*Template, error) {
+	yamlPath, err := YAMLPath(resolvedPath)
+	if err != nil {
+		return nil, err
 	}
 
 	// Read YAML file
@@ -132,7 +146,7 @@ func loadFromPath(resolvedPath string) (*Template, error) {
 	}
 
 	// Parse using shared Parse function
-	tmpl, err := Parse(data)
+	tmpl, err := parse(yamlPath, data)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse template YAML: %w", err)
 	}
@@ -142,42 +156,75 @@ func loadFromPath(resolvedPath string) (*Template, error) {
 
 // Parse parses template YAML data into a Template and validates it.
 // This is a public helper to allow callers to parse YAML without duplicating logic.
+// Decoding is strict: unknown keys are rejected. Errors carry the line and
+// column of the offending YAML as a *ParseError.
 func Parse(data []byte) (*Template, error) {
-	var tmpl Template
-	if err := yaml.Unmarshal(data, &tmpl); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal template YAML: %w", err)
+	return parse("", data)
+}
+
+// parse implements Parse, attaching path to any ParseError
+func parse(path string, data []byte) (*Template, error) {
+	doc, err := Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal template YAML: %w", withPath(err, path))
+	}
+
+	// Check the format version first: a newer format is expected to contain
+	// fields this forge does not know about
+	if err := doc.CheckAPIVersion(); err != nil {
+		return nil, withPath(err, path)
 	}
 
-	if err := tmpl.validate(); err != nil {
-		return nil, fmt.Errorf("template validation failed: %w", err)
+	if unknown := doc.UnknownFields(); len(unknown) > 0 {
+		return nil, fmt.Errorf("failed to unmarshal template YAML: %w", withPath(unknown[0], path))
 	}
 
-	return &tmpl, nil
+	if err := doc.validate(); err != nil {
+		return nil, fmt.Errorf("template validation failed: %w", withPath(err, path))
+	}
+
+	return doc.Template, nil
+}
+
+// withPath records the template file path on a ParseError
+func withPath(err error, path string) error {
+	var perr *ParseError
+	if errors.As(err, &perr) {
+		perr.Path = path
+	}
+	return err
 }
 
 // validate checks if the template is valid
-func (t *Template) validate() error {
+func (d *Document) validate() error {
+	t := d.Template
 	if t.Name == "" {
-		return fmt.Errorf("template name is required")
+		return d.Errorf(d.Node("name"), "template name is required")
+	}
+
+	if t.MinForgeVersion != "" {
+		if _, err := version.ParseRequirement(t.MinForgeVersion); err != nil {
+			return d.Errorf(d.Node("min_forge_version"), "invalid min_forge_version: %v", err)
+		}
 	}
 
 	// Validate commands
 	for i, cmd := range t.Commands {
 		if len(cmd.Cmd) == 0 {
-			return fmt.Errorf("command %d: cmd array is empty", i)
+			return d.Errorf(d.Node("commands", i), "command %d: cmd array is empty", i)
 		}
 		if cmd.Cmd[0] == "" {
-			return fmt.Errorf("command %d: first element (executable) cannot be empty", i)
+			return d.Errorf(d.Node("commands", i, "cmd", 0), "command %d: first element (executable) cannot be empty", i)
 		}
 	}
 
 	// Validate append patches
 	for i, patch := range t.Files.Append {
 		if patch.Target == "" {
-			return fmt.Errorf("append patch %d: target is required", i)
+			return d.Errorf(d.Node("files", "append", i), "append patch %d: target is required", i)
 		}
 		if patch.Source == "" {
-			return fmt.Errorf("append patch %d: source is required", i)
+			return d.Errorf(d.Node("files", "append", i), "append patch %d: source is required", i)
 		}
 	}
 
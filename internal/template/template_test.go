@@ -1,7 +1,10 @@
 package template
 
 import (
+	"errors"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -144,3 +147,179 @@ func TestHasFileOps(t *testing.T) {
 		})
 	}
 }
+
+func TestParseStrict(t *testing.T) {
+	tests := []struct {
+		name     string
+		yaml     string
+		wantLine int
+		wantCol  int
+		wantMsg  string
+	}{
+		{
+			name: "misspelled command key",
+			yaml: `name: strict
+commands:
+  - cmd: ["npm", "init"]
+    test-cmd: ["npm", "init", "-y"]`,
+			wantLine: 4,
+			wantCol:  5,
+			wantMsg:  `unknown field "test-cmd" in commands[0]`,
+		},
+		{
+			name: "misspelled top-level key",
+			yaml: `name: strict
+comands:
+  - cmd: ["git", "init"]`,
+			wantLine: 2,
+			wantCol:  1,
+			wantMsg:  `unknown field "comands"`,
+		},
+		{
+			name: "validation error is positioned",
+			yaml: `name: strict
+commands:
+  - cmd: ["git", "init"]
+  - cmd: []`,
+			wantLine: 4,
+			wantCol:  5,
+			wantMsg:  "command 1: cmd array is empty",
+		},
+		{
+			name: "type error",
+			yaml: `name: strict
+commands:
+  - cmd: ["npm", "init"]
+    interactive: maybe`,
+			wantLine: 4,
+			wantMsg:  "cannot unmarshal",
+		},
+		{
+			name:     "syntax error",
+			yaml:     "name: strict\ncommands:\n  - cmd: [\"git\"\n",
+			wantLine: 2,
+			wantMsg:  "did not find expected",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.yaml))
+			var perr *ParseError
+			if !errors.As(err, &perr) {
+				t.Fatalf("Parse() error = %v, want *ParseError", err)
+			}
+			if perr.Line != tt.wantLine || perr.Column != tt.wantCol {
+				t.Errorf("Parse() position = %d:%d, want %d:%d", perr.Line, perr.Column, tt.wantLine, tt.wantCol)
+			}
+			if !strings.Contains(perr.Msg, tt.wantMsg) {
+				t.Errorf("Parse() message = %q, want it to contain %q", perr.Msg, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestLoadParseErrorPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "template.yaml")
+	if err := os.WriteFile(path, []byte("name: x\nintractive: true\n"), 0644); err != nil {
+		t.Fatalf("WriteFile error = %v", err)
+	}
+
+	_, err := Load(path)
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("Load() error = %v, want *ParseError", err)
+	}
+	if !strings.Contains(err.Error(), path+":2:1:") {
+		t.Errorf("Load() error = %q, want it to contain %q", err.Error(), path+":2:1:")
+	}
+}
+
+func TestParseErrorRender(t *testing.T) {
+	_, err := Parse([]byte("name: render\ncommands:\n  - cmd: [\"git\", \"init\"]\n    intractive: true\n"))
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("Parse() error = %v, want *ParseError", err)
+	}
+
+	want := `4:5: unknown field "intractive" in commands[0]
+ 3 |   - cmd: ["git", "init"]
+ 4 |     intractive: true
+   |     ^`
+	if got := perr.Render(); got != want {
+		t.Errorf("Render() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestParseAPIVersion(t *testing.T) {
+	if _, err := Parse([]byte("apiVersion: forge/v1\nname: current\n")); err != nil {
+		t.Errorf("Parse() current apiVersion error = %v", err)
+	}
+
+	// A newer format is reported as such, not as an unknown field
+	_, err := Parse([]byte("apiVersion: forge/v2\nname: future\nvariables: {}\n"))
+	var perr *ParseError
+	if !errors.As(err, &perr) || perr.Line != 1 || !strings.Contains(perr.Msg, "forge update") {
+		t.Errorf("Parse() newer apiVersion error = %v, want positioned newer-forge error", err)
+	}
+}
+
+func TestCheckForgeVersion(t *testing.T) {
+	tests := []struct {
+		min     string
+		running string
+		wantErr bool
+	}{
+		{"", "0.1.0", false},
+		{"0.3.0", "0.3.0", false},
+		{"0.3.0", "v0.4.1", false},
+		{"0.3.0", "0.2.9", true},
+		{"1.0.0", "development", false},
+		{"0.3.0", "0.4.0-beta.1", false},
+		{"0.4.0", "0.4.0-beta.1", true},
+		{"^0.4", "0.4.7", false},
+		{"^0.4", "0.5.0", true},
+		{">=0.3 <1.0", "1.0.0", true},
+		{"^1.0 || ^2.0", "v2.1.0", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.min+"_on_"+tt.running, func(t *testing.T) {
+			tmpl := &Template{Name: "versioned", MinForgeVersion: tt.min}
+			err := tmpl.CheckForgeVersion(tt.running)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckForgeVersion(%q) error = %v, wantErr %v", tt.running, err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := Parse([]byte("name: bad\nmin_forge_version: soon\n")); err == nil {
+		t.Error("Parse() should reject an invalid min_forge_version")
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	legacy := "# My template\nname: legacy # keep me\ncommands:\n  - cmd: [\"git\", \"init\"]\n"
+
+	out, from, err := Migrate([]byte(legacy))
+	if err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	if from != "" {
+		t.Errorf("Migrate() from = %q, want unversioned", from)
+	}
+
+	want := "# My template\napiVersion: forge/v1\nname: legacy # keep me\ncommands:\n  - cmd: [\"git\", \"init\"]\n"
+	if string(out) != want {
+		t.Errorf("Migrate() =\n%s\nwant:\n%s", out, want)
+	}
+
+	// Migrating again is a no-op
+	again, from, err := Migrate(out)
+	if err != nil {
+		t.Fatalf("Migrate() second run error = %v", err)
+	}
+	if from != CurrentAPIVersion || string(again) != string(out) {
+		t.Errorf("Migrate() second run changed the template (from %q)", from)
+	}
+}
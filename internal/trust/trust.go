@@ -0,0 +1,125 @@
+package trust
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"forge/internal/config"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the name of the trust store in the forge config directory
+const FileName = "trust.yaml"
+
+// State is the trust decision recorded for a template's current content
+type State int
+
+const (
+	// Unknown templates have never been trusted
+	Unknown State = iota
+	// Changed templates were trusted with different content
+	Changed
+	// Trusted templates were trusted with exactly this content
+	Trusted
+)
+
+// Store records which template sources the user trusts, and the content
+// hash each was trusted with
+type Store struct {
+	Templates []Entry `yaml:"templates"`
+
+	path string
+}
+
+// Entry is one trust decision
+type Entry struct {
+	Source    string    `yaml:"source"` // registry/name, git+ URL or local path
+	Hash      string    `yaml:"hash"`   // content hash, see lock.HashDir
+	TrustedAt time.Time `yaml:"trusted_at"`
+}
+
+// DefaultPath returns the per-user trust store (~/.forge/trust.yaml)
+func DefaultPath() (string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, FileName), nil
+}
+
+// Load reads a trust store. A missing file yields an empty store that will
+// be created on Save.
+func Load(path string) (*Store, error) {
+	s := &Store{path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trust store: %w", err)
+	}
+	if err := yaml.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("failed to parse trust store %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Check returns the trust state of source with the given content hash
+func (s *Store) Check(source, hash string) State {
+	e, ok := s.Get(source)
+	switch {
+	case !ok:
+		return Unknown
+	case e.Hash != hash:
+		return Changed
+	default:
+		return Trusted
+	}
+}
+
+// Get returns the trust decision recorded for source
+func (s *Store) Get(source string) (Entry, bool) {
+	for _, e := range s.Templates {
+		if e.Source == source {
+			return e, true
+		}
+	}
+	return Entry{}, false
+}
+
+// Trust records source as trusted with hash, replacing any earlier decision
+func (s *Store) Trust(source, hash string) {
+	e := Entry{Source: source, Hash: hash, TrustedAt: time.Now().UTC().Truncate(time.Second)}
+	for i := range s.Templates {
+		if s.Templates[i].Source == source {
+			s.Templates[i] = e
+			return
+		}
+	}
+	s.Templates = append(s.Templates, e)
+}
+
+// Save writes the store, with entries sorted by source
+func (s *Store) Save() error {
+	sort.Slice(s.Templates, func(i, j int) bool {
+		return s.Templates[i].Source < s.Templates[j].Source
+	})
+
+	data, err := yaml.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to encode trust store: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create trust store directory: %w", err)
+	}
+	if err := os.WriteFile(s.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write trust store: %w", err)
+	}
+	return nil
+}
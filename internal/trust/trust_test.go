@@ -0,0 +1,65 @@
+package trust
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckStates(t *testing.T) {
+	s, err := Load(filepath.Join(t.TempDir(), FileName))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := s.Check("team/python", "sha256:aa"); got != Unknown {
+		t.Errorf("Check() before trusting = %v, want Unknown", got)
+	}
+
+	s.Trust("team/python", "sha256:aa")
+	if got := s.Check("team/python", "sha256:aa"); got != Trusted {
+		t.Errorf("Check() same hash = %v, want Trusted", got)
+	}
+	if got := s.Check("team/python", "sha256:bb"); got != Changed {
+		t.Errorf("Check() new hash = %v, want Changed", got)
+	}
+	if got := s.Check("official/python", "sha256:aa"); got != Unknown {
+		t.Errorf("Check() other source = %v, want Unknown", got)
+	}
+
+	s.Trust("team/python", "sha256:bb")
+	if len(s.Templates) != 1 || s.Check("team/python", "sha256:bb") != Trusted {
+		t.Errorf("re-trusting should replace the decision, got %+v", s.Templates)
+	}
+}
+
+func TestSaveAndLoadRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", FileName)
+	s, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Trust("team/python", "sha256:aa")
+	s.Trust("git+https://example.com/t.git//node", "sha256:cc")
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(loaded.Templates) != 2 || loaded.Templates[0].Source != "git+https://example.com/t.git//node" {
+		t.Fatalf("Templates = %+v, want both sorted by source", loaded.Templates)
+	}
+	if loaded.Check("team/python", "sha256:aa") != Trusted || loaded.Templates[1].TrustedAt.IsZero() {
+		t.Errorf("loaded entry = %+v", loaded.Templates[1])
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	os.WriteFile(path, []byte("templates: ["), 0644)
+	if _, err := Load(path); err == nil {
+		t.Error("Load() of invalid YAML should fail")
+	}
+}
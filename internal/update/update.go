@@ -1,9 +1,12 @@
 package update
 
 import (
+	"crypto/ed25519"
+	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
+	"runtime"
+	"strings"
 
 	"forge/internal/remote"
 	"forge/internal/version"
@@ -15,68 +18,249 @@ const (
 	GitHubRepo  = "forge"
 )
 
-// CheckUpdate checks if a newer version is available on GitHub.
-// When currentVersion is "development", the latest release is always considered
-// available so that dev builds are upgraded to a proper release.
-// Returns the new version string, whether an update is available, and any error.
-func CheckUpdate(currentVersion string) (newVersion string, available bool, err error) {
-	tagName, _, err := remote.FetchLatestReleaseVersion(GitHubOwner, GitHubRepo)
+// ChecksumAssets are the release assets, in order of preference, that list
+// the SHA-256 of each binary in sha256sum format. A signature of the
+// checksums file may be published next to it as <name>.sig.
+var ChecksumAssets = []string{"checksums.txt", "SHA256SUMS"}
+
+// ErrNoBackup is returned by Rollback when no previous binary was kept
+var ErrNoBackup = errors.New("no previous forge binary to roll back to")
+
+// Options control how a downloaded binary is verified
+type Options struct {
+	// TrustedKeys verify the checksums signature; when any are set the
+	// release must be signed
+	TrustedKeys []ed25519.PublicKey
+	// RequireSignature refuses releases without a valid checksums signature
+	RequireSignature bool
+	// InsecureSkipVerify installs the binary without any verification
+	InsecureSkipVerify bool
+}
+
+// Release channels
+const (
+	// ChannelStable offers only full releases
+	ChannelStable = "stable"
+	// ChannelBeta also offers pre-releases, whichever version is highest
+	ChannelBeta = "beta"
+)
+
+// ValidateChannel returns an error for an unknown channel name
+func ValidateChannel(channel string) error {
+	if channel != ChannelStable && channel != ChannelBeta {
+		return fmt.Errorf("unknown update channel %q (use %s or %s)", channel, ChannelStable, ChannelBeta)
+	}
+	return nil
+}
+
+// LatestRelease returns the highest release on channel. Drafts and tags
+// that are not semantic versions are ignored; the stable channel also
+// ignores releases marked as pre-releases or with pre-release versions.
+func LatestRelease(channel string) (*remote.GitHubRelease, error) {
+	if err := ValidateChannel(channel); err != nil {
+		return nil, err
+	}
+	releases, err := remote.FetchReleases(GitHubOwner, GitHubRepo)
 	if err != nil {
-		return "", false, fmt.Errorf("failed to check for updates: %w", err)
+		return nil, err
 	}
+	return selectRelease(releases, channel)
+}
 
-	// Dev builds have no real version to compare; always offer the latest release.
-	if currentVersion == "development" {
-		return tagName, true, nil
+func selectRelease(releases []remote.GitHubRelease, channel string) (*remote.GitHubRelease, error) {
+	var best *remote.GitHubRelease
+	var bestVersion version.Version
+	for i, r := range releases {
+		v, err := version.Parse(r.TagName)
+		if err != nil || r.Draft {
+			continue
+		}
+		if channel == ChannelStable && (r.Prerelease || v.IsPrerelease()) {
+			continue
+		}
+		if best == nil || v.Compare(bestVersion) > 0 {
+			best, bestVersion = &releases[i], v
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("no %s releases found", channel)
+	}
+	return best, nil
+}
+
+// PinnedRelease returns the release tagged tag; "0.4.2" also finds "v0.4.2"
+func PinnedRelease(tag string) (*remote.GitHubRelease, error) {
+	release, err := remote.FetchReleaseByTag(GitHubOwner, GitHubRepo, tag)
+	if errors.Is(err, remote.ErrReleaseNotFound) && !strings.HasPrefix(tag, "v") {
+		release, err = remote.FetchReleaseByTag(GitHubOwner, GitHubRepo, "v"+tag)
+	}
+	if errors.Is(err, remote.ErrReleaseNotFound) {
+		return nil, fmt.Errorf("forge %s: %w", tag, err)
+	}
+	return release, err
+}
+
+// CheckUpdate finds the latest release on channel and reports whether it is
+// newer than currentVersion. Pre-releases compare below their release, so
+// 1.2.0-beta.1 is upgraded to 1.2.0.
+// When currentVersion is "development", the latest release is always considered
+// available so that dev builds are upgraded to a proper release.
+func CheckUpdate(currentVersion, channel string) (release *remote.GitHubRelease, available bool, err error) {
+	release, err = LatestRelease(channel)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to check for updates: %w", err)
 	}
 
-	isNewer, err := version.IsNewerVersion(currentVersion, tagName)
+	isNewer, err := version.IsNewerVersion(currentVersion, release.TagName)
 	if err != nil {
-		return "", false, fmt.Errorf("failed to compare versions: %w", err)
+		return nil, false, fmt.Errorf("failed to compare versions: %w", err)
 	}
+	return release, isNewer, nil
+}
 
-	return tagName, isNewer, nil
+// BackupPath returns where the previous binary is kept after an update
+func BackupPath(binaryPath string) string {
+	return binaryPath + ".backup"
 }
 
-// PerformUpdate downloads the latest binary and replaces the current executable
+// PerformUpdate downloads the binary of release for this OS and
+// architecture, verifies it against the release checksums and replaces the
+// current executable, keeping the previous one at BackupPath for Rollback.
+// Binaries published inside zip or tar.gz archives are extracted first.
 // binaryPath should be the path to the current forge executable
-func PerformUpdate(newVersion, binaryPath string) error {
-	// Fetch release info and download URL
-	_, downloadURL, err := remote.FetchLatestReleaseVersion(GitHubOwner, GitHubRepo)
+func PerformUpdate(release *remote.GitHubRelease, binaryPath string, opts Options) error {
+	return performUpdate(release, binaryPath, runtime.GOOS, runtime.GOARCH, opts)
+}
+
+func performUpdate(release *remote.GitHubRelease, binaryPath, goos, goarch string, opts Options) error {
+	asset, err := remote.SelectBinaryAsset(release, goos, goarch)
 	if err != nil {
-		return fmt.Errorf("failed to fetch release info: %w", err)
+		return err
 	}
 
-	// Create temp file for download
-	tempDir := os.TempDir()
-	tempFile := filepath.Join(tempDir, "forge-update.exe")
+	// Download next to the binary so the final rename stays on one volume
+	tempFile := binaryPath + ".download"
+	defer os.Remove(tempFile)
 
-	defer os.Remove(tempFile) // Clean up temp file after update
-
-	// Download the new binary
-	fmt.Printf("Downloading Forge %s...\n", newVersion)
-	if err := remote.DownloadReleaseBinary(downloadURL, tempFile); err != nil {
+	fmt.Printf("Downloading Forge %s...\n", release.TagName)
+	if err := remote.DownloadReleaseBinary(asset.DownloadURL, tempFile); err != nil {
 		return fmt.Errorf("failed to download new binary: %w", err)
 	}
 
-	// Replace the old binary with the new one
-	// We need to back up the old binary in case something goes wrong
-	backupPath := binaryPath + ".backup"
+	if !opts.InsecureSkipVerify {
+		if err := verifyBinary(release, asset.Name, tempFile, opts); err != nil {
+			return err
+		}
+	}
+
+	newBinary := tempFile
+	if asset.Format != remote.FormatBinary {
+		newBinary = binaryPath + ".new"
+		defer os.Remove(newBinary)
+		if err := remote.ExtractBinary(tempFile, remote.BinaryName(goos), newBinary); err != nil {
+			return fmt.Errorf("failed to unpack %s: %w", asset.Name, err)
+		}
+	}
+	if err := os.Chmod(newBinary, 0755); err != nil {
+		return fmt.Errorf("failed to make new binary executable: %w", err)
+	}
+
+	return replaceBinary(newBinary, binaryPath)
+}
+
+// verifyBinary checks a downloaded asset against the release checksums and,
+// when the release is signed, the checksums signature
+func verifyBinary(release *remote.GitHubRelease, name, path string, opts Options) error {
+	var sums remote.GitHubAsset
+	found := false
+	for _, n := range ChecksumAssets {
+		if sums, found = release.Asset(n); found {
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("release %s publishes no %s; refusing to install an unverified binary", release.TagName, ChecksumAssets[0])
+	}
+
+	data, err := remote.FetchBytes(sums.DownloadURL)
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s: %w", sums.Name, err)
+	}
+
+	sig, signed := release.Asset(sums.Name + ".sig")
+	switch {
+	case signed && len(opts.TrustedKeys) > 0:
+		sigData, err := remote.FetchBytes(sig.DownloadURL)
+		if err != nil {
+			return fmt.Errorf("failed to fetch %s: %w", sig.Name, err)
+		}
+		if err := remote.VerifySignature(data, sigData, opts.TrustedKeys); err != nil {
+			return fmt.Errorf("release %s: %w", release.TagName, err)
+		}
+	case opts.RequireSignature && !signed:
+		return fmt.Errorf("release %s has no signature (%s.sig) and signatures are required", release.TagName, sums.Name)
+	case !signed && len(opts.TrustedKeys) > 0:
+		// A release stripped of its signature must not pass as an unsigned one
+		return fmt.Errorf("release %s has no signature (%s.sig) although trusted keys are configured; use --insecure-skip-verify to install it anyway", release.TagName, sums.Name)
+	case opts.RequireSignature:
+		return fmt.Errorf("release %s is signed but no trusted keys are configured to verify it", release.TagName)
+	}
 
-	// On Windows, we can't directly replace a running executable
-	// So we rename the old one, move the new one in place, and clean up
+	manifest, err := remote.ParseManifest(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", sums.Name, err)
+	}
+	if err := manifest.Verify(name, path); err != nil {
+		return fmt.Errorf("downloaded binary rejected: %w", err)
+	}
+	return nil
+}
+
+// replaceBinary moves newPath over binaryPath, keeping the current binary
+// as the backup
+func replaceBinary(newPath, binaryPath string) error {
+	backupPath := BackupPath(binaryPath)
+	// Only the most recent previous version is kept
+	if err := os.Remove(backupPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove old backup: %w", err)
+	}
+
+	// On Windows, we can't directly replace a running executable,
+	// but it can be renamed out of the way
 	if err := os.Rename(binaryPath, backupPath); err != nil {
 		return fmt.Errorf("failed to back up current binary: %w", err)
 	}
 
-	if err := os.Rename(tempFile, binaryPath); err != nil {
+	if err := os.Rename(newPath, binaryPath); err != nil {
 		// Restore backup on failure
 		os.Rename(backupPath, binaryPath)
 		return fmt.Errorf("failed to install new binary: %w", err)
 	}
+	return nil
+}
 
-	// Clean up backup
-	os.Remove(backupPath)
+// Rollback swaps the current binary with the one kept by the last update.
+// The binary rolled back from becomes the backup, so a second rollback
+// returns to it.
+func Rollback(binaryPath string) error {
+	backupPath := BackupPath(binaryPath)
+	if _, err := os.Stat(backupPath); errors.Is(err, os.ErrNotExist) {
+		return ErrNoBackup
+	} else if err != nil {
+		return err
+	}
 
+	swap := binaryPath + ".rollback"
+	os.Remove(swap)
+	if err := os.Rename(binaryPath, swap); err != nil {
+		return fmt.Errorf("failed to move current binary aside: %w", err)
+	}
+	if err := os.Rename(backupPath, binaryPath); err != nil {
+		os.Rename(swap, binaryPath)
+		return fmt.Errorf("failed to restore previous binary: %w", err)
+	}
+	if err := os.Rename(swap, backupPath); err != nil {
+		return fmt.Errorf("restored previous binary, but failed to keep the current one: %w", err)
+	}
 	return nil
 }
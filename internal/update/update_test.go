@@ -0,0 +1,372 @@
+package update
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"forge/internal/remote"
+)
+
+// releaseServer is a stand-in for the GitHub releases API
+type releaseServer struct {
+	srv      *httptest.Server
+	mux      *http.ServeMux
+	releases []remote.GitHubRelease
+}
+
+func newReleaseServer(t *testing.T) *releaseServer {
+	t.Helper()
+	s := &releaseServer{mux: http.NewServeMux()}
+	s.srv = httptest.NewServer(s.mux)
+	t.Cleanup(s.srv.Close)
+
+	base := fmt.Sprintf("/repos/%s/%s/releases", GitHubOwner, GitHubRepo)
+	s.mux.HandleFunc(base, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(s.releases)
+	})
+	s.mux.HandleFunc(base+"/tags/", func(w http.ResponseWriter, r *http.Request) {
+		tag := strings.TrimPrefix(r.URL.Path, base+"/tags/")
+		for _, rel := range s.releases {
+			if rel.TagName == tag {
+				json.NewEncoder(w).Encode(rel)
+				return
+			}
+		}
+		http.NotFound(w, r)
+	})
+
+	prev := remote.GitHubAPIBase
+	remote.GitHubAPIBase = s.srv.URL
+	t.Cleanup(func() { remote.GitHubAPIBase = prev })
+	return s
+}
+
+// add publishes a release with the given assets and returns it
+func (s *releaseServer) add(tag string, prerelease bool, assets map[string][]byte) *remote.GitHubRelease {
+	release := remote.GitHubRelease{TagName: tag, Prerelease: prerelease, Body: "Notes for " + tag}
+	for name, data := range assets {
+		data := data
+		path := "/download/" + tag + "/" + name
+		release.Assets = append(release.Assets, remote.GitHubAsset{Name: name, DownloadURL: s.srv.URL + path})
+		s.mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			w.Write(data)
+		})
+	}
+	s.releases = append(s.releases, release)
+	return &s.releases[len(s.releases)-1]
+}
+
+// fakeRelease serves a single stable release with the given assets
+func fakeRelease(t *testing.T, tag string, assets map[string][]byte) *remote.GitHubRelease {
+	t.Helper()
+	return newReleaseServer(t).add(tag, false, assets)
+}
+
+// binaryAsset is the release asset name for the platform running the tests
+var binaryAsset = "forge_" + runtime.GOOS + "_" + runtime.GOARCH
+
+func checksums(files map[string][]byte) []byte {
+	m := remote.Manifest{}
+	for name, data := range files {
+		sum := sha256.Sum256(data)
+		m[name] = hex.EncodeToString(sum[:])
+	}
+	return m.Bytes()
+}
+
+func installedBinary(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "forge")
+	if err := os.WriteFile(path, []byte("old binary"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestPerformUpdateVerifiesAndKeepsBackup(t *testing.T) {
+	bin := []byte("new binary")
+	release := fakeRelease(t, "v1.1.0", map[string][]byte{
+		binaryAsset:     bin,
+		"checksums.txt": checksums(map[string][]byte{binaryAsset: bin}),
+	})
+	path := installedBinary(t)
+
+	if err := PerformUpdate(release, path, Options{}); err != nil {
+		t.Fatalf("PerformUpdate() error = %v", err)
+	}
+	if got := readFile(t, path); got != "new binary" {
+		t.Errorf("binary = %q, want the new one", got)
+	}
+	if got := readFile(t, BackupPath(path)); got != "old binary" {
+		t.Errorf("backup = %q, want the previous binary", got)
+	}
+
+	if err := Rollback(path); err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+	if got := readFile(t, path); got != "old binary" {
+		t.Errorf("after rollback binary = %q, want the previous one", got)
+	}
+	if got := readFile(t, BackupPath(path)); got != "new binary" {
+		t.Errorf("after rollback backup = %q, want the rolled back binary", got)
+	}
+}
+
+func TestPerformUpdateRejectsBadChecksum(t *testing.T) {
+	release := fakeRelease(t, "v1.1.0", map[string][]byte{
+		binaryAsset:     []byte("tampered binary"),
+		"checksums.txt": checksums(map[string][]byte{binaryAsset: []byte("new binary")}),
+	})
+	path := installedBinary(t)
+
+	err := PerformUpdate(release, path, Options{})
+	if !errors.Is(err, remote.ErrChecksumMismatch) {
+		t.Fatalf("PerformUpdate() error = %v, want a checksum mismatch", err)
+	}
+	if got := readFile(t, path); got != "old binary" {
+		t.Errorf("binary = %q, should be left untouched", got)
+	}
+	if _, err := os.Stat(BackupPath(path)); err == nil {
+		t.Error("no backup should be made when the update is rejected")
+	}
+}
+
+func TestPerformUpdateNeedsChecksums(t *testing.T) {
+	release := fakeRelease(t, "v1.1.0", map[string][]byte{binaryAsset: []byte("new binary")})
+	path := installedBinary(t)
+
+	if err := PerformUpdate(release, path, Options{}); err == nil || !strings.Contains(err.Error(), "checksums.txt") {
+		t.Fatalf("PerformUpdate() error = %v, want a missing checksums error", err)
+	}
+	if err := PerformUpdate(release, path, Options{InsecureSkipVerify: true}); err != nil {
+		t.Fatalf("PerformUpdate() with InsecureSkipVerify error = %v", err)
+	}
+	if got := readFile(t, path); got != "new binary" {
+		t.Errorf("binary = %q, want the new one", got)
+	}
+}
+
+func TestPerformUpdateSignature(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	otherPub, _, _ := ed25519.GenerateKey(nil)
+	bin := []byte("new binary")
+	sums := checksums(map[string][]byte{binaryAsset: bin})
+
+	release := fakeRelease(t, "v1.1.0", map[string][]byte{
+		binaryAsset:         bin,
+		"checksums.txt":     sums,
+		"checksums.txt.sig": remote.SignManifest(sums, priv),
+	})
+
+	path := installedBinary(t)
+	err := PerformUpdate(release, path, Options{TrustedKeys: []ed25519.PublicKey{otherPub}})
+	if !errors.Is(err, remote.ErrBadSignature) {
+		t.Fatalf("PerformUpdate() with an untrusted signer error = %v, want a signature error", err)
+	}
+	if err := PerformUpdate(release, path, Options{RequireSignature: true}); err == nil {
+		t.Fatal("PerformUpdate() requiring a signature without trusted keys should fail")
+	}
+	if err := PerformUpdate(release, path, Options{TrustedKeys: []ed25519.PublicKey{pub}, RequireSignature: true}); err != nil {
+		t.Fatalf("PerformUpdate() with a trusted signature error = %v", err)
+	}
+}
+
+func TestPerformUpdateRequiredSignatureMissing(t *testing.T) {
+	pub, _, _ := ed25519.GenerateKey(nil)
+	bin := []byte("new binary")
+	release := fakeRelease(t, "v1.1.0", map[string][]byte{
+		binaryAsset:     bin,
+		"checksums.txt": checksums(map[string][]byte{binaryAsset: bin}),
+	})
+	path := installedBinary(t)
+
+	err := PerformUpdate(release, path, Options{TrustedKeys: []ed25519.PublicKey{pub}, RequireSignature: true})
+	if err == nil || !strings.Contains(err.Error(), "no signature") {
+		t.Fatalf("PerformUpdate() error = %v, want a missing signature error", err)
+	}
+}
+
+func TestPerformUpdateTrustedKeysRequireSignature(t *testing.T) {
+	pub, _, _ := ed25519.GenerateKey(nil)
+	bin := []byte("new binary")
+	release := fakeRelease(t, "v1.1.0", map[string][]byte{
+		binaryAsset:     bin,
+		"checksums.txt": checksums(map[string][]byte{binaryAsset: bin}),
+	})
+	path := installedBinary(t)
+
+	err := PerformUpdate(release, path, Options{TrustedKeys: []ed25519.PublicKey{pub}})
+	if err == nil || !strings.Contains(err.Error(), "no signature") {
+		t.Fatalf("PerformUpdate() error = %v, want a missing signature error", err)
+	}
+	if got := readFile(t, path); got != "old binary" {
+		t.Errorf("installed binary = %q, want the old one kept", got)
+	}
+}
+
+func tarGz(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		tw.Write([]byte(content))
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	gz.Close()
+	return buf.Bytes()
+}
+
+func zipFile(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, _ := zw.Create(name)
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestPerformUpdateFromArchive(t *testing.T) {
+	linux := tarGz(t, map[string]string{"forge_1.1.0_linux_arm64/forge": "linux arm64", "forge_1.1.0_linux_arm64/README.md": "docs"})
+	windows := zipFile(t, map[string]string{"forge.exe": "windows arm64", "LICENSE": "MIT"})
+	assets := map[string][]byte{
+		"forge_1.1.0_linux_arm64.tar.gz":     linux,
+		"forge_1.1.0_windows_arm64.zip":      windows,
+		"forge_1.1.0_linux_x86_64.tar.gz":    []byte("wrong arch"),
+		"forge_1.1.0_windows_x86_64.zip":     []byte("wrong arch"),
+		"forge_1.1.0_darwin_arm64.tar.gz":    []byte("wrong os"),
+		"forge_1.1.0_linux_arm64.tar.gz.sig": []byte("not an asset"),
+	}
+	assets["checksums.txt"] = checksums(assets)
+	release := fakeRelease(t, "v1.1.0", assets)
+
+	for _, tt := range []struct{ goos, want string }{{"linux", "linux arm64"}, {"windows", "windows arm64"}} {
+		path := installedBinary(t)
+		if err := performUpdate(release, path, tt.goos, "arm64", Options{}); err != nil {
+			t.Fatalf("performUpdate(%s/arm64) error = %v", tt.goos, err)
+		}
+		if got := readFile(t, path); got != tt.want {
+			t.Errorf("%s/arm64 binary = %q, want %q", tt.goos, got, tt.want)
+		}
+	}
+}
+
+func TestPerformUpdateNoMatchingAsset(t *testing.T) {
+	release := fakeRelease(t, "v1.1.0", map[string][]byte{
+		"forge-linux":   []byte("legacy amd64 binary"),
+		"checksums.txt": checksums(map[string][]byte{"forge-linux": []byte("legacy amd64 binary")}),
+	})
+	path := installedBinary(t)
+
+	err := performUpdate(release, path, "linux", "arm64", Options{})
+	if !errors.Is(err, remote.ErrNoBinaryAsset) {
+		t.Fatalf("performUpdate(linux/arm64) error = %v, want ErrNoBinaryAsset", err)
+	}
+	for _, want := range []string{"linux/arm64", "forge-linux", "checksums.txt"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %s", err, want)
+		}
+	}
+	if got := readFile(t, path); got != "old binary" {
+		t.Errorf("binary = %q, should be left untouched", got)
+	}
+
+	if err := performUpdate(release, path, "linux", "amd64", Options{}); err != nil {
+		t.Fatalf("performUpdate(linux/amd64) with a legacy asset error = %v", err)
+	}
+}
+
+func TestRollbackWithoutBackup(t *testing.T) {
+	if err := Rollback(installedBinary(t)); !errors.Is(err, ErrNoBackup) {
+		t.Errorf("Rollback() error = %v, want ErrNoBackup", err)
+	}
+}
+
+func TestCheckUpdateChannels(t *testing.T) {
+	s := newReleaseServer(t)
+	s.add("v1.1.0", false, nil)
+	s.add("v1.2.0-beta.2", true, nil)
+	s.add("v1.2.0-beta.10", true, nil)
+	s.add("v1.0.9", false, nil)
+	s.add("nightly", true, nil)
+
+	tests := []struct {
+		current, channel string
+		want             string
+		available        bool
+	}{
+		{"v1.0.0", ChannelStable, "v1.1.0", true},
+		{"v1.1.0", ChannelStable, "v1.1.0", false},
+		{"v1.1.0", ChannelBeta, "v1.2.0-beta.10", true},
+		{"v1.2.0-beta.10", ChannelBeta, "v1.2.0-beta.10", false},
+		// A beta user on the stable channel is not downgraded
+		{"v1.2.0-beta.2", ChannelStable, "v1.1.0", false},
+		{"development", ChannelStable, "v1.1.0", true},
+	}
+	for _, tt := range tests {
+		release, available, err := CheckUpdate(tt.current, tt.channel)
+		if err != nil {
+			t.Fatalf("CheckUpdate(%s, %s) error = %v", tt.current, tt.channel, err)
+		}
+		if release.TagName != tt.want || available != tt.available {
+			t.Errorf("CheckUpdate(%s, %s) = %s, %v, want %s, %v", tt.current, tt.channel, release.TagName, available, tt.want, tt.available)
+		}
+	}
+
+	s.add("v1.2.0", false, nil)
+	if release, ok, _ := CheckUpdate("v1.2.0-beta.10", ChannelStable); !ok || release.TagName != "v1.2.0" {
+		t.Errorf("a beta should be upgraded to its release, got %v, %v", release, ok)
+	}
+
+	if _, _, err := CheckUpdate("v1.0.0", "nightly"); err == nil {
+		t.Error("CheckUpdate() with an unknown channel should fail")
+	}
+}
+
+func TestPinnedRelease(t *testing.T) {
+	s := newReleaseServer(t)
+	s.add("v0.4.2", false, nil)
+	s.add("v0.5.0", false, nil)
+
+	for _, tag := range []string{"v0.4.2", "0.4.2"} {
+		release, err := PinnedRelease(tag)
+		if err != nil || release.TagName != "v0.4.2" || release.Body != "Notes for v0.4.2" {
+			t.Errorf("PinnedRelease(%s) = %+v, %v", tag, release, err)
+		}
+	}
+	if _, err := PinnedRelease("v9.9.9"); !errors.Is(err, remote.ErrReleaseNotFound) {
+		t.Errorf("PinnedRelease(v9.9.9) error = %v, want ErrReleaseNotFound", err)
+	}
+}
@@ -0,0 +1,286 @@
+package version
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Constraint is a version range expression such as "^1.2", "~1.2.3",
+// ">=1.0 <2.0" or "^1.0 || ^2.0". Space- or comma-separated comparators
+// must all match; "||" separates alternatives.
+//
+// Supported comparators:
+//
+//	1.2.3, =1.2.3   exactly 1.2.3
+//	1.2, 1.2.x      >=1.2.0 <1.3.0 (partial versions are ranges)
+//	>, >=, <, <=    ordering against a (possibly partial) version
+//	~1.2.3          >=1.2.3 <1.3.0 (patch-level changes)
+//	^1.2.3          >=1.2.3 <2.0.0 (changes that do not modify the left-most non-zero part)
+//	*, x            any version
+//
+// As in npm, a pre-release version only matches when a comparator in the
+// same alternative names a pre-release of the same major.minor.patch, so
+// "^1.2" does not select 2.0.0-beta or 1.5.0-rc.1.
+type Constraint struct {
+	raw     string
+	sets    [][]comparator
+	minimum bool // a bare version from ParseRequirement
+}
+
+type comparator struct {
+	op string // one of =, >, >=, <, <=
+	v  Version
+}
+
+// ParseConstraint parses a constraint expression
+func ParseConstraint(s string) (*Constraint, error) {
+	c := &Constraint{raw: strings.TrimSpace(s)}
+	if c.raw == "" {
+		return nil, fmt.Errorf("empty version constraint")
+	}
+
+	for _, alt := range strings.Split(c.raw, "||") {
+		set, err := parseSet(alt)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version constraint %q: %w", s, err)
+		}
+		c.sets = append(c.sets, set)
+	}
+	return c, nil
+}
+
+// ParseRequirement parses a requirement such as min_forge_version: a bare
+// version means that version or newer, anything else is a constraint
+func ParseRequirement(s string) (*Constraint, error) {
+	if Validate(strings.TrimSpace(s)) == nil {
+		c, err := ParseConstraint(">=" + strings.TrimSpace(s))
+		if err != nil {
+			return nil, err
+		}
+		c.raw = strings.TrimSpace(s)
+		c.minimum = true
+		return c, nil
+	}
+	return ParseConstraint(s)
+}
+
+// IsMinimum reports whether the constraint came from a bare version given
+// to ParseRequirement
+func (c *Constraint) IsMinimum() bool {
+	return c.minimum
+}
+
+// String returns the constraint as written
+func (c *Constraint) String() string {
+	return c.raw
+}
+
+// Check reports whether v satisfies the constraint
+func (c *Constraint) Check(v Version) bool {
+	for _, set := range c.sets {
+		if setMatches(set, v, false) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckIncludingPrerelease is Check without the pre-release rule, for
+// testing a running pre-release build against a requirement: 1.3.0-beta
+// satisfies ">=1.2" but not ">=1.3.0"
+func (c *Constraint) CheckIncludingPrerelease(v Version) bool {
+	for _, set := range c.sets {
+		if setMatches(set, v, true) {
+			return true
+		}
+	}
+	return false
+}
+
+// Satisfies parses v and reports whether it satisfies the constraint
+func (c *Constraint) Satisfies(v string) (bool, error) {
+	parsed, err := Parse(v)
+	if err != nil {
+		return false, err
+	}
+	return c.Check(parsed), nil
+}
+
+func setMatches(set []comparator, v Version, includePrerelease bool) bool {
+	for _, cmp := range set {
+		if !cmp.matches(v) {
+			return false
+		}
+	}
+	if includePrerelease || !v.IsPrerelease() {
+		return true
+	}
+	for _, cmp := range set {
+		if cmp.v.IsPrerelease() && cmp.v.Major == v.Major && cmp.v.Minor == v.Minor && cmp.v.Patch == v.Patch {
+			return true
+		}
+	}
+	return false
+}
+
+func (c comparator) matches(v Version) bool {
+	cmp := v.Compare(c.v)
+	switch c.op {
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	default:
+		return cmp == 0
+	}
+}
+
+// parseSet parses one alternative (a list of comparators)
+func parseSet(s string) ([]comparator, error) {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ' ' || r == '\t' || r == ','
+	})
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty alternative")
+	}
+
+	var set []comparator
+	for i := 0; i < len(fields); i++ {
+		tok := fields[i]
+		// Allow a space between operator and version: ">= 1.0"
+		if isOperator(tok) && i+1 < len(fields) {
+			i++
+			tok += fields[i]
+		}
+		cmps, err := parseComparator(tok)
+		if err != nil {
+			return nil, err
+		}
+		set = append(set, cmps...)
+	}
+	return set, nil
+}
+
+func isOperator(s string) bool {
+	switch s {
+	case "=", ">", ">=", "<", "<=", "~", "^":
+		return true
+	}
+	return false
+}
+
+// parseComparator expands one comparator token into primitive comparators
+func parseComparator(tok string) ([]comparator, error) {
+	op := ""
+	for _, candidate := range []string{">=", "<=", ">", "<", "=", "~", "^"} {
+		if strings.HasPrefix(tok, candidate) {
+			op = candidate
+			break
+		}
+	}
+	rest := strings.TrimPrefix(tok, op)
+
+	v, n, err := parseRangeVersion(rest)
+	if err != nil {
+		return nil, err
+	}
+
+	anyVersion := []comparator{{op: ">=", v: Version{}}}
+	switch op {
+	case "", "=":
+		if n == 0 {
+			return anyVersion, nil
+		}
+		if n == 3 {
+			return []comparator{{op: "=", v: v}}, nil
+		}
+		return []comparator{{op: ">=", v: v}, {op: "<", v: bump(v, n)}}, nil
+	case ">":
+		if n == 0 {
+			return nil, fmt.Errorf("%q matches no version", tok)
+		}
+		if n == 3 {
+			return []comparator{{op: ">", v: v}}, nil
+		}
+		return []comparator{{op: ">=", v: bump(v, n)}}, nil
+	case ">=":
+		return []comparator{{op: ">=", v: v}}, nil
+	case "<":
+		if n == 0 {
+			return nil, fmt.Errorf("%q matches no version", tok)
+		}
+		return []comparator{{op: "<", v: v}}, nil
+	case "<=":
+		if n == 0 {
+			return anyVersion, nil
+		}
+		if n == 3 {
+			return []comparator{{op: "<=", v: v}}, nil
+		}
+		return []comparator{{op: "<", v: bump(v, n)}}, nil
+	case "~":
+		if n == 0 {
+			return anyVersion, nil
+		}
+		upper := bump(v, 2)
+		if n == 1 {
+			upper = bump(v, 1)
+		}
+		return []comparator{{op: ">=", v: v}, {op: "<", v: upper}}, nil
+	case "^":
+		if n == 0 {
+			return anyVersion, nil
+		}
+		return []comparator{{op: ">=", v: v}, {op: "<", v: caretUpper(v, n)}}, nil
+	}
+	return nil, fmt.Errorf("unknown operator in %q", tok)
+}
+
+// parseRangeVersion parses a version that may be partial or use x/*
+// wildcards, returning the number of specified numeric parts (0-3)
+func parseRangeVersion(s string) (Version, int, error) {
+	parts := strings.Split(strings.TrimPrefix(s, "v"), ".")
+	// Drop trailing wildcards: "1.2.x" is the same as "1.2"
+	for len(parts) > 0 && isWildcard(parts[len(parts)-1]) {
+		parts = parts[:len(parts)-1]
+	}
+	if len(parts) == 0 {
+		return Version{}, 0, nil
+	}
+	for _, p := range parts {
+		if isWildcard(p) {
+			return Version{}, 0, fmt.Errorf("wildcard must be the last part in %q", s)
+		}
+	}
+	return parsePartial(strings.Join(parts, "."))
+}
+
+func isWildcard(s string) bool {
+	return s == "x" || s == "X" || s == "*"
+}
+
+// bump returns the exclusive upper bound for a version with n specified
+// parts: 1.2 -> 1.3.0, 1 -> 2.0.0
+func bump(v Version, n int) Version {
+	if n == 1 {
+		return Version{Major: v.Major + 1}
+	}
+	return Version{Major: v.Major, Minor: v.Minor + 1}
+}
+
+// caretUpper returns the exclusive upper bound for ^v: the next version
+// that changes the left-most non-zero specified part
+func caretUpper(v Version, n int) Version {
+	switch {
+	case v.Major > 0 || n == 1:
+		return Version{Major: v.Major + 1}
+	case v.Minor > 0 || n == 2:
+		return Version{Minor: v.Minor + 1}
+	default:
+		return Version{Patch: v.Patch + 1}
+	}
+}
@@ -6,68 +6,226 @@ import (
 	"strings"
 )
 
-// IsNewerVersion compares two semantic versions (e.g., "0.1.5" vs "0.2.0")
-// Returns true if latest is newer than current.
-// If current is "development" (local build), any release version is considered newer.
-// Example: IsNewerVersion("0.1.5", "0.2.0") returns true
-func IsNewerVersion(current, latest string) (bool, error) {
-	// Development builds always consider any release as newer
-	if current == "development" {
-		return true, nil
-	}
+// Version is a parsed SemVer 2.0.0 version
+type Version struct {
+	Major uint64
+	Minor uint64
+	Patch uint64
+	Pre   []string // pre-release identifiers, e.g. ["beta", "2"]
+	Build []string // build metadata identifiers; ignored for precedence
+}
 
-	currParts, err := parseVersion(current)
+// Parse parses a full semantic version such as "1.2.3", "v1.2.3-rc.1" or
+// "1.2.3+build.5". A leading "v" is accepted, as used in release tags.
+func Parse(s string) (Version, error) {
+	v, n, err := parsePartial(s)
 	if err != nil {
-		return false, fmt.Errorf("invalid current version format: %w", err)
+		return Version{}, err
+	}
+	if n < 3 {
+		return Version{}, fmt.Errorf("expected semantic version format (major.minor.patch), got: %s", s)
 	}
+	return v, nil
+}
 
-	latestParts, err := parseVersion(latest)
-	if err != nil {
-		return false, fmt.Errorf("invalid latest version format: %w", err)
+// parsePartial parses a version that may omit minor and patch (as allowed
+// in constraints) and returns how many numeric parts were present
+func parsePartial(s string) (Version, int, error) {
+	var v Version
+	raw := s
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if s == "" {
+		return v, 0, fmt.Errorf("empty version")
 	}
 
-	// Compare major.minor.patch
-	for i := 0; i < 3; i++ {
-		if latestParts[i] > currParts[i] {
-			return true, nil
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		build, err := splitIdentifiers(s[i+1:], false)
+		if err != nil {
+			return v, 0, fmt.Errorf("invalid build metadata in %q: %w", raw, err)
 		}
-		if latestParts[i] < currParts[i] {
-			return false, nil
+		v.Build = build
+		s = s[:i]
+	}
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		pre, err := splitIdentifiers(s[i+1:], true)
+		if err != nil {
+			return v, 0, fmt.Errorf("invalid pre-release in %q: %w", raw, err)
 		}
+		v.Pre = pre
+		s = s[:i]
 	}
 
-	// Versions are equal
-	return false, nil
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return v, 0, fmt.Errorf("expected semantic version format (major.minor.patch), got: %s", raw)
+	}
+	nums := [3]*uint64{&v.Major, &v.Minor, &v.Patch}
+	for i, p := range parts {
+		n, err := parseNumeric(p)
+		if err != nil {
+			return v, 0, fmt.Errorf("invalid version number at position %d: %s", i, raw)
+		}
+		*nums[i] = n
+	}
+	if (v.Pre != nil || v.Build != nil) && len(parts) < 3 {
+		return v, 0, fmt.Errorf("expected semantic version format (major.minor.patch), got: %s", raw)
+	}
+
+	return v, len(parts), nil
 }
 
-// parseVersion converts a version string like "0.1.5" into [0, 1, 5]
-// Handles versions with leading "v" prefix (e.g., "v0.2.0")
-func parseVersion(v string) ([3]int, error) {
-	var result [3]int
+// parseNumeric parses a numeric identifier, rejecting leading zeros
+func parseNumeric(s string) (uint64, error) {
+	if s == "" || (len(s) > 1 && s[0] == '0') {
+		return 0, fmt.Errorf("invalid numeric identifier %q", s)
+	}
+	return strconv.ParseUint(s, 10, 64)
+}
 
-	// Remove common prefixes
-	v = strings.TrimPrefix(v, "v")
-	v = strings.TrimSpace(v)
+// splitIdentifiers splits dot-separated identifiers, validating characters;
+// numeric pre-release identifiers must not have leading zeros
+func splitIdentifiers(s string, pre bool) ([]string, error) {
+	ids := strings.Split(s, ".")
+	for _, id := range ids {
+		if id == "" {
+			return nil, fmt.Errorf("empty identifier")
+		}
+		for _, r := range id {
+			if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '-') {
+				return nil, fmt.Errorf("invalid character %q in identifier %q", r, id)
+			}
+		}
+		if pre && isNumeric(id) && len(id) > 1 && id[0] == '0' {
+			return nil, fmt.Errorf("numeric identifier %q has a leading zero", id)
+		}
+	}
+	return ids, nil
+}
 
-	// Split by dots
-	parts := strings.Split(v, ".")
-	if len(parts) < 3 {
-		return result, fmt.Errorf("expected semantic version format (major.minor.patch), got: %s", v)
+func isNumeric(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
 	}
+	return s != ""
+}
 
-	// Parse major, minor, patch
-	for i := 0; i < 3; i++ {
-		// Handle pre-release versions (e.g., "0.2.0-alpha") - just take the numeric part
-		numStr := strings.FieldsFunc(parts[i], func(r rune) bool {
-			return r == '-' || r == '+' || r == '.'
-		})[0]
+// String returns the canonical form without a "v" prefix
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if len(v.Pre) > 0 {
+		s += "-" + strings.Join(v.Pre, ".")
+	}
+	if len(v.Build) > 0 {
+		s += "+" + strings.Join(v.Build, ".")
+	}
+	return s
+}
 
-		num, err := strconv.Atoi(numStr)
-		if err != nil {
-			return result, fmt.Errorf("invalid version number at position %d: %s", i, parts[i])
+// IsPrerelease reports whether the version has pre-release identifiers
+func (v Version) IsPrerelease() bool {
+	return len(v.Pre) > 0
+}
+
+// Compare returns -1, 0 or 1 following SemVer precedence. Build metadata
+// is ignored, so 1.0.0+a and 1.0.0+b compare equal.
+func (v Version) Compare(o Version) int {
+	if c := compareUint(v.Major, o.Major); c != 0 {
+		return c
+	}
+	if c := compareUint(v.Minor, o.Minor); c != 0 {
+		return c
+	}
+	if c := compareUint(v.Patch, o.Patch); c != 0 {
+		return c
+	}
+
+	// A pre-release has lower precedence than the normal version
+	switch {
+	case len(v.Pre) == 0 && len(o.Pre) == 0:
+		return 0
+	case len(v.Pre) == 0:
+		return 1
+	case len(o.Pre) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(v.Pre) && i < len(o.Pre); i++ {
+		if c := compareIdentifier(v.Pre[i], o.Pre[i]); c != 0 {
+			return c
 		}
-		result[i] = num
 	}
+	// A larger set of pre-release fields has higher precedence
+	return compareUint(uint64(len(v.Pre)), uint64(len(o.Pre)))
+}
+
+// compareIdentifier compares pre-release identifiers: numeric ones
+// numerically, alphanumeric ones lexically, numeric lower than alphanumeric
+func compareIdentifier(a, b string) int {
+	aNum, bNum := isNumeric(a), isNumeric(b)
+	switch {
+	case aNum && bNum:
+		an, _ := strconv.ParseUint(a, 10, 64)
+		bn, _ := strconv.ParseUint(b, 10, 64)
+		return compareUint(an, bn)
+	case aNum:
+		return -1
+	case bNum:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func compareUint(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// Compare parses and compares two version strings
+func Compare(a, b string) (int, error) {
+	va, err := Parse(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := Parse(b)
+	if err != nil {
+		return 0, err
+	}
+	return va.Compare(vb), nil
+}
+
+// IsNewerVersion compares two semantic versions (e.g., "0.1.5" vs "0.2.0")
+// Returns true if latest is newer than current.
+// If current is "development" (local build), any release version is considered newer.
+// Pre-release versions follow SemVer precedence: 0.2.0-alpha < 0.2.0.
+// Example: IsNewerVersion("0.1.5", "0.2.0") returns true
+func IsNewerVersion(current, latest string) (bool, error) {
+	// Development builds always consider any release as newer
+	if current == "development" {
+		return true, nil
+	}
+
+	curr, err := Parse(current)
+	if err != nil {
+		return false, fmt.Errorf("invalid current version format: %w", err)
+	}
+
+	next, err := Parse(latest)
+	if err != nil {
+		return false, fmt.Errorf("invalid latest version format: %w", err)
+	}
+
+	return next.Compare(curr) > 0, nil
+}
 
-	return result, nil
+// Validate returns an error if v is not a full semantic version
+func Validate(v string) error {
+	_, err := Parse(v)
+	return err
 }
@@ -56,3 +56,229 @@ func TestIsNewerVersion(t *testing.T) {
 		})
 	}
 }
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"1.9.0", "1.9.0", false},
+		{"v1.10.0", "1.10.0", false},
+		{"1.0.0-alpha", "1.0.0-alpha", false},
+		{"1.0.0-alpha.1", "1.0.0-alpha.1", false},
+		{"1.0.0-0.3.7", "1.0.0-0.3.7", false},
+		{"1.0.0-x.7.z.92", "1.0.0-x.7.z.92", false},
+		{"1.0.0-x-y-z.--", "1.0.0-x-y-z.--", false},
+		{"1.0.0-alpha+001", "1.0.0-alpha+001", false},
+		{"1.0.0+20130313144700", "1.0.0+20130313144700", false},
+		{"1.0.0-beta+exp.sha.5114f85", "1.0.0-beta+exp.sha.5114f85", false},
+
+		// Invalid per the SemVer 2.0.0 grammar
+		{"1.2", "", true},
+		{"1.2.3.4", "", true},
+		{"01.1.1", "", true},
+		{"1.01.1", "", true},
+		{"1.1.01", "", true},
+		{"1.0.0-01", "", true},
+		{"1.0.0-alpha..1", "", true},
+		{"1.0.0-", "", true},
+		{"1.0.0+", "", true},
+		{"1.0.0-alpha_beta", "", true},
+		{"-1.0.0", "", true},
+		{"1.0.0-", "", true},
+		{"", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if err == nil && got.String() != tt.want {
+				t.Errorf("Parse(%q) = %q, want %q", tt.input, got.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestComparePrecedence(t *testing.T) {
+	// Ordered examples from the SemVer 2.0.0 specification, section 11
+	ordered := []string{
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"2.0.0",
+		"2.1.0",
+		"2.1.1",
+	}
+
+	for i := range ordered {
+		for j := range ordered {
+			got, err := Compare(ordered[i], ordered[j])
+			if err != nil {
+				t.Fatalf("Compare(%q, %q) error = %v", ordered[i], ordered[j], err)
+			}
+			want := 0
+			if i < j {
+				want = -1
+			} else if i > j {
+				want = 1
+			}
+			if got != want {
+				t.Errorf("Compare(%q, %q) = %d, want %d", ordered[i], ordered[j], got, want)
+			}
+		}
+	}
+
+	// Build metadata does not affect precedence
+	if got, _ := Compare("1.0.0+build.1", "1.0.0+build.2"); got != 0 {
+		t.Errorf("Compare() with build metadata = %d, want 0", got)
+	}
+}
+
+func TestIsNewerVersionPrerelease(t *testing.T) {
+	tests := []struct {
+		current, latest string
+		want            bool
+	}{
+		{"0.2.0-alpha", "0.2.0", true},
+		{"0.2.0", "0.2.0-alpha", false},
+		{"0.2.0-alpha", "0.2.0-alpha", false},
+		{"0.2.0-beta.2", "0.2.0-beta.11", true},
+		{"0.2.0+build.1", "0.2.0+build.2", false},
+	}
+
+	for _, tt := range tests {
+		got, err := IsNewerVersion(tt.current, tt.latest)
+		if err != nil {
+			t.Fatalf("IsNewerVersion(%q, %q) error = %v", tt.current, tt.latest, err)
+		}
+		if got != tt.want {
+			t.Errorf("IsNewerVersion(%q, %q) = %v, want %v", tt.current, tt.latest, got, tt.want)
+		}
+	}
+}
+
+func TestConstraint(t *testing.T) {
+	tests := []struct {
+		constraint string
+		version    string
+		want       bool
+	}{
+		// Caret
+		{"^1.2", "1.2.0", true},
+		{"^1.2", "1.9.9", true},
+		{"^1.2", "2.0.0", false},
+		{"^1.2", "1.1.9", false},
+		{"^1.2.3", "1.2.3", true},
+		{"^1.2.3", "1.2.2", false},
+		{"^0.2.3", "0.2.9", true},
+		{"^0.2.3", "0.3.0", false},
+		{"^0.0.3", "0.0.3", true},
+		{"^0.0.3", "0.0.4", false},
+		{"^0", "0.9.9", true},
+		{"^0", "1.0.0", false},
+
+		// Tilde
+		{"~1.2.3", "1.2.9", true},
+		{"~1.2.3", "1.3.0", false},
+		{"~1.2", "1.2.0", true},
+		{"~1", "1.9.0", true},
+		{"~1", "2.0.0", false},
+
+		// Comparators and ranges
+		{">=1.0 <2.0", "1.5.0", true},
+		{">=1.0 <2.0", "2.0.0", false},
+		{">=1.0, <2.0", "0.9.0", false},
+		{">= 1.0 < 2.0", "1.0.0", true},
+		{">1.2", "1.2.9", false},
+		{">1.2", "1.3.0", true},
+		{"<=1.2", "1.2.9", true},
+		{"<=1.2", "1.3.0", false},
+		{"1.2.3", "1.2.3", true},
+		{"=1.2.3", "1.2.4", false},
+		{"1.2.x", "1.2.7", true},
+		{"1.x", "1.7.0", true},
+		{"1.x", "2.0.0", false},
+		{"*", "3.4.5", true},
+
+		// Alternatives
+		{"^1.0 || ^3.0", "3.1.0", true},
+		{"^1.0 || ^3.0", "2.1.0", false},
+
+		// Pre-releases only match when the range names one on the same tuple
+		{"^1.2", "1.5.0-rc.1", false},
+		{"^1.2", "2.0.0-beta", false},
+		{">=1.2.3-beta.2", "1.2.3-beta.3", true},
+		{">=1.2.3-beta.2", "1.2.3-beta.1", false},
+		{">=1.2.3-beta.2", "1.2.4-beta.1", false},
+		{"^1.2.3-beta.2", "1.2.3", true},
+		{"*", "1.0.0-alpha", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.constraint+"_"+tt.version, func(t *testing.T) {
+			c, err := ParseConstraint(tt.constraint)
+			if err != nil {
+				t.Fatalf("ParseConstraint(%q) error = %v", tt.constraint, err)
+			}
+			got, err := c.Satisfies(tt.version)
+			if err != nil {
+				t.Fatalf("Satisfies(%q) error = %v", tt.version, err)
+			}
+			if got != tt.want {
+				t.Errorf("%q.Satisfies(%q) = %v, want %v", tt.constraint, tt.version, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseConstraintErrors(t *testing.T) {
+	for _, input := range []string{"", "^", ">=1.0 ||", "~1.x.3", "^01.2", ">*", "<x", "1.2.3.4"} {
+		if _, err := ParseConstraint(input); err == nil {
+			t.Errorf("ParseConstraint(%q) should fail", input)
+		}
+	}
+}
+
+func TestParseRequirement(t *testing.T) {
+	tests := []struct {
+		req, v  string
+		minimum bool
+		want    bool
+	}{
+		{"0.3.0", "0.3.0", true, true},
+		{"v0.3.0", "1.4.0", true, true},
+		{"0.3.0", "0.2.9", true, false},
+		{"~0.3", "0.3.9", false, true},
+		{"~0.3", "0.4.0", false, false},
+	}
+	for _, tt := range tests {
+		c, err := ParseRequirement(tt.req)
+		if err != nil {
+			t.Fatalf("ParseRequirement(%q) error = %v", tt.req, err)
+		}
+		if c.IsMinimum() != tt.minimum || c.String() != tt.req {
+			t.Errorf("ParseRequirement(%q) = %q, minimum %v", tt.req, c, c.IsMinimum())
+		}
+		if got, _ := c.Satisfies(tt.v); got != tt.want {
+			t.Errorf("%q satisfies %q = %v, want %v", tt.v, tt.req, got, tt.want)
+		}
+	}
+	if _, err := ParseRequirement("soon"); err == nil {
+		t.Error("ParseRequirement(soon) should fail")
+	}
+
+	c, _ := ParseRequirement("0.3.0")
+	beta, _ := Parse("0.4.0-beta.1")
+	if c.Check(beta) || !c.CheckIncludingPrerelease(beta) {
+		t.Error("a running pre-release should only satisfy a minimum when pre-releases are included")
+	}
+}